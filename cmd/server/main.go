@@ -13,6 +13,7 @@ import (
 	"go-backend-service/internal/db"
 	"go-backend-service/internal/lifecycle"
 	"go-backend-service/internal/logger"
+	"go-backend-service/internal/middleware"
 	"go-backend-service/internal/mongo"
 	"go-backend-service/internal/otp"
 	"go-backend-service/internal/redis"
@@ -143,23 +144,129 @@ func main() {
 		MaxAttempts:     cfg.OTP.MaxAttempts,
 		TenantCacheTTL:  cfg.OTP.TenantCacheTTL,
 		ProviderTimeout: cfg.OTP.ProviderTimeout,
+		FailoverTimeout: cfg.OTP.FailoverTimeout,
+		ResendCooldown:  cfg.OTP.ResendCooldown,
+		MaxResends:      cfg.OTP.MaxResends,
+		ResendMode:      cfg.OTP.ResendMode,
 	}
 	otpTenantSettingsProvider := repository.NewCachedTenantSettingsProvider(rdb, tenantSettingsRepo, otpConfig.TenantCacheTTL)
+	var phoneKeyHasher *otp.PhoneKeyHasher
+	if cfg.OTP.PhoneKeySecret != "" {
+		phoneKeyHasher, err = otp.NewPhoneKeyHasher([]byte(cfg.OTP.PhoneKeySecret))
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to initialize OTP phone key hasher")
+		}
+		log.Info().Bool("legacy_reads", cfg.OTP.PhoneKeyLegacyReads).Msg("OTP phone numbers hashed in Redis keys")
+	} else {
+		log.Warn().Msg("OTP_PHONE_KEY_SECRET not set; Redis keys contain plaintext phone numbers")
+	}
 	otpStore := repository.NewRedisOTPStore(rdb)
-	otpSMSProvider := sms.NewFakeProviderWithDelay(cfg.OTP.FakeSMSMinDelay, cfg.OTP.FakeSMSMaxDelay)
+	otpStore.SetPhoneKeyHasher(phoneKeyHasher)
+	otpStore.SetLegacyPhoneKeyReads(cfg.OTP.PhoneKeyLegacyReads)
+	fakeSMSProvider := sms.NewFakeProviderWithDelay(cfg.OTP.FakeSMSMinDelay, cfg.OTP.FakeSMSMaxDelay)
 	if cfg.OTP.FakeSMSDebugCodeRedis && cfg.App.GinMode != gin.ReleaseMode {
-		otpSMSProvider = sms.NewFakeProviderWithDelayAndDebugCodeCapture(
+		fakeSMSProvider = sms.NewFakeProviderWithDelayAndDebugCodeCapture(
 			cfg.OTP.FakeSMSMinDelay,
 			cfg.OTP.FakeSMSMaxDelay,
 			rdb,
 			minDuration(cfg.OTP.FakeSMSDebugCodeTTL, otpConfig.TTL),
 		)
+		fakeSMSProvider.SetPhoneKeyHasher(phoneKeyHasher)
+	}
+	faults, err := fakeSMSFaults(cfg.OTP)
+	if err == nil {
+		err = fakeSMSProvider.SetFaults(faults)
+	}
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid fake SMS fault injection settings")
 	}
+	smsCredentials := repository.NewTenantSMSCredentialsProvider(tenantSettingsRepo)
+	otpSMSProvider := sms.NewRegistry()
+	smsRetryConfig := sms.RetryConfig{
+		MaxAttempts: cfg.OTP.SMSRetryMaxAttempts,
+		BaseDelay:   cfg.OTP.SMSRetryBaseDelay,
+		MaxDelay:    cfg.OTP.SMSRetryMaxDelay,
+	}
+	smsRetryBudget := sms.NewRetryBudget(cfg.OTP.SMSRetryBudgetRatio, cfg.OTP.SMSRetryBudgetBurst)
+	// Retries wrap the circuit breaker so every attempt counts towards the provider's health.
+	registerSMSProvider := func(name string, provider otp.SMSProvider) {
+		breaker := sms.NewCircuitBreaker(name, provider, smsCircuitBreakerConfig(cfg.OTP, name))
+		otpSMSProvider.Register(name, sms.NewRetryingProvider(name, breaker, smsRetryConfig, smsRetryBudget))
+	}
+	registerSMSProvider(string(repository.SMSProviderKavenegar), sms.NewKavenegarProvider(cfg.OTP.KavenegarBaseURL, nil, smsCredentials, cfg.OTP.KavenegarTemplate))
+	registerSMSProvider(string(repository.SMSProviderTwilio), sms.NewTwilioProvider(cfg.OTP.TwilioBaseURL, nil, smsCredentials, cfg.OTP.TwilioSender))
+	registerSMSProvider(string(repository.SMSProviderGhasedak), sms.NewGhasedakProvider(cfg.OTP.GhasedakBaseURL, nil, smsCredentials, cfg.OTP.GhasedakTemplate))
+	// Fake entries are registered last so dev environments can shadow real providers.
+	for _, name := range cfg.OTP.SMSFakeProviders {
+		registerSMSProvider(name, fakeSMSProvider)
+	}
+	log.Info().
+		Strs("sms_providers", otpSMSProvider.Providers()).
+		Strs("fake_sms_providers", cfg.OTP.SMSFakeProviders).
+		Msg("SMS provider registry initialized")
 	otpRequestLogger := repository.NewOTPRequestLogRepository(database)
 	otpVerificationLogger := repository.NewOTPVerificationLogRepository(database)
 	otpService := otp.NewService(otpTenantSettingsProvider, otpStore, otpSMSProvider, otpRequestLogger, otpVerificationLogger, otpConfig)
+	phoneNormalizer, err := otp.NewPhoneNormalizer(cfg.OTP.DefaultPhoneRegion)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid OTP_DEFAULT_PHONE_REGION")
+	}
+	otpService.SetPhoneNormalizer(phoneNormalizer)
 	if cfg.OTP.SendRateLimitEnabled {
-		otpService.SetSendRateLimiter(repository.NewRedisOTPSendRateLimiter(rdb, cfg.OTP.SendRateLimitMax, cfg.OTP.SendRateLimitWindow))
+		sendRateLimiter, err := repository.NewRedisOTPSendRateLimiterWithAlgorithm(rdb, cfg.OTP.SendRateLimitAlgorithm, cfg.OTP.SendRateLimitMax, cfg.OTP.SendRateLimitWindow, phoneKeyHasher)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to initialize OTP send rate limiter")
+		}
+		if cfg.OTP.SendRateLimitFallbackEnabled {
+			memoryLimiter := repository.NewMemoryOTPSendRateLimiter(cfg.OTP.SendRateLimitMax, cfg.OTP.SendRateLimitWindow, cfg.OTP.SendRateLimitFallbackMaxKeys)
+			sendRateLimiter = repository.NewFallbackOTPSendRateLimiter(sendRateLimiter, memoryLimiter, cfg.OTP.SendRateLimitFallbackProbeInterval)
+		}
+		otpService.SetSendRateLimiter(sendRateLimiter)
+	}
+	if cfg.OTP.GlobalPhoneRateLimitEnabled {
+		globalPhoneRateLimiter := repository.NewRedisGlobalPhoneRateLimiter(rdb, cfg.OTP.GlobalPhoneRateLimitMax, cfg.OTP.GlobalPhoneRateLimitWindow)
+		globalPhoneRateLimiter.SetPhoneKeyHasher(phoneKeyHasher)
+		otpService.SetGlobalPhoneRateLimiter(globalPhoneRateLimiter)
+	}
+	if cfg.OTP.TenantRateLimitEnabled {
+		otpService.SetTenantSendRateLimiter(repository.NewRedisTenantSendRateLimiter(rdb))
+	}
+	if cfg.OTP.LockoutEnabled {
+		phoneLockout := repository.NewRedisPhoneLockout(rdb, cfg.OTP.LockoutMaxFailures, cfg.OTP.LockoutFailureWindow, cfg.OTP.LockoutDurations)
+		phoneLockout.SetPhoneKeyHasher(phoneKeyHasher)
+		phoneLockout.SetLegacyPhoneKeyReads(cfg.OTP.PhoneKeyLegacyReads)
+		otpService.SetPhoneLockout(phoneLockout)
+	}
+	if len(cfg.OTP.CodeHashKeys) > 0 {
+		codeHashKeys := make(map[string][]byte, len(cfg.OTP.CodeHashKeys))
+		for keyID, secret := range cfg.OTP.CodeHashKeys {
+			codeHashKeys[keyID] = []byte(secret)
+		}
+		codeHasher, err := otp.NewCodeHasher(cfg.OTP.CodeHashKeyID, codeHashKeys)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to initialize OTP code hasher")
+		}
+		otpService.SetCodeHasher(codeHasher)
+		log.Info().Str("code_hash_key_id", cfg.OTP.CodeHashKeyID).Msg("OTP codes hashed with HMAC-SHA256")
+	} else {
+		log.Warn().Msg("OTP_CODE_HASH_KEYS not set; OTP codes hashed with unkeyed SHA-256")
+	}
+	if cfg.OTP.ResendCodeSecret != "" {
+		codeSealer, err := otp.NewCodeSealer([]byte(cfg.OTP.ResendCodeSecret))
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to initialize OTP resend code sealer")
+		}
+		otpService.SetCodeSealer(codeSealer)
+	} else if cfg.OTP.ResendMode == otp.ResendModeSame {
+		log.Warn().Msg("OTP_RESEND_CODE_SECRET not set; same-code resends fall back to rotating the code")
+	}
+	var otpIPRateLimit *middleware.IPRateLimit
+	if cfg.OTP.IPRateLimitEnabled {
+		allowlist, err := middleware.ParseIPAllowlist(cfg.OTP.IPRateLimitAllowlist)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid OTP IP rate limit allowlist")
+		}
+		otpIPRateLimit = middleware.NewIPRateLimit(repository.NewRedisIPRateLimiter(rdb, cfg.OTP.IPRateLimitMax, cfg.OTP.IPRateLimitWindow), allowlist)
 	}
 	log.Info().Msg("Repositories initialized successfully")
 
@@ -192,7 +299,11 @@ func main() {
 
 	// Create Gin router (without default logger to use our structured JSON logger)
 	router := gin.New()
-	log.Debug().Msg("Gin router created")
+	// Only X-Forwarded-For set by a trusted proxy is believed; otherwise c.ClientIP() is the TCP peer.
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatal().Err(err).Msg("Invalid trusted proxies")
+	}
+	log.Debug().Strs("trusted_proxies", cfg.Server.TrustedProxies).Msg("Gin router created")
 
 	// Setup middleware
 	log.Debug().Msg("Setting up middleware...")
@@ -201,7 +312,7 @@ func main() {
 
 	// Setup routes (pass lifecycle manager and repositories)
 	log.Debug().Msg("Setting up routes...")
-	api.SetupRoutes(router, lifecycleMgr, tenantSettingsRepo, tenantSettingsInsertRepo, redisRepo, mongoRepo, otpService)
+	api.SetupRoutes(router, lifecycleMgr, tenantSettingsRepo, tenantSettingsInsertRepo, redisRepo, mongoRepo, otpIPRateLimit, otpService)
 	log.Info().Msg("Routes setup completed")
 
 	// Create and start server
@@ -211,6 +322,22 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed to start server")
 	}
 
+	// Tenant admin endpoints such as phone unlock are only served by the admin listener
+	var adminSrv *server.Server
+	if cfg.Server.AdminEnabled {
+		adminRouter := gin.New()
+		api.SetupMiddleware(adminRouter)
+		api.SetupAdminRoutes(adminRouter, otpService)
+		adminAddr := fmt.Sprintf("%s:%d", cfg.Server.AdminHost, cfg.Server.AdminPort)
+		adminSrv = server.NewWithAddr(cfg, adminAddr, adminRouter)
+		if err := adminSrv.Start(); err != nil {
+			log.Fatal().Err(err).Msg("Failed to start admin server")
+		}
+		log.Info().Str("address", adminAddr).Msg("Admin HTTP server is running")
+	} else {
+		log.Debug().Msg("Admin HTTP server is disabled")
+	}
+
 	// Mark application as ready
 	lifecycleMgr.SetState(lifecycle.StateReady)
 
@@ -254,6 +381,11 @@ func main() {
 	} else {
 		log.Info().Msg("HTTP server shutdown completed successfully")
 	}
+	if adminSrv != nil {
+		if err := adminSrv.Shutdown(ctx); err != nil {
+			log.Error().Err(err).Msg("Error during admin HTTP server shutdown")
+		}
+	}
 
 	// Close database connection pool
 	log.Info().Msg("Closing database connection pool...")
@@ -288,6 +420,46 @@ func main() {
 		Msg("Server exited gracefully")
 }
 
+func fakeSMSFaults(otpCfg config.OTPConfig) (sms.FakeFaults, error) {
+	phoneOutcomes := make(map[string]sms.FakeOutcome, len(otpCfg.FakeSMSPhoneOutcomes))
+	for suffix, name := range otpCfg.FakeSMSPhoneOutcomes {
+		outcome, err := sms.ParseFakeOutcome(name)
+		if err != nil {
+			return sms.FakeFaults{}, fmt.Errorf("OTP_FAKE_SMS_PHONE_OUTCOMES: %w", err)
+		}
+		phoneOutcomes[suffix] = outcome
+	}
+
+	return sms.FakeFaults{
+		ErrorRate:           otpCfg.FakeSMSErrorRate,
+		HangRate:            otpCfg.FakeSMSHangRate,
+		LatencyDistribution: otpCfg.FakeSMSLatencyDistribution,
+		ParetoAlpha:         otpCfg.FakeSMSParetoAlpha,
+		LatencyCap:          otpCfg.FakeSMSLatencyCap,
+		PhoneOutcomes:       phoneOutcomes,
+	}, nil
+}
+
+// smsCircuitBreakerConfig applies the per-provider overrides on top of the global thresholds.
+func smsCircuitBreakerConfig(otpCfg config.OTPConfig, name string) sms.CircuitBreakerConfig {
+	breakerCfg := sms.CircuitBreakerConfig{
+		WindowSize:            otpCfg.SMSCircuitWindowSize,
+		MinCalls:              otpCfg.SMSCircuitMinCalls,
+		FailureRateThreshold:  otpCfg.SMSCircuitFailureRate,
+		SlowCallDuration:      otpCfg.SMSCircuitSlowCallDuration,
+		SlowCallRateThreshold: otpCfg.SMSCircuitSlowCallRate,
+		OpenDuration:          otpCfg.SMSCircuitOpenDuration,
+		HalfOpenMaxCalls:      otpCfg.SMSCircuitHalfOpenCalls,
+	}
+	if rate, ok := otpCfg.SMSCircuitFailureRates[name]; ok {
+		breakerCfg.FailureRateThreshold = rate
+	}
+	if duration, ok := otpCfg.SMSCircuitSlowCallDurations[name]; ok {
+		breakerCfg.SlowCallDuration = duration
+	}
+	return breakerCfg
+}
+
 func minDuration(a, b time.Duration) time.Duration {
 	if a < b {
 		return a
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"go-backend-service/internal/middleware"
@@ -15,22 +16,56 @@ import (
 
 type otpFlowService interface {
 	SendOTP(ctx context.Context, req otp.SendRequest) (*otp.SendResponse, error)
+	ResendOTP(ctx context.Context, req otp.ResendRequest) (*otp.SendResponse, error)
 	VerifyOTP(ctx context.Context, req otp.VerifyRequest) (*otp.VerifyResponse, error)
+	VerifyOTPByRequestID(ctx context.Context, req otp.VerifyByRequestIDRequest) (*otp.VerifyResponse, error)
+	GetOTP(ctx context.Context, tenantID int64, requestID string) (*otp.OTPStatus, error)
+	CancelOTP(ctx context.Context, req otp.CancelRequest) error
+	UnlockPhone(ctx context.Context, req otp.UnlockRequest) error
 }
 
 type sendOTPRequest struct {
 	Phone    string                 `json:"phone"`
 	TenantID int64                  `json:"tenant_id"`
+	Purpose  string                 `json:"purpose"`
 	Token    string                 `json:"token"`
 	Metadata map[string]interface{} `json:"metadata"`
 }
 
+type resendOTPRequest struct {
+	TenantID int64                  `json:"tenant_id"`
+	Phone    string                 `json:"phone"`
+	Purpose  string                 `json:"purpose"`
+	Metadata map[string]interface{} `json:"metadata"`
+}
+
 type verifyOTPRequest struct {
 	TenantID int64  `json:"tenant_id"`
 	Phone    string `json:"phone"`
+	Purpose  string `json:"purpose"`
 	Code     string `json:"code"`
 }
 
+type verifyOTPByRequestIDRequest struct {
+	TenantID int64  `json:"tenant_id"`
+	Purpose  string `json:"purpose"`
+	Code     string `json:"code"`
+}
+
+type cancelOTPResponse struct {
+	RequestID string `json:"request_id"`
+	Cancelled bool   `json:"cancelled"`
+}
+
+type unlockPhoneRequest struct {
+	TenantID int64  `json:"tenant_id"`
+	Phone    string `json:"phone"`
+}
+
+type unlockPhoneResponse struct {
+	Unlocked bool `json:"unlocked"`
+}
+
 // SendOTPHandler handles POST /v1/otp/send.
 func SendOTPHandler(service otpFlowService) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -51,6 +86,7 @@ func SendOTPHandler(service otpFlowService) gin.HandlerFunc {
 		resp, err := service.SendOTP(c.Request.Context(), otp.SendRequest{
 			Phone:    req.Phone,
 			TenantID: req.TenantID,
+			Purpose:  req.Purpose,
 			Token:    req.Token,
 			Metadata: req.Metadata,
 		})
@@ -63,6 +99,38 @@ func SendOTPHandler(service otpFlowService) gin.HandlerFunc {
 	}
 }
 
+// ResendOTPHandler handles POST /v1/otp/resend.
+func ResendOTPHandler(service otpFlowService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req resendOTPRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			middleware.ErrorHandler(c, apperrors.ErrBadRequest("Invalid request body"))
+			return
+		}
+		if req.TenantID <= 0 {
+			middleware.ErrorHandler(c, apperrors.ErrBadRequest("tenant_id is required"))
+			return
+		}
+		if strings.TrimSpace(req.Phone) == "" {
+			middleware.ErrorHandler(c, apperrors.ErrBadRequest("phone is required"))
+			return
+		}
+
+		resp, err := service.ResendOTP(c.Request.Context(), otp.ResendRequest{
+			TenantID: req.TenantID,
+			Phone:    req.Phone,
+			Purpose:  req.Purpose,
+			Metadata: req.Metadata,
+		})
+		if err != nil {
+			handleOTPServiceError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
 // VerifyOTPHandler handles POST /v1/otp/verify.
 func VerifyOTPHandler(service otpFlowService) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -87,6 +155,7 @@ func VerifyOTPHandler(service otpFlowService) gin.HandlerFunc {
 		resp, err := service.VerifyOTP(c.Request.Context(), otp.VerifyRequest{
 			TenantID: req.TenantID,
 			Phone:    req.Phone,
+			Purpose:  req.Purpose,
 			Code:     req.Code,
 		})
 		if err != nil {
@@ -98,16 +167,156 @@ func VerifyOTPHandler(service otpFlowService) gin.HandlerFunc {
 	}
 }
 
+// VerifyOTPByRequestIDHandler handles POST /v1/otp/:request_id/verify, which verifies
+// without the phone.
+func VerifyOTPByRequestIDHandler(service otpFlowService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req verifyOTPByRequestIDRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			middleware.ErrorHandler(c, apperrors.ErrBadRequest("Invalid request body"))
+			return
+		}
+		if req.TenantID <= 0 {
+			middleware.ErrorHandler(c, apperrors.ErrBadRequest("tenant_id is required"))
+			return
+		}
+		if strings.TrimSpace(req.Code) == "" {
+			middleware.ErrorHandler(c, apperrors.ErrBadRequest("code is required"))
+			return
+		}
+
+		resp, err := service.VerifyOTPByRequestID(c.Request.Context(), otp.VerifyByRequestIDRequest{
+			TenantID:  req.TenantID,
+			RequestID: c.Param("request_id"),
+			Purpose:   req.Purpose,
+			Code:      req.Code,
+		})
+		if err != nil {
+			handleOTPServiceError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// GetOTPHandler handles GET /v1/otp/:request_id?tenant_id=..., returning the status of
+// an active OTP. Expired, consumed and replaced OTPs are 404.
+func GetOTPHandler(service otpFlowService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID, err := strconv.ParseInt(c.Query("tenant_id"), 10, 64)
+		if err != nil || tenantID <= 0 {
+			middleware.ErrorHandler(c, apperrors.ErrBadRequest("tenant_id is required"))
+			return
+		}
+
+		status, err := service.GetOTP(c.Request.Context(), tenantID, c.Param("request_id"))
+		if err != nil {
+			handleOTPServiceError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, status)
+	}
+}
+
+// CancelOTPHandler handles DELETE /v1/otp/:request_id?tenant_id=...&reason=..., which
+// revokes an active OTP. Expired, consumed and replaced OTPs are 404.
+func CancelOTPHandler(service otpFlowService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID, err := strconv.ParseInt(c.Query("tenant_id"), 10, 64)
+		if err != nil || tenantID <= 0 {
+			middleware.ErrorHandler(c, apperrors.ErrBadRequest("tenant_id is required"))
+			return
+		}
+		reason := strings.TrimSpace(c.Query("reason"))
+		if len(reason) > otp.MaxCancelReasonLength {
+			middleware.ErrorHandler(c, apperrors.ErrBadRequest("reason is too long"))
+			return
+		}
+
+		requestID := c.Param("request_id")
+		if err := service.CancelOTP(c.Request.Context(), otp.CancelRequest{
+			TenantID:  tenantID,
+			RequestID: requestID,
+			Reason:    reason,
+		}); err != nil {
+			handleOTPServiceError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, cancelOTPResponse{RequestID: requestID, Cancelled: true})
+	}
+}
+
+// UnlockPhoneHandler handles POST /v1/otp/admin/unlock for tenant admins. It is only
+// served by the admin listener; the bearer token must be the named tenant's admin token.
+func UnlockPhoneHandler(service otpFlowService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req unlockPhoneRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			middleware.ErrorHandler(c, apperrors.ErrBadRequest("Invalid request body"))
+			return
+		}
+		if req.TenantID <= 0 {
+			middleware.ErrorHandler(c, apperrors.ErrBadRequest("tenant_id is required"))
+			return
+		}
+		if strings.TrimSpace(req.Phone) == "" {
+			middleware.ErrorHandler(c, apperrors.ErrBadRequest("phone is required"))
+			return
+		}
+
+		adminToken, _ := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if err := service.UnlockPhone(c.Request.Context(), otp.UnlockRequest{
+			TenantID:   req.TenantID,
+			Phone:      req.Phone,
+			AdminToken: adminToken,
+		}); err != nil {
+			handleOTPServiceError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, unlockPhoneResponse{Unlocked: true})
+	}
+}
+
 func handleOTPServiceError(c *gin.Context, err error) {
 	switch {
 	case errors.Is(err, otp.ErrTenantDisabled):
 		middleware.ErrorHandler(c, apperrors.ErrForbidden("Tenant is disabled"))
 	case errors.Is(err, otp.ErrTenantNotFound):
 		middleware.ErrorHandler(c, apperrors.ErrNotFound("Tenant not found"))
+	case errors.Is(err, otp.ErrAdminUnauthorized):
+		middleware.ErrorHandler(c, apperrors.ErrUnauthorized("Invalid tenant admin token"))
+	case errors.Is(err, otp.ErrInvalidPhone):
+		middleware.ErrorHandler(c, apperrors.ErrBadRequest("Invalid phone number"))
+	case errors.Is(err, otp.ErrInvalidPurpose):
+		middleware.ErrorHandler(c, apperrors.ErrBadRequest("Invalid OTP purpose"))
+	case errors.Is(err, otp.ErrPurposeNotAllowed):
+		middleware.ErrorHandler(c, apperrors.ErrForbidden("OTP purpose not allowed for tenant"))
+	case errors.Is(err, otp.ErrOTPNotFound):
+		middleware.ErrorHandler(c, apperrors.ErrNotFound("No active OTP"))
 	case errors.Is(err, otp.ErrOTPAlreadyActive):
-		middleware.ErrorHandler(c, apperrors.NewAppError(http.StatusTooManyRequests, "OTP already active"))
+		middleware.TooManyRequests(c, err, "OTP already active")
+	case errors.Is(err, otp.ErrOTPResendCooldown):
+		middleware.TooManyRequests(c, err, "OTP resend cooldown active")
+	case errors.Is(err, otp.ErrOTPResendLimit):
+		middleware.TooManyRequests(c, err, "OTP resend limit reached")
 	case errors.Is(err, otp.ErrOTPRateLimited):
-		middleware.ErrorHandler(c, apperrors.NewAppError(http.StatusTooManyRequests, "OTP send rate limit exceeded"))
+		middleware.TooManyRequests(c, err, "OTP send rate limit exceeded")
+	case errors.Is(err, otp.ErrTenantRateLimited):
+		middleware.TooManyRequests(c, err, "Tenant OTP send quota exceeded")
+	case errors.Is(err, otp.ErrPhoneLocked):
+		middleware.TooManyRequests(c, err, "Phone temporarily locked after too many failed verifications")
+	case errors.Is(err, otp.ErrSMSInvalidRecipient):
+		middleware.ErrorHandler(c, apperrors.ErrBadRequest("Phone number is not valid for SMS delivery"))
+	case errors.Is(err, otp.ErrSMSRecipientLandline):
+		middleware.ErrorHandler(c, apperrors.ErrBadRequest("Phone number is not a mobile number"))
+	case errors.Is(err, otp.ErrSMSRecipientUnreachable):
+		middleware.ErrorHandler(c, apperrors.ErrBadRequest("Phone number cannot receive SMS"))
+	case errors.Is(err, otp.ErrSMSCircuitOpen):
+		middleware.ErrorHandler(c, apperrors.NewAppError(http.StatusServiceUnavailable, "SMS provider temporarily unavailable"))
 	case errors.Is(err, otp.ErrSMSProviderFailed):
 		middleware.ErrorHandler(c, apperrors.NewAppError(http.StatusBadGateway, "SMS provider failed"))
 	default:
@@ -5,8 +5,10 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -22,10 +24,22 @@ import (
 type fakeOTPFlowService struct {
 	sendResp   *otp.SendResponse
 	sendErr    error
+	resendResp *otp.SendResponse
+	resendErr  error
 	verifyResp *otp.VerifyResponse
 	verifyErr  error
+	getStatus  *otp.OTPStatus
+	getErr     error
+	cancelErr  error
+	unlockErr  error
 	sendReq    otp.SendRequest
+	resendReq  otp.ResendRequest
 	verifyReq  otp.VerifyRequest
+	verifyByID otp.VerifyByRequestIDRequest
+	getTenant  int64
+	getReqID   string
+	cancelReq  otp.CancelRequest
+	unlockReq  otp.UnlockRequest
 }
 
 func (s *fakeOTPFlowService) SendOTP(ctx context.Context, req otp.SendRequest) (*otp.SendResponse, error) {
@@ -36,6 +50,14 @@ func (s *fakeOTPFlowService) SendOTP(ctx context.Context, req otp.SendRequest) (
 	return s.sendResp, nil
 }
 
+func (s *fakeOTPFlowService) ResendOTP(ctx context.Context, req otp.ResendRequest) (*otp.SendResponse, error) {
+	s.resendReq = req
+	if s.resendErr != nil {
+		return nil, s.resendErr
+	}
+	return s.resendResp, nil
+}
+
 func (s *fakeOTPFlowService) VerifyOTP(ctx context.Context, req otp.VerifyRequest) (*otp.VerifyResponse, error) {
 	s.verifyReq = req
 	if s.verifyErr != nil {
@@ -44,6 +66,33 @@ func (s *fakeOTPFlowService) VerifyOTP(ctx context.Context, req otp.VerifyReques
 	return s.verifyResp, nil
 }
 
+func (s *fakeOTPFlowService) VerifyOTPByRequestID(ctx context.Context, req otp.VerifyByRequestIDRequest) (*otp.VerifyResponse, error) {
+	s.verifyByID = req
+	if s.verifyErr != nil {
+		return nil, s.verifyErr
+	}
+	return s.verifyResp, nil
+}
+
+func (s *fakeOTPFlowService) GetOTP(ctx context.Context, tenantID int64, requestID string) (*otp.OTPStatus, error) {
+	s.getTenant = tenantID
+	s.getReqID = requestID
+	if s.getErr != nil {
+		return nil, s.getErr
+	}
+	return s.getStatus, nil
+}
+
+func (s *fakeOTPFlowService) CancelOTP(ctx context.Context, req otp.CancelRequest) error {
+	s.cancelReq = req
+	return s.cancelErr
+}
+
+func (s *fakeOTPFlowService) UnlockPhone(ctx context.Context, req otp.UnlockRequest) error {
+	s.unlockReq = req
+	return s.unlockErr
+}
+
 func TestSendOTPHandlerSuccess(t *testing.T) {
 	service := &fakeOTPFlowService{sendResp: &otp.SendResponse{
 		RequestID: "request-1",
@@ -52,7 +101,7 @@ func TestSendOTPHandlerSuccess(t *testing.T) {
 	router := newOTPFlowTestRouter()
 	router.POST("/v1/otp/send", SendOTPHandler(service))
 
-	w := performJSONRequest(router, "POST", "/v1/otp/send", `{"tenant_id":42,"phone":"+989121234567","token":"token","metadata":{"source":"test"}}`)
+	w := performJSONRequest(router, "POST", "/v1/otp/send", `{"tenant_id":42,"phone":"+989121234567","purpose":"login","token":"token","metadata":{"source":"test"}}`)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	var resp otp.SendResponse
@@ -60,6 +109,7 @@ func TestSendOTPHandlerSuccess(t *testing.T) {
 	assert.Equal(t, "request-1", resp.RequestID)
 	assert.Equal(t, int64(42), service.sendReq.TenantID)
 	assert.Equal(t, "+989121234567", service.sendReq.Phone)
+	assert.Equal(t, "login", service.sendReq.Purpose)
 	assert.Equal(t, "token", service.sendReq.Token)
 	assert.Equal(t, "test", service.sendReq.Metadata["source"])
 }
@@ -124,6 +174,148 @@ func TestSendOTPHandlerOTPRateLimited(t *testing.T) {
 	assertErrorResponse(t, w, http.StatusTooManyRequests)
 }
 
+func TestSendOTPHandlerRateLimitHeaders(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		retryAfter string
+		limit      string
+		remaining  string
+		seconds    int
+	}{
+		{
+			name:       "send rate limit",
+			err:        fmt.Errorf("otp send rate limiter: %w", &otp.LimitError{Err: otp.ErrOTPRateLimited, Limit: 5, ResetAfter: 12300 * time.Millisecond}),
+			retryAfter: "13",
+			limit:      "5",
+			remaining:  "0",
+			seconds:    13,
+		},
+		{
+			name:       "active otp",
+			err:        &otp.LimitError{Err: otp.ErrOTPAlreadyActive, Limit: 1, ResetAfter: 2 * time.Minute},
+			retryAfter: "120",
+			limit:      "1",
+			remaining:  "0",
+			seconds:    120,
+		},
+		{
+			name:       "tenant quota resetting now",
+			err:        &otp.LimitError{Err: otp.ErrTenantRateLimited, Limit: 100, ResetAfter: 0},
+			retryAfter: "1",
+			limit:      "100",
+			remaining:  "0",
+			seconds:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeOTPFlowService{sendErr: tt.err}
+			router := newOTPFlowTestRouter()
+			router.POST("/v1/otp/send", SendOTPHandler(service))
+
+			w := performJSONRequest(router, "POST", "/v1/otp/send", `{"tenant_id":42,"phone":"+989121234567"}`)
+
+			assertErrorResponse(t, w, http.StatusTooManyRequests)
+			assert.Equal(t, tt.retryAfter, w.Header().Get("Retry-After"))
+			assert.Equal(t, tt.limit, w.Header().Get("RateLimit-Limit"))
+			assert.Equal(t, tt.remaining, w.Header().Get("RateLimit-Remaining"))
+			assert.Equal(t, tt.retryAfter, w.Header().Get("RateLimit-Reset"))
+			var resp apperrors.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.seconds, resp.RetryAfterSeconds)
+		})
+	}
+}
+
+func TestSendOTPHandlerRateLimitWithoutMetadata(t *testing.T) {
+	service := &fakeOTPFlowService{sendErr: otp.ErrOTPRateLimited}
+	router := newOTPFlowTestRouter()
+	router.POST("/v1/otp/send", SendOTPHandler(service))
+
+	w := performJSONRequest(router, "POST", "/v1/otp/send", `{"tenant_id":42,"phone":"+989121234567"}`)
+
+	assertErrorResponse(t, w, http.StatusTooManyRequests)
+	assert.Empty(t, w.Header().Get("Retry-After"))
+	assert.NotContains(t, w.Body.String(), "retry_after_seconds")
+}
+
+func TestSendOTPHandlerTenantRateLimited(t *testing.T) {
+	service := &fakeOTPFlowService{sendErr: otp.ErrTenantRateLimited}
+	router := newOTPFlowTestRouter()
+	router.POST("/v1/otp/send", SendOTPHandler(service))
+
+	w := performJSONRequest(router, "POST", "/v1/otp/send", `{"tenant_id":42,"phone":"+989121234567"}`)
+
+	assertErrorResponse(t, w, http.StatusTooManyRequests)
+	assert.Contains(t, w.Body.String(), "Tenant OTP send quota exceeded")
+}
+
+func TestOTPHandlersPhoneLocked(t *testing.T) {
+	lockErr := &otp.LimitError{Err: otp.ErrPhoneLocked, Limit: 10, ResetAfter: 15 * time.Minute}
+	service := &fakeOTPFlowService{sendErr: lockErr, verifyErr: lockErr}
+	router := newOTPFlowTestRouter()
+	router.POST("/v1/otp/send", SendOTPHandler(service))
+	router.POST("/v1/otp/verify", VerifyOTPHandler(service))
+
+	for _, w := range []*httptest.ResponseRecorder{
+		performJSONRequest(router, "POST", "/v1/otp/send", `{"tenant_id":42,"phone":"+989121234567"}`),
+		performJSONRequest(router, "POST", "/v1/otp/verify", `{"tenant_id":42,"phone":"+989121234567","code":"123456"}`),
+	} {
+		assertErrorResponse(t, w, http.StatusTooManyRequests)
+		assert.Equal(t, "900", w.Header().Get("Retry-After"))
+		assert.Contains(t, w.Body.String(), "Phone temporarily locked")
+	}
+}
+
+func TestOTPHandlersInvalidPhone(t *testing.T) {
+	phoneErr := fmt.Errorf("%w: wrong length for region IR", otp.ErrInvalidPhone)
+	service := &fakeOTPFlowService{sendErr: phoneErr, verifyErr: phoneErr}
+	router := newOTPFlowTestRouter()
+	router.POST("/v1/otp/send", SendOTPHandler(service))
+	router.POST("/v1/otp/verify", VerifyOTPHandler(service))
+
+	for _, w := range []*httptest.ResponseRecorder{
+		performJSONRequest(router, "POST", "/v1/otp/send", `{"tenant_id":42,"phone":"0912"}`),
+		performJSONRequest(router, "POST", "/v1/otp/verify", `{"tenant_id":42,"phone":"0912","code":"123456"}`),
+	} {
+		assertErrorResponse(t, w, http.StatusBadRequest)
+		assert.Contains(t, w.Body.String(), "Invalid phone number")
+	}
+}
+
+func TestOTPHandlersPurposeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		message string
+	}{
+		{name: "invalid purpose", err: fmt.Errorf("%w: must match pattern", otp.ErrInvalidPurpose), status: http.StatusBadRequest, message: "Invalid OTP purpose"},
+		{name: "purpose not allowed", err: otp.ErrPurposeNotAllowed, status: http.StatusForbidden, message: "OTP purpose not allowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeOTPFlowService{sendErr: tt.err, resendErr: tt.err, verifyErr: tt.err}
+			router := newOTPFlowTestRouter()
+			router.POST("/v1/otp/send", SendOTPHandler(service))
+			router.POST("/v1/otp/resend", ResendOTPHandler(service))
+			router.POST("/v1/otp/verify", VerifyOTPHandler(service))
+
+			for _, w := range []*httptest.ResponseRecorder{
+				performJSONRequest(router, "POST", "/v1/otp/send", `{"tenant_id":42,"phone":"+989121234567","purpose":"Login!"}`),
+				performJSONRequest(router, "POST", "/v1/otp/resend", `{"tenant_id":42,"phone":"+989121234567","purpose":"Login!"}`),
+				performJSONRequest(router, "POST", "/v1/otp/verify", `{"tenant_id":42,"phone":"+989121234567","purpose":"Login!","code":"123456"}`),
+			} {
+				assertErrorResponse(t, w, tt.status)
+				assert.Contains(t, w.Body.String(), tt.message)
+			}
+		})
+	}
+}
+
 func TestSendOTPHandlerProviderFailure(t *testing.T) {
 	service := &fakeOTPFlowService{sendErr: otp.ErrSMSProviderFailed}
 	router := newOTPFlowTestRouter()
@@ -134,6 +326,41 @@ func TestSendOTPHandlerProviderFailure(t *testing.T) {
 	assertErrorResponse(t, w, http.StatusBadGateway)
 }
 
+func TestSendOTPHandlerCircuitOpen(t *testing.T) {
+	providerErr := &otp.SMSProviderError{Provider: "kavenegar", Kind: otp.ErrSMSCircuitOpen}
+	service := &fakeOTPFlowService{sendErr: fmt.Errorf("%w: %w", otp.ErrSMSProviderFailed, providerErr)}
+	router := newOTPFlowTestRouter()
+	router.POST("/v1/otp/send", SendOTPHandler(service))
+
+	w := performJSONRequest(router, "POST", "/v1/otp/send", `{"tenant_id":42,"phone":"+989121234567"}`)
+
+	assertErrorResponse(t, w, http.StatusServiceUnavailable)
+}
+
+func TestSendOTPHandlerRecipientErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		kind error
+	}{
+		{name: "invalid recipient", kind: otp.ErrSMSInvalidRecipient},
+		{name: "landline", kind: otp.ErrSMSRecipientLandline},
+		{name: "unreachable", kind: otp.ErrSMSRecipientUnreachable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providerErr := &otp.SMSProviderError{Provider: "twilio", Kind: tt.kind}
+			service := &fakeOTPFlowService{sendErr: fmt.Errorf("%w: %w", otp.ErrSMSProviderFailed, providerErr)}
+			router := newOTPFlowTestRouter()
+			router.POST("/v1/otp/send", SendOTPHandler(service))
+
+			w := performJSONRequest(router, "POST", "/v1/otp/send", `{"tenant_id":42,"phone":"+989121234567"}`)
+
+			assertErrorResponse(t, w, http.StatusBadRequest)
+		})
+	}
+}
+
 func TestSendOTPHandlerGenericError(t *testing.T) {
 	service := &fakeOTPFlowService{sendErr: errors.New("boom")}
 	router := newOTPFlowTestRouter()
@@ -149,7 +376,7 @@ func TestVerifyOTPHandlerSuccess(t *testing.T) {
 	router := newOTPFlowTestRouter()
 	router.POST("/v1/otp/verify", VerifyOTPHandler(service))
 
-	w := performJSONRequest(router, "POST", "/v1/otp/verify", `{"tenant_id":42,"phone":"+989121234567","code":"123456"}`)
+	w := performJSONRequest(router, "POST", "/v1/otp/verify", `{"tenant_id":42,"phone":"+989121234567","purpose":"login","code":"123456"}`)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	var resp otp.VerifyResponse
@@ -158,6 +385,7 @@ func TestVerifyOTPHandlerSuccess(t *testing.T) {
 	assert.Equal(t, "request-2", resp.RequestID)
 	assert.Equal(t, int64(42), service.verifyReq.TenantID)
 	assert.Equal(t, "+989121234567", service.verifyReq.Phone)
+	assert.Equal(t, "login", service.verifyReq.Purpose)
 	assert.Equal(t, "123456", service.verifyReq.Code)
 }
 
@@ -229,6 +457,251 @@ func TestVerifyOTPHandlerGenericError(t *testing.T) {
 	assertErrorResponse(t, w, http.StatusInternalServerError)
 }
 
+func TestResendOTPHandlerSuccess(t *testing.T) {
+	service := &fakeOTPFlowService{resendResp: &otp.SendResponse{
+		RequestID:   "request-1",
+		ExpiredAt:   time.Date(2026, 5, 9, 12, 0, 0, 0, time.UTC),
+		ResendCount: 1,
+	}}
+	router := newOTPFlowTestRouter()
+	router.POST("/v1/otp/resend", ResendOTPHandler(service))
+
+	w := performJSONRequest(router, "POST", "/v1/otp/resend", `{"tenant_id":42,"phone":"+989121234567","purpose":"password_reset","metadata":{"source":"test"}}`)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"request_id":"request-1","expired_at":"2026-05-09T12:00:00Z","resend_count":1}`, w.Body.String())
+	assert.Equal(t, int64(42), service.resendReq.TenantID)
+	assert.Equal(t, "+989121234567", service.resendReq.Phone)
+	assert.Equal(t, "password_reset", service.resendReq.Purpose)
+	assert.Equal(t, "test", service.resendReq.Metadata["source"])
+}
+
+func TestResendOTPHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		err        error
+		status     int
+		retryAfter string
+	}{
+		{name: "invalid json", body: `{invalid-json`, status: http.StatusBadRequest},
+		{name: "missing tenant id", body: `{"phone":"+989121234567"}`, status: http.StatusBadRequest},
+		{name: "empty phone", body: `{"tenant_id":42,"phone":" "}`, status: http.StatusBadRequest},
+		{name: "no active otp", body: `{"tenant_id":42,"phone":"+989121234567"}`, err: otp.ErrOTPNotFound, status: http.StatusNotFound},
+		{
+			name:       "cooldown",
+			body:       `{"tenant_id":42,"phone":"+989121234567"}`,
+			err:        &otp.LimitError{Err: otp.ErrOTPResendCooldown, Limit: 1, ResetAfter: 20 * time.Second},
+			status:     http.StatusTooManyRequests,
+			retryAfter: "20",
+		},
+		{
+			name:       "resend limit",
+			body:       `{"tenant_id":42,"phone":"+989121234567"}`,
+			err:        &otp.LimitError{Err: otp.ErrOTPResendLimit, Limit: 3, ResetAfter: time.Minute},
+			status:     http.StatusTooManyRequests,
+			retryAfter: "60",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeOTPFlowService{resendErr: tt.err}
+			router := newOTPFlowTestRouter()
+			router.POST("/v1/otp/resend", ResendOTPHandler(service))
+
+			w := performJSONRequest(router, "POST", "/v1/otp/resend", tt.body)
+
+			assertErrorResponse(t, w, tt.status)
+			assert.Equal(t, tt.retryAfter, w.Header().Get("Retry-After"))
+		})
+	}
+}
+
+func TestVerifyOTPByRequestIDHandlerSuccess(t *testing.T) {
+	service := &fakeOTPFlowService{verifyResp: &otp.VerifyResponse{Verified: true, RequestID: "request-1"}}
+	router := newOTPFlowTestRouter()
+	router.POST("/v1/otp/verify", VerifyOTPHandler(service))
+	router.POST("/v1/otp/:request_id/verify", VerifyOTPByRequestIDHandler(service))
+
+	w := performJSONRequest(router, "POST", "/v1/otp/request-1/verify", `{"tenant_id":42,"purpose":"payment","code":"123456"}`)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"verified":true,"request_id":"request-1"}`, w.Body.String())
+	assert.Equal(t, otp.VerifyByRequestIDRequest{TenantID: 42, RequestID: "request-1", Purpose: "payment", Code: "123456"}, service.verifyByID)
+	assert.Empty(t, service.verifyReq.Phone)
+}
+
+func TestVerifyOTPByRequestIDHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		err    error
+		status int
+	}{
+		{name: "invalid json", body: `{invalid-json`, status: http.StatusBadRequest},
+		{name: "missing tenant id", body: `{"code":"123456"}`, status: http.StatusBadRequest},
+		{name: "empty code", body: `{"tenant_id":42,"code":" "}`, status: http.StatusBadRequest},
+		{
+			name:   "phone locked",
+			body:   `{"tenant_id":42,"code":"123456"}`,
+			err:    &otp.LimitError{Err: otp.ErrPhoneLocked, Limit: 10, ResetAfter: time.Hour},
+			status: http.StatusTooManyRequests,
+		},
+		{name: "internal", body: `{"tenant_id":42,"code":"123456"}`, err: errors.New("redis down"), status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeOTPFlowService{verifyErr: tt.err}
+			router := newOTPFlowTestRouter()
+			router.POST("/v1/otp/:request_id/verify", VerifyOTPByRequestIDHandler(service))
+
+			w := performJSONRequest(router, "POST", "/v1/otp/request-1/verify", tt.body)
+
+			assertErrorResponse(t, w, tt.status)
+		})
+	}
+}
+
+func TestGetOTPHandlerSuccess(t *testing.T) {
+	expiredAt := time.Date(2026, 5, 9, 12, 0, 0, 0, time.UTC)
+	service := &fakeOTPFlowService{getStatus: &otp.OTPStatus{
+		RequestID:         "request-1",
+		Purpose:           "login",
+		AttemptCount:      1,
+		MaxAttempts:       3,
+		RemainingAttempts: 2,
+		ResendCount:       1,
+		CreatedAt:         expiredAt.Add(-2 * time.Minute),
+		LastSentAt:        expiredAt.Add(-time.Minute),
+		ExpiredAt:         expiredAt,
+	}}
+	router := newOTPFlowTestRouter()
+	router.GET("/v1/otp/:request_id", GetOTPHandler(service))
+
+	w := performJSONRequest(router, "GET", "/v1/otp/request-1?tenant_id=42", "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"request_id":"request-1",
+		"purpose":"login",
+		"attempt_count":1,
+		"max_attempts":3,
+		"remaining_attempts":2,
+		"resend_count":1,
+		"created_at":"2026-05-09T11:58:00Z",
+		"last_sent_at":"2026-05-09T11:59:00Z",
+		"expired_at":"2026-05-09T12:00:00Z"
+	}`, w.Body.String())
+	assert.Equal(t, int64(42), service.getTenant)
+	assert.Equal(t, "request-1", service.getReqID)
+}
+
+func TestGetOTPHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		err    error
+		status int
+	}{
+		{name: "missing tenant id", path: "/v1/otp/request-1", status: http.StatusBadRequest},
+		{name: "invalid tenant id", path: "/v1/otp/request-1?tenant_id=abc", status: http.StatusBadRequest},
+		{name: "not found", path: "/v1/otp/request-1?tenant_id=42", err: otp.ErrOTPNotFound, status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeOTPFlowService{getErr: tt.err}
+			router := newOTPFlowTestRouter()
+			router.GET("/v1/otp/:request_id", GetOTPHandler(service))
+
+			w := performJSONRequest(router, "GET", tt.path, "")
+
+			assertErrorResponse(t, w, tt.status)
+		})
+	}
+}
+
+func TestCancelOTPHandlerSuccess(t *testing.T) {
+	service := &fakeOTPFlowService{}
+	router := newOTPFlowTestRouter()
+	router.DELETE("/v1/otp/:request_id", CancelOTPHandler(service))
+
+	w := performJSONRequest(router, "DELETE", "/v1/otp/request-1?tenant_id=42&reason=checkout+abandoned", "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"request_id":"request-1","cancelled":true}`, w.Body.String())
+	assert.Equal(t, otp.CancelRequest{TenantID: 42, RequestID: "request-1", Reason: "checkout abandoned"}, service.cancelReq)
+}
+
+func TestCancelOTPHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		err    error
+		status int
+	}{
+		{name: "missing tenant id", path: "/v1/otp/request-1", status: http.StatusBadRequest},
+		{name: "invalid tenant id", path: "/v1/otp/request-1?tenant_id=abc", status: http.StatusBadRequest},
+		{name: "reason too long", path: "/v1/otp/request-1?tenant_id=42&reason=" + strings.Repeat("x", otp.MaxCancelReasonLength+1), status: http.StatusBadRequest},
+		{name: "not found", path: "/v1/otp/request-1?tenant_id=42", err: otp.ErrOTPNotFound, status: http.StatusNotFound},
+		{name: "store failure", path: "/v1/otp/request-1?tenant_id=42", err: errors.New("redis down"), status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeOTPFlowService{cancelErr: tt.err}
+			router := newOTPFlowTestRouter()
+			router.DELETE("/v1/otp/:request_id", CancelOTPHandler(service))
+
+			w := performJSONRequest(router, "DELETE", tt.path, "")
+
+			assertErrorResponse(t, w, tt.status)
+		})
+	}
+}
+
+func TestUnlockPhoneHandlerSuccess(t *testing.T) {
+	service := &fakeOTPFlowService{}
+	router := newOTPFlowTestRouter()
+	router.POST("/v1/otp/admin/unlock", UnlockPhoneHandler(service))
+
+	w := performJSONRequest(router, "POST", "/v1/otp/admin/unlock", `{"tenant_id":42,"phone":"+989121234567"}`)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"unlocked":true}`, w.Body.String())
+	assert.Equal(t, otp.UnlockRequest{TenantID: 42, Phone: "+989121234567"}, service.unlockReq)
+}
+
+func TestUnlockPhoneHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		err    error
+		status int
+	}{
+		{name: "invalid json", body: `{invalid-json`, status: http.StatusBadRequest},
+		{name: "missing tenant id", body: `{"phone":"+989121234567"}`, status: http.StatusBadRequest},
+		{name: "empty phone", body: `{"tenant_id":42,"phone":" "}`, status: http.StatusBadRequest},
+		{name: "tenant not found", body: `{"tenant_id":42,"phone":"+989121234567"}`, err: otp.ErrTenantNotFound, status: http.StatusNotFound},
+		{name: "invalid admin token", body: `{"tenant_id":42,"phone":"+989121234567"}`, err: otp.ErrAdminUnauthorized, status: http.StatusUnauthorized},
+		{name: "generic error", body: `{"tenant_id":42,"phone":"+989121234567"}`, err: errors.New("boom"), status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeOTPFlowService{unlockErr: tt.err}
+			router := newOTPFlowTestRouter()
+			router.POST("/v1/otp/admin/unlock", UnlockPhoneHandler(service))
+
+			w := performJSONRequest(router, "POST", "/v1/otp/admin/unlock", tt.body)
+
+			assertErrorResponse(t, w, tt.status)
+		})
+	}
+}
+
 func newOTPFlowTestRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
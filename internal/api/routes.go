@@ -36,7 +36,9 @@ func SetupMiddleware(router *gin.Engine) {
 }
 
 // SetupRoutes registers all routes with the router
-func SetupRoutes(router *gin.Engine, lifecycleMgr *lifecycle.Manager, tenantSettingsRepo *repository.TenantSettingsRepository, tenantSettingsInsertRepo *repository.TenantSettingsInsertRepository, redisBenchmarkRepo *repository.RedisBenchmarkRepository, mongoBenchmarkRepo *repository.MongoBenchmarkRepository, otpServices ...*otp.Service) {
+// A nil otpIPRateLimit leaves the OTP send, resend and verify routes unlimited by client IP.
+// Resends share the send limit.
+func SetupRoutes(router *gin.Engine, lifecycleMgr *lifecycle.Manager, tenantSettingsRepo *repository.TenantSettingsRepository, tenantSettingsInsertRepo *repository.TenantSettingsInsertRepository, redisBenchmarkRepo *repository.RedisBenchmarkRepository, mongoBenchmarkRepo *repository.MongoBenchmarkRepository, otpIPRateLimit *middleware.IPRateLimit, otpServices ...*otp.Service) {
 	var otpService *otp.Service
 	if len(otpServices) > 0 {
 		otpService = otpServices[0]
@@ -70,8 +72,12 @@ func SetupRoutes(router *gin.Engine, lifecycleMgr *lifecycle.Manager, tenantSett
 		{
 			otp.POST("/code", GenerateOTPCodeHandler)
 			if otpService != nil {
-				otp.POST("/send", SendOTPHandler(otpService))
-				otp.POST("/verify", VerifyOTPHandler(otpService))
+				otp.POST("/send", otpIPRateLimit.Middleware("send"), SendOTPHandler(otpService))
+				otp.POST("/resend", otpIPRateLimit.Middleware("send"), ResendOTPHandler(otpService))
+				otp.POST("/verify", otpIPRateLimit.Middleware("verify"), VerifyOTPHandler(otpService))
+				otp.POST("/:request_id/verify", otpIPRateLimit.Middleware("verify"), VerifyOTPByRequestIDHandler(otpService))
+				otp.GET("/:request_id", GetOTPHandler(otpService))
+				otp.DELETE("/:request_id", CancelOTPHandler(otpService))
 			}
 			// Tenant settings routes
 			otp.GET("/tenant-settings/:id", GetTenantSettingsByIDHandler(tenantSettingsRepo))
@@ -91,3 +97,13 @@ func SetupRoutes(router *gin.Engine, lifecycleMgr *lifecycle.Manager, tenantSett
 		}
 	}
 }
+
+// SetupAdminRoutes registers the tenant admin endpoints on the admin listener's router.
+// They are never registered on the public router; each request is authorized with the
+// admin token of the tenant it names.
+func SetupAdminRoutes(router *gin.Engine, otpService *otp.Service) {
+	admin := router.Group("/v1/otp/admin")
+	{
+		admin.POST("/unlock", UnlockPhoneHandler(otpService))
+	}
+}
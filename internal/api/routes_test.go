@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-backend-service/internal/lifecycle"
+	"go-backend-service/internal/otp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticTenantSettingsProvider map[int64]*otp.TenantSettings
+
+func (p staticTenantSettingsProvider) GetTenantSettings(ctx context.Context, tenantID int64) (*otp.TenantSettings, error) {
+	return p[tenantID], nil
+}
+
+func TestUnlockRouteOnlyOnAdminRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service := otp.NewService(nil, nil, nil, nil, nil, otp.Config{})
+	body := `{"tenant_id":42,"phone":"+989121234567"}`
+
+	public := gin.New()
+	SetupRoutes(public, lifecycle.NewManager(), nil, nil, nil, nil, nil, service)
+	w := performJSONRequest(public, "POST", "/v1/otp/admin/unlock", body)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestUnlockRouteRequiresTenantAdminToken(t *testing.T) {
+	tenantToken := func(token string) *otp.TenantSettings {
+		sum := sha256.Sum256([]byte(token))
+		return &otp.TenantSettings{
+			Status:   "active",
+			Metadata: map[string]interface{}{otp.AdminTokenHashMetadataKey: hex.EncodeToString(sum[:])},
+		}
+	}
+	tenants := staticTenantSettingsProvider{
+		42: tenantToken("tenant-42-admin-token"),
+		43: tenantToken("tenant-43-admin-token"),
+		44: {Status: "active"},
+	}
+	service := otp.NewService(tenants, nil, nil, nil, nil, otp.Config{})
+	admin := newOTPFlowTestRouter()
+	SetupAdminRoutes(admin, service)
+
+	tests := []struct {
+		name     string
+		tenantID string
+		token    string
+		status   int
+	}{
+		{name: "own tenant", tenantID: "42", token: "tenant-42-admin-token", status: http.StatusOK},
+		{name: "missing token", tenantID: "42", status: http.StatusUnauthorized},
+		{name: "another tenant's token", tenantID: "42", token: "tenant-43-admin-token", status: http.StatusUnauthorized},
+		{name: "tenant without admin token", tenantID: "44", token: "tenant-42-admin-token", status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/v1/otp/admin/unlock", strings.NewReader(`{"tenant_id":`+tt.tenantID+`,"phone":"+989121234567"}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+
+			admin.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"net/netip"
 	"os"
 	"strconv"
 	"strings"
@@ -32,6 +33,7 @@ type OTPConfig struct {
 	MaxAttempts           int
 	TenantCacheTTL        time.Duration
 	ProviderTimeout       time.Duration
+	FailoverTimeout       time.Duration
 	FakeSMSMinDelay       time.Duration
 	FakeSMSMaxDelay       time.Duration
 	FakeSMSDebugCodeRedis bool
@@ -39,6 +41,73 @@ type OTPConfig struct {
 	SendRateLimitEnabled  bool
 	SendRateLimitMax      int
 	SendRateLimitWindow   time.Duration
+	CodeHashKeyID         string
+	CodeHashKeys          map[string]string
+	SMSFakeProviders      []string
+	KavenegarBaseURL      string
+	KavenegarTemplate     string
+	TwilioBaseURL         string
+	TwilioSender          string
+	GhasedakBaseURL       string
+	GhasedakTemplate      string
+	// SMS provider circuit breaker thresholds; the per-provider maps override the globals.
+	SMSCircuitWindowSize        int
+	SMSCircuitMinCalls          int
+	SMSCircuitFailureRate       float64
+	SMSCircuitSlowCallDuration  time.Duration
+	SMSCircuitSlowCallRate      float64
+	SMSCircuitOpenDuration      time.Duration
+	SMSCircuitHalfOpenCalls     int
+	SMSCircuitFailureRates      map[string]float64
+	SMSCircuitSlowCallDurations map[string]time.Duration
+	// SMS provider retry policy; the budget is shared by all providers.
+	SMSRetryMaxAttempts int
+	SMSRetryBaseDelay   time.Duration
+	SMSRetryMaxDelay    time.Duration
+	SMSRetryBudgetRatio float64
+	SMSRetryBudgetBurst int
+	// Fake SMS fault injection for load tests; phone outcomes are keyed by phone suffix.
+	FakeSMSErrorRate           float64
+	FakeSMSHangRate            float64
+	FakeSMSLatencyDistribution string
+	FakeSMSParetoAlpha         float64
+	FakeSMSLatencyCap          time.Duration
+	FakeSMSPhoneOutcomes       map[string]string
+	// TenantRateLimitEnabled enforces tenant_settings.rate_limit_per_min across all phones.
+	TenantRateLimitEnabled bool
+	// SendRateLimitAlgorithm is fixed_window, sliding_window or gcra.
+	SendRateLimitAlgorithm string
+	// Per-client-IP limit on the send and verify endpoints; allowlisted IPs/CIDRs are exempt.
+	IPRateLimitEnabled   bool
+	IPRateLimitMax       int
+	IPRateLimitWindow    time.Duration
+	IPRateLimitAllowlist []string
+	// Phone lockout after repeated failed verifications across OTPs; each further lock
+	// within the failure window uses the next duration, the last one repeating.
+	LockoutEnabled       bool
+	LockoutMaxFailures   int
+	LockoutFailureWindow time.Duration
+	LockoutDurations     []time.Duration
+	// In-memory send rate limiter used while Redis errors; Redis is probed once per interval.
+	SendRateLimitFallbackEnabled       bool
+	SendRateLimitFallbackMaxKeys       int
+	SendRateLimitFallbackProbeInterval time.Duration
+	// Per-phone send limit shared by all tenants, alongside the tenant-scoped send limit.
+	GlobalPhoneRateLimitEnabled bool
+	GlobalPhoneRateLimitMax     int
+	GlobalPhoneRateLimitWindow  time.Duration
+	// DefaultPhoneRegion reads national numbers for tenants without a phone region (e.g. IR).
+	DefaultPhoneRegion string
+	// Secret for hashing phones in Redis key names; empty keeps plaintext phones. Legacy
+	// reads also look up plaintext-phone keys written before the secret was set.
+	PhoneKeySecret      string
+	PhoneKeyLegacyReads bool
+	// Resend spacing, per-OTP cap and mode (rotate or same). Same-code resends need
+	// ResendCodeSecret to seal the code in Redis; without it resends rotate.
+	ResendCooldown   time.Duration
+	MaxResends       int
+	ResendMode       string
+	ResendCodeSecret string
 }
 
 // TracingConfig holds OpenTelemetry tracing configuration
@@ -71,6 +140,12 @@ type ServerConfig struct {
 	WriteTimeout            time.Duration `koanf:"write_timeout"`
 	IdleTimeout             time.Duration `koanf:"idle_timeout"`
 	GracefulShutdownTimeout time.Duration `koanf:"graceful_shutdown_timeout"`
+	// TrustedProxies lists the proxy IPs/CIDRs whose X-Forwarded-For is believed; empty trusts none.
+	TrustedProxies []string `koanf:"trusted_proxies"`
+	// Separate listener for tenant admin endpoints such as phone unlock; disabled by default.
+	AdminEnabled bool   `koanf:"admin_enabled"`
+	AdminHost    string `koanf:"admin_host"`
+	AdminPort    int    `koanf:"admin_port"`
 }
 
 // DatabaseConfig holds database-related configuration
@@ -225,6 +300,27 @@ func loadServerConfig(cfg *Config) error {
 		return fmt.Errorf("invalid SERVER_GRACEFUL_SHUTDOWN_TIMEOUT: %w", err)
 	}
 
+	trustedProxies, err := parseIPList("SERVER_TRUSTED_PROXIES", os.Getenv("SERVER_TRUSTED_PROXIES"))
+	if err != nil {
+		return err
+	}
+
+	adminEnabled := parseBoolEnv("SERVER_ADMIN_ENABLED")
+	adminHost := os.Getenv("SERVER_ADMIN_HOST")
+	if adminHost == "" {
+		adminHost = "127.0.0.1"
+	}
+	adminPort := 8081
+	if adminPortStr := os.Getenv("SERVER_ADMIN_PORT"); adminPortStr != "" {
+		adminPort, err = strconv.Atoi(adminPortStr)
+		if err != nil {
+			return fmt.Errorf("invalid SERVER_ADMIN_PORT: %w", err)
+		}
+	}
+	if adminEnabled && adminPort == port {
+		return fmt.Errorf("SERVER_ADMIN_PORT must differ from SERVER_PORT")
+	}
+
 	cfg.Server = ServerConfig{
 		Host:                    host,
 		Port:                    port,
@@ -232,6 +328,10 @@ func loadServerConfig(cfg *Config) error {
 		WriteTimeout:            writeTimeout,
 		IdleTimeout:             idleTimeout,
 		GracefulShutdownTimeout: gracefulShutdownTimeout,
+		TrustedProxies:          trustedProxies,
+		AdminEnabled:            adminEnabled,
+		AdminHost:               adminHost,
+		AdminPort:               adminPort,
 	}
 
 	return nil
@@ -610,6 +710,11 @@ func loadOTPConfig(cfg *Config) error {
 		return err
 	}
 
+	failoverTimeout, err := parsePositiveDurationEnv("OTP_PROVIDER_FAILOVER_TIMEOUT", "5s")
+	if err != nil {
+		return err
+	}
+
 	fakeSMSMinDelay, err := parseDurationEnv("OTP_FAKE_SMS_MIN_DELAY", "20ms")
 	if err != nil {
 		return err
@@ -634,6 +739,47 @@ func loadOTPConfig(cfg *Config) error {
 		return err
 	}
 
+	fakeSMSErrorRate, err := parseProbabilityEnv("OTP_FAKE_SMS_ERROR_RATE", "0")
+	if err != nil {
+		return err
+	}
+	fakeSMSHangRate, err := parseProbabilityEnv("OTP_FAKE_SMS_HANG_RATE", "0")
+	if err != nil {
+		return err
+	}
+	if fakeSMSErrorRate+fakeSMSHangRate > 1 {
+		return fmt.Errorf("OTP_FAKE_SMS_ERROR_RATE + OTP_FAKE_SMS_HANG_RATE must be <= 1")
+	}
+	fakeSMSLatencyDistribution := strings.ToLower(strings.TrimSpace(os.Getenv("OTP_FAKE_SMS_LATENCY_DISTRIBUTION")))
+	if fakeSMSLatencyDistribution == "" {
+		fakeSMSLatencyDistribution = "uniform"
+	}
+	if fakeSMSLatencyDistribution != "uniform" && fakeSMSLatencyDistribution != "pareto" {
+		return fmt.Errorf("OTP_FAKE_SMS_LATENCY_DISTRIBUTION must be uniform or pareto")
+	}
+	fakeSMSParetoAlphaStr := os.Getenv("OTP_FAKE_SMS_PARETO_ALPHA")
+	if fakeSMSParetoAlphaStr == "" {
+		fakeSMSParetoAlphaStr = "1.5"
+	}
+	fakeSMSParetoAlpha, err := strconv.ParseFloat(fakeSMSParetoAlphaStr, 64)
+	if err != nil {
+		return fmt.Errorf("invalid OTP_FAKE_SMS_PARETO_ALPHA: %w", err)
+	}
+	if fakeSMSParetoAlpha <= 0 {
+		return fmt.Errorf("OTP_FAKE_SMS_PARETO_ALPHA must be > 0")
+	}
+	fakeSMSLatencyCap, err := parsePositiveDurationEnv("OTP_FAKE_SMS_LATENCY_CAP", "10s")
+	if err != nil {
+		return err
+	}
+	if fakeSMSLatencyDistribution == "pareto" && (fakeSMSMinDelay <= 0 || fakeSMSLatencyCap < fakeSMSMinDelay) {
+		return fmt.Errorf("pareto fake SMS latency needs 0 < OTP_FAKE_SMS_MIN_DELAY <= OTP_FAKE_SMS_LATENCY_CAP")
+	}
+	fakeSMSPhoneOutcomes, err := parseKeyValueList("OTP_FAKE_SMS_PHONE_OUTCOMES", os.Getenv("OTP_FAKE_SMS_PHONE_OUTCOMES"))
+	if err != nil {
+		return err
+	}
+
 	sendRateLimitMaxStr := os.Getenv("OTP_SEND_RATE_LIMIT_MAX")
 	if sendRateLimitMaxStr == "" {
 		sendRateLimitMaxStr = "5"
@@ -650,6 +796,174 @@ func loadOTPConfig(cfg *Config) error {
 	if err != nil {
 		return err
 	}
+	sendRateLimitAlgorithm := strings.ToLower(strings.TrimSpace(os.Getenv("OTP_SEND_RATE_LIMIT_ALGORITHM")))
+	if sendRateLimitAlgorithm == "" {
+		sendRateLimitAlgorithm = "fixed_window"
+	}
+	switch sendRateLimitAlgorithm {
+	case "fixed_window", "sliding_window", "gcra":
+	default:
+		return fmt.Errorf("OTP_SEND_RATE_LIMIT_ALGORITHM must be fixed_window, sliding_window or gcra")
+	}
+
+	ipRateLimitMax, err := parsePositiveIntEnv("OTP_IP_RATE_LIMIT_MAX", "30")
+	if err != nil {
+		return err
+	}
+	ipRateLimitWindow, err := parsePositiveDurationEnv("OTP_IP_RATE_LIMIT_WINDOW", "1m")
+	if err != nil {
+		return err
+	}
+	ipRateLimitAllowlist, err := parseIPList("OTP_IP_RATE_LIMIT_ALLOWLIST", os.Getenv("OTP_IP_RATE_LIMIT_ALLOWLIST"))
+	if err != nil {
+		return err
+	}
+
+	lockoutMaxFailures, err := parsePositiveIntEnv("OTP_LOCKOUT_MAX_FAILURES", "10")
+	if err != nil {
+		return err
+	}
+	lockoutFailureWindow, err := parsePositiveDurationEnv("OTP_LOCKOUT_FAILURE_WINDOW", "24h")
+	if err != nil {
+		return err
+	}
+	lockoutDurationsValue := os.Getenv("OTP_LOCKOUT_DURATIONS")
+	if strings.TrimSpace(lockoutDurationsValue) == "" {
+		lockoutDurationsValue = "15m,1h,24h"
+	}
+	lockoutDurations, err := parseDurationList("OTP_LOCKOUT_DURATIONS", lockoutDurationsValue)
+	if err != nil {
+		return err
+	}
+
+	sendRateLimitFallbackMaxKeys, err := parsePositiveIntEnv("OTP_SEND_RATE_LIMIT_FALLBACK_MAX_KEYS", "10000")
+	if err != nil {
+		return err
+	}
+	sendRateLimitFallbackProbeInterval, err := parsePositiveDurationEnv("OTP_SEND_RATE_LIMIT_FALLBACK_PROBE_INTERVAL", "5s")
+	if err != nil {
+		return err
+	}
+
+	globalPhoneRateLimitMax, err := parsePositiveIntEnv("OTP_GLOBAL_PHONE_RATE_LIMIT_MAX", "10")
+	if err != nil {
+		return err
+	}
+	globalPhoneRateLimitWindow, err := parsePositiveDurationEnv("OTP_GLOBAL_PHONE_RATE_LIMIT_WINDOW", "1h")
+	if err != nil {
+		return err
+	}
+
+	phoneKeySecret := os.Getenv("OTP_PHONE_KEY_SECRET")
+	if phoneKeySecret != "" && len(phoneKeySecret) < 16 {
+		return fmt.Errorf("OTP_PHONE_KEY_SECRET must be at least 16 bytes")
+	}
+
+	resendCooldown, err := parsePositiveDurationEnv("OTP_RESEND_COOLDOWN", "30s")
+	if err != nil {
+		return err
+	}
+	maxResends, err := parsePositiveIntEnv("OTP_MAX_RESENDS", "3")
+	if err != nil {
+		return err
+	}
+	resendMode := strings.ToLower(strings.TrimSpace(os.Getenv("OTP_RESEND_MODE")))
+	if resendMode == "" {
+		resendMode = "rotate"
+	}
+	switch resendMode {
+	case "rotate", "same":
+	default:
+		return fmt.Errorf("OTP_RESEND_MODE must be rotate or same")
+	}
+	resendCodeSecret := os.Getenv("OTP_RESEND_CODE_SECRET")
+	if resendCodeSecret != "" && len(resendCodeSecret) < 16 {
+		return fmt.Errorf("OTP_RESEND_CODE_SECRET must be at least 16 bytes")
+	}
+
+	codeHashKeys, err := parseCodeHashKeys(os.Getenv("OTP_CODE_HASH_KEYS"))
+	if err != nil {
+		return err
+	}
+	codeHashKeyID := strings.TrimSpace(os.Getenv("OTP_CODE_HASH_KEY_ID"))
+	if len(codeHashKeys) > 0 && codeHashKeyID == "" {
+		return fmt.Errorf("OTP_CODE_HASH_KEY_ID is required when OTP_CODE_HASH_KEYS is set")
+	}
+	if codeHashKeyID != "" {
+		if _, ok := codeHashKeys[codeHashKeyID]; !ok {
+			return fmt.Errorf("OTP_CODE_HASH_KEY_ID %q not found in OTP_CODE_HASH_KEYS", codeHashKeyID)
+		}
+	}
+
+	circuitWindowSize, err := parsePositiveIntEnv("OTP_SMS_CIRCUIT_WINDOW_SIZE", "20")
+	if err != nil {
+		return err
+	}
+	circuitMinCalls, err := parsePositiveIntEnv("OTP_SMS_CIRCUIT_MIN_CALLS", "10")
+	if err != nil {
+		return err
+	}
+	if circuitMinCalls > circuitWindowSize {
+		return fmt.Errorf("OTP_SMS_CIRCUIT_MIN_CALLS must be <= OTP_SMS_CIRCUIT_WINDOW_SIZE")
+	}
+	circuitFailureRate, err := parseRateEnv("OTP_SMS_CIRCUIT_FAILURE_RATE", "0.5")
+	if err != nil {
+		return err
+	}
+	circuitSlowCallDuration, err := parsePositiveDurationEnv("OTP_SMS_CIRCUIT_SLOW_CALL_DURATION", "1s")
+	if err != nil {
+		return err
+	}
+	circuitSlowCallRate, err := parseRateEnv("OTP_SMS_CIRCUIT_SLOW_CALL_RATE", "0.8")
+	if err != nil {
+		return err
+	}
+	circuitOpenDuration, err := parsePositiveDurationEnv("OTP_SMS_CIRCUIT_OPEN_DURATION", "30s")
+	if err != nil {
+		return err
+	}
+	circuitHalfOpenCalls, err := parsePositiveIntEnv("OTP_SMS_CIRCUIT_HALF_OPEN_CALLS", "3")
+	if err != nil {
+		return err
+	}
+	circuitFailureRates, err := parseProviderRates("OTP_SMS_CIRCUIT_FAILURE_RATES", os.Getenv("OTP_SMS_CIRCUIT_FAILURE_RATES"))
+	if err != nil {
+		return err
+	}
+	circuitSlowCallDurations, err := parseProviderDurations("OTP_SMS_CIRCUIT_SLOW_CALL_DURATIONS", os.Getenv("OTP_SMS_CIRCUIT_SLOW_CALL_DURATIONS"))
+	if err != nil {
+		return err
+	}
+
+	retryMaxAttempts, err := parsePositiveIntEnv("OTP_SMS_RETRY_MAX_ATTEMPTS", "3")
+	if err != nil {
+		return err
+	}
+	retryBaseDelay, err := parsePositiveDurationEnv("OTP_SMS_RETRY_BASE_DELAY", "100ms")
+	if err != nil {
+		return err
+	}
+	retryMaxDelay, err := parsePositiveDurationEnv("OTP_SMS_RETRY_MAX_DELAY", "1s")
+	if err != nil {
+		return err
+	}
+	if retryMaxDelay < retryBaseDelay {
+		return fmt.Errorf("OTP_SMS_RETRY_MAX_DELAY must be >= OTP_SMS_RETRY_BASE_DELAY")
+	}
+	retryBudgetRatio, err := parseRateEnv("OTP_SMS_RETRY_BUDGET_RATIO", "0.1")
+	if err != nil {
+		return err
+	}
+	retryBudgetBurst, err := parsePositiveIntEnv("OTP_SMS_RETRY_BUDGET_BURST", "10")
+	if err != nil {
+		return err
+	}
+
+	fakeProvidersStr := os.Getenv("OTP_SMS_FAKE_PROVIDERS")
+	if fakeProvidersStr == "" {
+		fakeProvidersStr = "other"
+	}
+	fakeProviders := parseCommaSeparatedList(strings.ToLower(fakeProvidersStr))
 
 	cfg.OTP = OTPConfig{
 		CodeLength:            codeLength,
@@ -657,6 +971,7 @@ func loadOTPConfig(cfg *Config) error {
 		MaxAttempts:           maxAttempts,
 		TenantCacheTTL:        tenantCacheTTL,
 		ProviderTimeout:       providerTimeout,
+		FailoverTimeout:       failoverTimeout,
 		FakeSMSMinDelay:       fakeSMSMinDelay,
 		FakeSMSMaxDelay:       fakeSMSMaxDelay,
 		FakeSMSDebugCodeRedis: parseBoolEnv("OTP_FAKE_SMS_DEBUG_CODE_REDIS"),
@@ -664,11 +979,99 @@ func loadOTPConfig(cfg *Config) error {
 		SendRateLimitEnabled:  parseBoolEnv("OTP_SEND_RATE_LIMIT_ENABLED"),
 		SendRateLimitMax:      sendRateLimitMax,
 		SendRateLimitWindow:   sendRateLimitWindow,
+		CodeHashKeyID:         codeHashKeyID,
+		CodeHashKeys:          codeHashKeys,
+		SMSFakeProviders:      fakeProviders,
+		KavenegarBaseURL:      strings.TrimSpace(os.Getenv("OTP_KAVENEGAR_BASE_URL")),
+		KavenegarTemplate:     strings.TrimSpace(os.Getenv("OTP_KAVENEGAR_TEMPLATE")),
+		TwilioBaseURL:         strings.TrimSpace(os.Getenv("OTP_TWILIO_BASE_URL")),
+		TwilioSender:          strings.TrimSpace(os.Getenv("OTP_TWILIO_SENDER")),
+		GhasedakBaseURL:       strings.TrimSpace(os.Getenv("OTP_GHASEDAK_BASE_URL")),
+		GhasedakTemplate:      strings.TrimSpace(os.Getenv("OTP_GHASEDAK_TEMPLATE")),
+
+		SMSCircuitWindowSize:        circuitWindowSize,
+		SMSCircuitMinCalls:          circuitMinCalls,
+		SMSCircuitFailureRate:       circuitFailureRate,
+		SMSCircuitSlowCallDuration:  circuitSlowCallDuration,
+		SMSCircuitSlowCallRate:      circuitSlowCallRate,
+		SMSCircuitOpenDuration:      circuitOpenDuration,
+		SMSCircuitHalfOpenCalls:     circuitHalfOpenCalls,
+		SMSCircuitFailureRates:      circuitFailureRates,
+		SMSCircuitSlowCallDurations: circuitSlowCallDurations,
+
+		SMSRetryMaxAttempts: retryMaxAttempts,
+		SMSRetryBaseDelay:   retryBaseDelay,
+		SMSRetryMaxDelay:    retryMaxDelay,
+		SMSRetryBudgetRatio: retryBudgetRatio,
+		SMSRetryBudgetBurst: retryBudgetBurst,
+
+		FakeSMSErrorRate:           fakeSMSErrorRate,
+		FakeSMSHangRate:            fakeSMSHangRate,
+		FakeSMSLatencyDistribution: fakeSMSLatencyDistribution,
+		FakeSMSParetoAlpha:         fakeSMSParetoAlpha,
+		FakeSMSLatencyCap:          fakeSMSLatencyCap,
+		FakeSMSPhoneOutcomes:       fakeSMSPhoneOutcomes,
+
+		TenantRateLimitEnabled: parseBoolEnv("OTP_TENANT_RATE_LIMIT_ENABLED"),
+		SendRateLimitAlgorithm: sendRateLimitAlgorithm,
+
+		IPRateLimitEnabled:   parseBoolEnv("OTP_IP_RATE_LIMIT_ENABLED"),
+		IPRateLimitMax:       ipRateLimitMax,
+		IPRateLimitWindow:    ipRateLimitWindow,
+		IPRateLimitAllowlist: ipRateLimitAllowlist,
+
+		LockoutEnabled:       parseBoolEnv("OTP_LOCKOUT_ENABLED"),
+		LockoutMaxFailures:   lockoutMaxFailures,
+		LockoutFailureWindow: lockoutFailureWindow,
+		LockoutDurations:     lockoutDurations,
+
+		SendRateLimitFallbackEnabled:       parseBoolEnv("OTP_SEND_RATE_LIMIT_FALLBACK_ENABLED"),
+		SendRateLimitFallbackMaxKeys:       sendRateLimitFallbackMaxKeys,
+		SendRateLimitFallbackProbeInterval: sendRateLimitFallbackProbeInterval,
+
+		GlobalPhoneRateLimitEnabled: parseBoolEnv("OTP_GLOBAL_PHONE_RATE_LIMIT_ENABLED"),
+		GlobalPhoneRateLimitMax:     globalPhoneRateLimitMax,
+		GlobalPhoneRateLimitWindow:  globalPhoneRateLimitWindow,
+
+		DefaultPhoneRegion: strings.ToUpper(strings.TrimSpace(os.Getenv("OTP_DEFAULT_PHONE_REGION"))),
+
+		PhoneKeySecret:      phoneKeySecret,
+		PhoneKeyLegacyReads: parseBoolEnvDefault("OTP_PHONE_KEY_LEGACY_READS", true),
+
+		ResendCooldown:   resendCooldown,
+		MaxResends:       maxResends,
+		ResendMode:       resendMode,
+		ResendCodeSecret: resendCodeSecret,
 	}
 
 	return nil
 }
 
+// parseCodeHashKeys parses OTP_CODE_HASH_KEYS in the form "key_id:secret,key_id:secret".
+// Secrets may contain ':' since only the first separator is significant.
+func parseCodeHashKeys(s string) (map[string]string, error) {
+	keys := make(map[string]string)
+	for _, entry := range parseCommaSeparatedList(s) {
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid OTP_CODE_HASH_KEYS entry: expected key_id:secret")
+		}
+		keyID := strings.TrimSpace(parts[0])
+		secret := strings.TrimSpace(parts[1])
+		if keyID == "" {
+			return nil, fmt.Errorf("invalid OTP_CODE_HASH_KEYS entry: key id must not be empty")
+		}
+		if len(secret) < 16 {
+			return nil, fmt.Errorf("OTP_CODE_HASH_KEYS secret for %q must be at least 16 characters", keyID)
+		}
+		if _, exists := keys[keyID]; exists {
+			return nil, fmt.Errorf("duplicate OTP_CODE_HASH_KEYS key id %q", keyID)
+		}
+		keys[keyID] = secret
+	}
+	return keys, nil
+}
+
 func parseDurationEnv(key string, defaultValue string) (time.Duration, error) {
 	value := os.Getenv(key)
 	if value == "" {
@@ -692,11 +1095,157 @@ func parsePositiveDurationEnv(key string, defaultValue string) (time.Duration, e
 	return parsed, nil
 }
 
+func parsePositiveIntEnv(key string, defaultValue string) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		value = defaultValue
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	if parsed <= 0 {
+		return 0, fmt.Errorf("%s must be > 0", key)
+	}
+	return parsed, nil
+}
+
+// parseProbabilityEnv parses a probability in [0, 1].
+func parseProbabilityEnv(key string, defaultValue string) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		value = defaultValue
+	}
+	parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	if parsed < 0 || parsed > 1 {
+		return 0, fmt.Errorf("%s must be in [0, 1]", key)
+	}
+	return parsed, nil
+}
+
+// parseRateEnv parses a fraction in (0, 1].
+func parseRateEnv(key string, defaultValue string) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		value = defaultValue
+	}
+	return parseRate(key, value)
+}
+
+func parseRate(key string, value string) (float64, error) {
+	parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	if parsed <= 0 || parsed > 1 {
+		return 0, fmt.Errorf("%s must be in (0, 1]", key)
+	}
+	return parsed, nil
+}
+
+// parseIPList parses a comma-separated list of IPs and CIDRs such as "10.0.0.1,172.16.0.0/12".
+func parseIPList(key string, s string) ([]string, error) {
+	entries := parseCommaSeparatedList(s)
+	for _, entry := range entries {
+		var err error
+		if strings.Contains(entry, "/") {
+			_, err = netip.ParsePrefix(entry)
+		} else {
+			_, err = netip.ParseAddr(entry)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s entry %q: expected an IP or CIDR", key, entry)
+		}
+	}
+	return entries, nil
+}
+
+// parseDurationList parses a comma-separated list of positive durations such as "15m,1h".
+func parseDurationList(key string, s string) ([]time.Duration, error) {
+	entries := parseCommaSeparatedList(s)
+	durations := make([]time.Duration, 0, len(entries))
+	for _, entry := range entries {
+		duration, err := time.ParseDuration(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", key, err)
+		}
+		if duration <= 0 {
+			return nil, fmt.Errorf("%s must be > 0", key)
+		}
+		durations = append(durations, duration)
+	}
+	return durations, nil
+}
+
+// parseKeyValueList splits "name:value,name:value" into lower-cased names.
+func parseKeyValueList(key string, s string) (map[string]string, error) {
+	overrides := make(map[string]string)
+	for _, entry := range parseCommaSeparatedList(s) {
+		provider, value, ok := strings.Cut(entry, ":")
+		provider = strings.ToLower(strings.TrimSpace(provider))
+		if !ok || provider == "" || strings.TrimSpace(value) == "" {
+			return nil, fmt.Errorf("invalid %s entry %q: expected name:value", key, entry)
+		}
+		overrides[provider] = strings.TrimSpace(value)
+	}
+	return overrides, nil
+}
+
+func parseProviderRates(key string, s string) (map[string]float64, error) {
+	overrides, err := parseKeyValueList(key, s)
+	if err != nil {
+		return nil, err
+	}
+	rates := make(map[string]float64, len(overrides))
+	for provider, value := range overrides {
+		rate, err := parseRate(key, value)
+		if err != nil {
+			return nil, err
+		}
+		rates[provider] = rate
+	}
+	return rates, nil
+}
+
+func parseProviderDurations(key string, s string) (map[string]time.Duration, error) {
+	overrides, err := parseKeyValueList(key, s)
+	if err != nil {
+		return nil, err
+	}
+	durations := make(map[string]time.Duration, len(overrides))
+	for provider, value := range overrides {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", key, err)
+		}
+		if duration <= 0 {
+			return nil, fmt.Errorf("%s must be > 0", key)
+		}
+		durations[provider] = duration
+	}
+	return durations, nil
+}
+
 func parseBoolEnv(key string) bool {
 	value := os.Getenv(key)
 	return value == "true" || value == "1"
 }
 
+// parseBoolEnvDefault is parseBoolEnv for settings that are on unless set to "false" or "0".
+func parseBoolEnvDefault(key string, defaultValue bool) bool {
+	switch os.Getenv(key) {
+	case "true", "1":
+		return true
+	case "false", "0":
+		return false
+	default:
+		return defaultValue
+	}
+}
+
 // loadTracingConfig loads and validates tracing configuration
 func loadTracingConfig(cfg *Config) error {
 	enabledStr := os.Getenv("OTEL_TRACING_ENABLED")
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 
@@ -59,6 +60,36 @@ func TestLoadServerConfig(t *testing.T) {
 	}
 }
 
+func TestLoadServerConfigTrustedProxies(t *testing.T) {
+	t.Setenv("SERVER_HOST", "127.0.0.1")
+	t.Setenv("SERVER_PORT", "3000")
+	t.Setenv("SERVER_READ_TIMEOUT", "15s")
+	t.Setenv("SERVER_WRITE_TIMEOUT", "15s")
+	t.Setenv("SERVER_GRACEFUL_SHUTDOWN_TIMEOUT", "10s")
+
+	t.Setenv("SERVER_TRUSTED_PROXIES", "")
+	cfg := &Config{}
+	if err := loadServerConfig(cfg); err != nil {
+		t.Fatalf("Failed to load server config: %v", err)
+	}
+	if len(cfg.Server.TrustedProxies) != 0 {
+		t.Errorf("Expected SERVER_TRUSTED_PROXIES default to be empty, got %v", cfg.Server.TrustedProxies)
+	}
+
+	t.Setenv("SERVER_TRUSTED_PROXIES", "172.16.0.0/12, 10.0.0.5, ::1")
+	if err := loadServerConfig(cfg); err != nil {
+		t.Fatalf("Failed to load server config: %v", err)
+	}
+	if !reflect.DeepEqual(cfg.Server.TrustedProxies, []string{"172.16.0.0/12", "10.0.0.5", "::1"}) {
+		t.Errorf("Expected trusted proxies from env, got %v", cfg.Server.TrustedProxies)
+	}
+
+	t.Setenv("SERVER_TRUSTED_PROXIES", "traefik")
+	if err := loadServerConfig(cfg); err == nil {
+		t.Error("Expected error for a trusted proxy that is not an IP or CIDR")
+	}
+}
+
 func TestLoadConfigMissingRequiredFields(t *testing.T) {
 	// Clear required environment variables (DB now has defaults, but JWT still required)
 	os.Unsetenv("JWT_SECRET_KEY")
@@ -272,6 +303,9 @@ func TestLoadOTPConfigDefaults(t *testing.T) {
 	if cfg.OTP.ProviderTimeout != 2*time.Second {
 		t.Errorf("Expected OTP_PROVIDER_TIMEOUT default to be 2s, got %v", cfg.OTP.ProviderTimeout)
 	}
+	if cfg.OTP.FailoverTimeout != 5*time.Second {
+		t.Errorf("Expected OTP_PROVIDER_FAILOVER_TIMEOUT default to be 5s, got %v", cfg.OTP.FailoverTimeout)
+	}
 	if cfg.OTP.FakeSMSMinDelay != 20*time.Millisecond {
 		t.Errorf("Expected OTP_FAKE_SMS_MIN_DELAY default to be 20ms, got %v", cfg.OTP.FakeSMSMinDelay)
 	}
@@ -293,6 +327,107 @@ func TestLoadOTPConfigDefaults(t *testing.T) {
 	if cfg.OTP.SendRateLimitWindow != 10*time.Minute {
 		t.Errorf("Expected OTP_SEND_RATE_LIMIT_WINDOW default to be 10m, got %v", cfg.OTP.SendRateLimitWindow)
 	}
+	if cfg.OTP.CodeHashKeyID != "" {
+		t.Errorf("Expected OTP_CODE_HASH_KEY_ID default to be empty, got %q", cfg.OTP.CodeHashKeyID)
+	}
+	if len(cfg.OTP.CodeHashKeys) != 0 {
+		t.Errorf("Expected OTP_CODE_HASH_KEYS default to be empty, got %d keys", len(cfg.OTP.CodeHashKeys))
+	}
+	if !reflect.DeepEqual(cfg.OTP.SMSFakeProviders, []string{"other"}) {
+		t.Errorf("Expected OTP_SMS_FAKE_PROVIDERS default to be [other], got %v", cfg.OTP.SMSFakeProviders)
+	}
+	if cfg.OTP.KavenegarBaseURL != "" || cfg.OTP.TwilioBaseURL != "" || cfg.OTP.GhasedakBaseURL != "" {
+		t.Error("Expected SMS provider base URL defaults to be empty")
+	}
+	if cfg.OTP.SMSCircuitWindowSize != 20 || cfg.OTP.SMSCircuitMinCalls != 10 || cfg.OTP.SMSCircuitHalfOpenCalls != 3 {
+		t.Errorf("Expected SMS circuit window/min calls/half-open calls defaults 20/10/3, got %d/%d/%d",
+			cfg.OTP.SMSCircuitWindowSize, cfg.OTP.SMSCircuitMinCalls, cfg.OTP.SMSCircuitHalfOpenCalls)
+	}
+	if cfg.OTP.SMSCircuitFailureRate != 0.5 || cfg.OTP.SMSCircuitSlowCallRate != 0.8 {
+		t.Errorf("Expected SMS circuit failure/slow call rate defaults 0.5/0.8, got %v/%v",
+			cfg.OTP.SMSCircuitFailureRate, cfg.OTP.SMSCircuitSlowCallRate)
+	}
+	if cfg.OTP.SMSCircuitSlowCallDuration != time.Second || cfg.OTP.SMSCircuitOpenDuration != 30*time.Second {
+		t.Errorf("Expected SMS circuit slow call/open duration defaults 1s/30s, got %v/%v",
+			cfg.OTP.SMSCircuitSlowCallDuration, cfg.OTP.SMSCircuitOpenDuration)
+	}
+	if len(cfg.OTP.SMSCircuitFailureRates) != 0 || len(cfg.OTP.SMSCircuitSlowCallDurations) != 0 {
+		t.Error("Expected SMS circuit per-provider overrides default to be empty")
+	}
+	if cfg.OTP.SMSRetryMaxAttempts != 3 || cfg.OTP.SMSRetryBaseDelay != 100*time.Millisecond || cfg.OTP.SMSRetryMaxDelay != time.Second {
+		t.Errorf("Expected SMS retry attempts/base/max delay defaults 3/100ms/1s, got %d/%v/%v",
+			cfg.OTP.SMSRetryMaxAttempts, cfg.OTP.SMSRetryBaseDelay, cfg.OTP.SMSRetryMaxDelay)
+	}
+	if cfg.OTP.SMSRetryBudgetRatio != 0.1 || cfg.OTP.SMSRetryBudgetBurst != 10 {
+		t.Errorf("Expected SMS retry budget ratio/burst defaults 0.1/10, got %v/%d",
+			cfg.OTP.SMSRetryBudgetRatio, cfg.OTP.SMSRetryBudgetBurst)
+	}
+	if cfg.OTP.FakeSMSErrorRate != 0 || cfg.OTP.FakeSMSHangRate != 0 {
+		t.Errorf("Expected fake SMS error/hang rate defaults 0/0, got %v/%v", cfg.OTP.FakeSMSErrorRate, cfg.OTP.FakeSMSHangRate)
+	}
+	if cfg.OTP.FakeSMSLatencyDistribution != "uniform" {
+		t.Errorf("Expected OTP_FAKE_SMS_LATENCY_DISTRIBUTION default to be uniform, got %q", cfg.OTP.FakeSMSLatencyDistribution)
+	}
+	if cfg.OTP.FakeSMSParetoAlpha != 1.5 || cfg.OTP.FakeSMSLatencyCap != 10*time.Second {
+		t.Errorf("Expected fake SMS pareto alpha/latency cap defaults 1.5/10s, got %v/%v", cfg.OTP.FakeSMSParetoAlpha, cfg.OTP.FakeSMSLatencyCap)
+	}
+	if len(cfg.OTP.FakeSMSPhoneOutcomes) != 0 {
+		t.Errorf("Expected OTP_FAKE_SMS_PHONE_OUTCOMES default to be empty, got %v", cfg.OTP.FakeSMSPhoneOutcomes)
+	}
+	if cfg.OTP.TenantRateLimitEnabled {
+		t.Error("Expected OTP_TENANT_RATE_LIMIT_ENABLED default to be false")
+	}
+	if cfg.OTP.SendRateLimitAlgorithm != "fixed_window" {
+		t.Errorf("Expected OTP_SEND_RATE_LIMIT_ALGORITHM default to be fixed_window, got %q", cfg.OTP.SendRateLimitAlgorithm)
+	}
+	if cfg.OTP.IPRateLimitEnabled {
+		t.Error("Expected OTP_IP_RATE_LIMIT_ENABLED default to be false")
+	}
+	if cfg.OTP.IPRateLimitMax != 30 || cfg.OTP.IPRateLimitWindow != time.Minute {
+		t.Errorf("Expected IP rate limit max/window defaults 30/1m, got %d/%v", cfg.OTP.IPRateLimitMax, cfg.OTP.IPRateLimitWindow)
+	}
+	if len(cfg.OTP.IPRateLimitAllowlist) != 0 {
+		t.Errorf("Expected OTP_IP_RATE_LIMIT_ALLOWLIST default to be empty, got %v", cfg.OTP.IPRateLimitAllowlist)
+	}
+	if cfg.OTP.LockoutEnabled {
+		t.Error("Expected OTP_LOCKOUT_ENABLED default to be false")
+	}
+	if cfg.OTP.LockoutMaxFailures != 10 || cfg.OTP.LockoutFailureWindow != 24*time.Hour {
+		t.Errorf("Expected lockout max failures/window defaults 10/24h, got %d/%v", cfg.OTP.LockoutMaxFailures, cfg.OTP.LockoutFailureWindow)
+	}
+	if !reflect.DeepEqual(cfg.OTP.LockoutDurations, []time.Duration{15 * time.Minute, time.Hour, 24 * time.Hour}) {
+		t.Errorf("Expected OTP_LOCKOUT_DURATIONS default 15m,1h,24h, got %v", cfg.OTP.LockoutDurations)
+	}
+	if cfg.OTP.SendRateLimitFallbackEnabled {
+		t.Error("Expected OTP_SEND_RATE_LIMIT_FALLBACK_ENABLED default to be false")
+	}
+	if cfg.OTP.SendRateLimitFallbackMaxKeys != 10000 || cfg.OTP.SendRateLimitFallbackProbeInterval != 5*time.Second {
+		t.Errorf("Expected send rate limit fallback max keys/probe interval defaults 10000/5s, got %d/%v",
+			cfg.OTP.SendRateLimitFallbackMaxKeys, cfg.OTP.SendRateLimitFallbackProbeInterval)
+	}
+	if cfg.OTP.GlobalPhoneRateLimitEnabled {
+		t.Error("Expected OTP_GLOBAL_PHONE_RATE_LIMIT_ENABLED default to be false")
+	}
+	if cfg.OTP.GlobalPhoneRateLimitMax != 10 || cfg.OTP.GlobalPhoneRateLimitWindow != time.Hour {
+		t.Errorf("Expected global phone rate limit max/window defaults 10/1h, got %d/%v",
+			cfg.OTP.GlobalPhoneRateLimitMax, cfg.OTP.GlobalPhoneRateLimitWindow)
+	}
+	if cfg.OTP.DefaultPhoneRegion != "" {
+		t.Errorf("Expected OTP_DEFAULT_PHONE_REGION default to be empty, got %q", cfg.OTP.DefaultPhoneRegion)
+	}
+	if cfg.OTP.PhoneKeySecret != "" {
+		t.Error("Expected OTP_PHONE_KEY_SECRET default to be empty")
+	}
+	if !cfg.OTP.PhoneKeyLegacyReads {
+		t.Error("Expected OTP_PHONE_KEY_LEGACY_READS default to be true")
+	}
+	if cfg.OTP.ResendCooldown != 30*time.Second || cfg.OTP.MaxResends != 3 || cfg.OTP.ResendMode != "rotate" {
+		t.Errorf("Expected resend cooldown/max/mode defaults 30s/3/rotate, got %v/%d/%q",
+			cfg.OTP.ResendCooldown, cfg.OTP.MaxResends, cfg.OTP.ResendMode)
+	}
+	if cfg.OTP.ResendCodeSecret != "" {
+		t.Error("Expected OTP_RESEND_CODE_SECRET default to be empty")
+	}
 }
 
 func TestLoadOTPConfigFromEnv(t *testing.T) {
@@ -302,6 +437,7 @@ func TestLoadOTPConfigFromEnv(t *testing.T) {
 	t.Setenv("OTP_MAX_ATTEMPTS", "5")
 	t.Setenv("OTP_TENANT_CACHE_TTL", "7m")
 	t.Setenv("OTP_PROVIDER_TIMEOUT", "1500ms")
+	t.Setenv("OTP_PROVIDER_FAILOVER_TIMEOUT", "4s")
 	t.Setenv("OTP_FAKE_SMS_MIN_DELAY", "5ms")
 	t.Setenv("OTP_FAKE_SMS_MAX_DELAY", "10ms")
 	t.Setenv("OTP_FAKE_SMS_DEBUG_CODE_REDIS", "true")
@@ -309,6 +445,58 @@ func TestLoadOTPConfigFromEnv(t *testing.T) {
 	t.Setenv("OTP_SEND_RATE_LIMIT_ENABLED", "true")
 	t.Setenv("OTP_SEND_RATE_LIMIT_MAX", "9")
 	t.Setenv("OTP_SEND_RATE_LIMIT_WINDOW", "15m")
+	t.Setenv("OTP_CODE_HASH_KEY_ID", "2026-10")
+	t.Setenv("OTP_CODE_HASH_KEYS", "2026-10:new-pepper-0123456789, 2026-04:old:pepper-0123456789")
+	t.Setenv("OTP_SMS_FAKE_PROVIDERS", "Other, kavenegar")
+	t.Setenv("OTP_KAVENEGAR_BASE_URL", "http://kavenegar.local")
+	t.Setenv("OTP_KAVENEGAR_TEMPLATE", "verify")
+	t.Setenv("OTP_TWILIO_BASE_URL", "http://twilio.local")
+	t.Setenv("OTP_TWILIO_SENDER", "+15557122661")
+	t.Setenv("OTP_GHASEDAK_BASE_URL", "http://ghasedak.local")
+	t.Setenv("OTP_GHASEDAK_TEMPLATE", "otp-login")
+	t.Setenv("OTP_SMS_CIRCUIT_WINDOW_SIZE", "40")
+	t.Setenv("OTP_SMS_CIRCUIT_MIN_CALLS", "15")
+	t.Setenv("OTP_SMS_CIRCUIT_FAILURE_RATE", "0.4")
+	t.Setenv("OTP_SMS_CIRCUIT_SLOW_CALL_DURATION", "2s")
+	t.Setenv("OTP_SMS_CIRCUIT_SLOW_CALL_RATE", "0.6")
+	t.Setenv("OTP_SMS_CIRCUIT_OPEN_DURATION", "1m")
+	t.Setenv("OTP_SMS_CIRCUIT_HALF_OPEN_CALLS", "5")
+	t.Setenv("OTP_SMS_CIRCUIT_FAILURE_RATES", "Twilio:0.3, kavenegar:0.7")
+	t.Setenv("OTP_SMS_CIRCUIT_SLOW_CALL_DURATIONS", "twilio:1500ms")
+	t.Setenv("OTP_SMS_RETRY_MAX_ATTEMPTS", "1")
+	t.Setenv("OTP_SMS_RETRY_BASE_DELAY", "50ms")
+	t.Setenv("OTP_SMS_RETRY_MAX_DELAY", "400ms")
+	t.Setenv("OTP_SMS_RETRY_BUDGET_RATIO", "0.25")
+	t.Setenv("OTP_SMS_RETRY_BUDGET_BURST", "4")
+	t.Setenv("OTP_FAKE_SMS_ERROR_RATE", "0.2")
+	t.Setenv("OTP_FAKE_SMS_HANG_RATE", "0.05")
+	t.Setenv("OTP_FAKE_SMS_LATENCY_DISTRIBUTION", "Pareto")
+	t.Setenv("OTP_FAKE_SMS_PARETO_ALPHA", "1.2")
+	t.Setenv("OTP_FAKE_SMS_LATENCY_CAP", "3s")
+	t.Setenv("OTP_FAKE_SMS_PHONE_OUTCOMES", "0001:unavailable, 0002:hang")
+	t.Setenv("OTP_TENANT_RATE_LIMIT_ENABLED", "true")
+	t.Setenv("OTP_SEND_RATE_LIMIT_ALGORITHM", "GCRA")
+	t.Setenv("OTP_IP_RATE_LIMIT_ENABLED", "true")
+	t.Setenv("OTP_IP_RATE_LIMIT_MAX", "100")
+	t.Setenv("OTP_IP_RATE_LIMIT_WINDOW", "30s")
+	t.Setenv("OTP_IP_RATE_LIMIT_ALLOWLIST", "10.0.0.0/8, 192.0.2.1")
+	t.Setenv("OTP_LOCKOUT_ENABLED", "true")
+	t.Setenv("OTP_LOCKOUT_MAX_FAILURES", "5")
+	t.Setenv("OTP_LOCKOUT_FAILURE_WINDOW", "12h")
+	t.Setenv("OTP_LOCKOUT_DURATIONS", "5m, 30m")
+	t.Setenv("OTP_SEND_RATE_LIMIT_FALLBACK_ENABLED", "true")
+	t.Setenv("OTP_SEND_RATE_LIMIT_FALLBACK_MAX_KEYS", "500")
+	t.Setenv("OTP_SEND_RATE_LIMIT_FALLBACK_PROBE_INTERVAL", "1s")
+	t.Setenv("OTP_GLOBAL_PHONE_RATE_LIMIT_ENABLED", "true")
+	t.Setenv("OTP_GLOBAL_PHONE_RATE_LIMIT_MAX", "20")
+	t.Setenv("OTP_GLOBAL_PHONE_RATE_LIMIT_WINDOW", "6h")
+	t.Setenv("OTP_DEFAULT_PHONE_REGION", " ir ")
+	t.Setenv("OTP_PHONE_KEY_SECRET", "0123456789abcdef")
+	t.Setenv("OTP_PHONE_KEY_LEGACY_READS", "false")
+	t.Setenv("OTP_RESEND_COOLDOWN", "45s")
+	t.Setenv("OTP_MAX_RESENDS", "5")
+	t.Setenv("OTP_RESEND_MODE", " Same ")
+	t.Setenv("OTP_RESEND_CODE_SECRET", "fedcba9876543210")
 
 	cfg := &Config{}
 	err := loadOTPConfig(cfg)
@@ -331,6 +519,9 @@ func TestLoadOTPConfigFromEnv(t *testing.T) {
 	if cfg.OTP.ProviderTimeout != 1500*time.Millisecond {
 		t.Errorf("Expected ProviderTimeout=1500ms, got %v", cfg.OTP.ProviderTimeout)
 	}
+	if cfg.OTP.FailoverTimeout != 4*time.Second {
+		t.Errorf("Expected FailoverTimeout=4s, got %v", cfg.OTP.FailoverTimeout)
+	}
 	if cfg.OTP.FakeSMSMinDelay != 5*time.Millisecond {
 		t.Errorf("Expected FakeSMSMinDelay=5ms, got %v", cfg.OTP.FakeSMSMinDelay)
 	}
@@ -352,6 +543,104 @@ func TestLoadOTPConfigFromEnv(t *testing.T) {
 	if cfg.OTP.SendRateLimitWindow != 15*time.Minute {
 		t.Errorf("Expected SendRateLimitWindow=15m, got %v", cfg.OTP.SendRateLimitWindow)
 	}
+	if cfg.OTP.CodeHashKeyID != "2026-10" {
+		t.Errorf("Expected CodeHashKeyID=2026-10, got %q", cfg.OTP.CodeHashKeyID)
+	}
+	if cfg.OTP.CodeHashKeys["2026-10"] != "new-pepper-0123456789" {
+		t.Errorf("Expected current code hash key to be parsed, got %q", cfg.OTP.CodeHashKeys["2026-10"])
+	}
+	if cfg.OTP.CodeHashKeys["2026-04"] != "old:pepper-0123456789" {
+		t.Errorf("Expected previous code hash key to keep ':' in secret, got %q", cfg.OTP.CodeHashKeys["2026-04"])
+	}
+	if !reflect.DeepEqual(cfg.OTP.SMSFakeProviders, []string{"other", "kavenegar"}) {
+		t.Errorf("Expected SMSFakeProviders=[other kavenegar], got %v", cfg.OTP.SMSFakeProviders)
+	}
+	if cfg.OTP.KavenegarBaseURL != "http://kavenegar.local" || cfg.OTP.KavenegarTemplate != "verify" {
+		t.Errorf("Expected Kavenegar settings from env, got %q %q", cfg.OTP.KavenegarBaseURL, cfg.OTP.KavenegarTemplate)
+	}
+	if cfg.OTP.TwilioBaseURL != "http://twilio.local" || cfg.OTP.TwilioSender != "+15557122661" {
+		t.Errorf("Expected Twilio settings from env, got %q %q", cfg.OTP.TwilioBaseURL, cfg.OTP.TwilioSender)
+	}
+	if cfg.OTP.GhasedakBaseURL != "http://ghasedak.local" || cfg.OTP.GhasedakTemplate != "otp-login" {
+		t.Errorf("Expected Ghasedak settings from env, got %q %q", cfg.OTP.GhasedakBaseURL, cfg.OTP.GhasedakTemplate)
+	}
+	if cfg.OTP.SMSCircuitWindowSize != 40 || cfg.OTP.SMSCircuitMinCalls != 15 || cfg.OTP.SMSCircuitHalfOpenCalls != 5 {
+		t.Errorf("Expected SMS circuit window/min calls/half-open calls 40/15/5, got %d/%d/%d",
+			cfg.OTP.SMSCircuitWindowSize, cfg.OTP.SMSCircuitMinCalls, cfg.OTP.SMSCircuitHalfOpenCalls)
+	}
+	if cfg.OTP.SMSCircuitFailureRate != 0.4 || cfg.OTP.SMSCircuitSlowCallRate != 0.6 {
+		t.Errorf("Expected SMS circuit failure/slow call rate 0.4/0.6, got %v/%v",
+			cfg.OTP.SMSCircuitFailureRate, cfg.OTP.SMSCircuitSlowCallRate)
+	}
+	if cfg.OTP.SMSCircuitSlowCallDuration != 2*time.Second || cfg.OTP.SMSCircuitOpenDuration != time.Minute {
+		t.Errorf("Expected SMS circuit slow call/open duration 2s/1m, got %v/%v",
+			cfg.OTP.SMSCircuitSlowCallDuration, cfg.OTP.SMSCircuitOpenDuration)
+	}
+	if !reflect.DeepEqual(cfg.OTP.SMSCircuitFailureRates, map[string]float64{"twilio": 0.3, "kavenegar": 0.7}) {
+		t.Errorf("Expected per-provider failure rates, got %v", cfg.OTP.SMSCircuitFailureRates)
+	}
+	if !reflect.DeepEqual(cfg.OTP.SMSCircuitSlowCallDurations, map[string]time.Duration{"twilio": 1500 * time.Millisecond}) {
+		t.Errorf("Expected per-provider slow call durations, got %v", cfg.OTP.SMSCircuitSlowCallDurations)
+	}
+	if cfg.OTP.SMSRetryMaxAttempts != 1 || cfg.OTP.SMSRetryBaseDelay != 50*time.Millisecond || cfg.OTP.SMSRetryMaxDelay != 400*time.Millisecond {
+		t.Errorf("Expected SMS retry attempts/base/max delay 1/50ms/400ms, got %d/%v/%v",
+			cfg.OTP.SMSRetryMaxAttempts, cfg.OTP.SMSRetryBaseDelay, cfg.OTP.SMSRetryMaxDelay)
+	}
+	if cfg.OTP.SMSRetryBudgetRatio != 0.25 || cfg.OTP.SMSRetryBudgetBurst != 4 {
+		t.Errorf("Expected SMS retry budget ratio/burst 0.25/4, got %v/%d",
+			cfg.OTP.SMSRetryBudgetRatio, cfg.OTP.SMSRetryBudgetBurst)
+	}
+	if cfg.OTP.FakeSMSErrorRate != 0.2 || cfg.OTP.FakeSMSHangRate != 0.05 {
+		t.Errorf("Expected fake SMS error/hang rate 0.2/0.05, got %v/%v", cfg.OTP.FakeSMSErrorRate, cfg.OTP.FakeSMSHangRate)
+	}
+	if cfg.OTP.FakeSMSLatencyDistribution != "pareto" || cfg.OTP.FakeSMSParetoAlpha != 1.2 || cfg.OTP.FakeSMSLatencyCap != 3*time.Second {
+		t.Errorf("Expected pareto fake SMS latency with alpha 1.2 and cap 3s, got %q/%v/%v",
+			cfg.OTP.FakeSMSLatencyDistribution, cfg.OTP.FakeSMSParetoAlpha, cfg.OTP.FakeSMSLatencyCap)
+	}
+	if !reflect.DeepEqual(cfg.OTP.FakeSMSPhoneOutcomes, map[string]string{"0001": "unavailable", "0002": "hang"}) {
+		t.Errorf("Expected fake SMS phone outcomes, got %v", cfg.OTP.FakeSMSPhoneOutcomes)
+	}
+	if !cfg.OTP.TenantRateLimitEnabled {
+		t.Error("Expected TenantRateLimitEnabled=true")
+	}
+	if cfg.OTP.SendRateLimitAlgorithm != "gcra" {
+		t.Errorf("Expected SendRateLimitAlgorithm=gcra, got %q", cfg.OTP.SendRateLimitAlgorithm)
+	}
+	if !cfg.OTP.IPRateLimitEnabled || cfg.OTP.IPRateLimitMax != 100 || cfg.OTP.IPRateLimitWindow != 30*time.Second {
+		t.Errorf("Expected IP rate limit enabled with max/window 100/30s, got %v %d/%v",
+			cfg.OTP.IPRateLimitEnabled, cfg.OTP.IPRateLimitMax, cfg.OTP.IPRateLimitWindow)
+	}
+	if !reflect.DeepEqual(cfg.OTP.IPRateLimitAllowlist, []string{"10.0.0.0/8", "192.0.2.1"}) {
+		t.Errorf("Expected IP rate limit allowlist, got %v", cfg.OTP.IPRateLimitAllowlist)
+	}
+	if !cfg.OTP.LockoutEnabled || cfg.OTP.LockoutMaxFailures != 5 || cfg.OTP.LockoutFailureWindow != 12*time.Hour {
+		t.Errorf("Expected lockout enabled with max failures/window 5/12h, got %v %d/%v",
+			cfg.OTP.LockoutEnabled, cfg.OTP.LockoutMaxFailures, cfg.OTP.LockoutFailureWindow)
+	}
+	if !reflect.DeepEqual(cfg.OTP.LockoutDurations, []time.Duration{5 * time.Minute, 30 * time.Minute}) {
+		t.Errorf("Expected lockout durations 5m,30m, got %v", cfg.OTP.LockoutDurations)
+	}
+	if !cfg.OTP.SendRateLimitFallbackEnabled || cfg.OTP.SendRateLimitFallbackMaxKeys != 500 || cfg.OTP.SendRateLimitFallbackProbeInterval != time.Second {
+		t.Errorf("Expected send rate limit fallback enabled with max keys/probe interval 500/1s, got %v %d/%v",
+			cfg.OTP.SendRateLimitFallbackEnabled, cfg.OTP.SendRateLimitFallbackMaxKeys, cfg.OTP.SendRateLimitFallbackProbeInterval)
+	}
+	if !cfg.OTP.GlobalPhoneRateLimitEnabled || cfg.OTP.GlobalPhoneRateLimitMax != 20 || cfg.OTP.GlobalPhoneRateLimitWindow != 6*time.Hour {
+		t.Errorf("Expected global phone rate limit enabled with max/window 20/6h, got %v %d/%v",
+			cfg.OTP.GlobalPhoneRateLimitEnabled, cfg.OTP.GlobalPhoneRateLimitMax, cfg.OTP.GlobalPhoneRateLimitWindow)
+	}
+	if cfg.OTP.DefaultPhoneRegion != "IR" {
+		t.Errorf("Expected OTP_DEFAULT_PHONE_REGION IR, got %q", cfg.OTP.DefaultPhoneRegion)
+	}
+	if cfg.OTP.PhoneKeySecret != "0123456789abcdef" || cfg.OTP.PhoneKeyLegacyReads {
+		t.Errorf("Expected phone key secret set with legacy reads off, got %q %v", cfg.OTP.PhoneKeySecret, cfg.OTP.PhoneKeyLegacyReads)
+	}
+	if cfg.OTP.ResendCooldown != 45*time.Second || cfg.OTP.MaxResends != 5 || cfg.OTP.ResendMode != "same" {
+		t.Errorf("Expected resend cooldown/max/mode 45s/5/same, got %v/%d/%q",
+			cfg.OTP.ResendCooldown, cfg.OTP.MaxResends, cfg.OTP.ResendMode)
+	}
+	if cfg.OTP.ResendCodeSecret != "fedcba9876543210" {
+		t.Errorf("Expected OTP_RESEND_CODE_SECRET to be set, got %q", cfg.OTP.ResendCodeSecret)
+	}
 }
 
 func TestLoadOTPConfigValidation(t *testing.T) {
@@ -394,10 +683,198 @@ func TestLoadOTPConfigValidation(t *testing.T) {
 			name: "send rate limit window zero",
 			env:  map[string]string{"OTP_SEND_RATE_LIMIT_WINDOW": "0s"},
 		},
+		{
+			name: "send rate limit algorithm unknown",
+			env:  map[string]string{"OTP_SEND_RATE_LIMIT_ALGORITHM": "leaky_bucket"},
+		},
 		{
 			name: "send rate limit window invalid",
 			env:  map[string]string{"OTP_SEND_RATE_LIMIT_WINDOW": "soon"},
 		},
+		{
+			name: "code hash keys without key id",
+			env:  map[string]string{"OTP_CODE_HASH_KEYS": "k1:pepper-0123456789"},
+		},
+		{
+			name: "code hash key id not in keys",
+			env: map[string]string{
+				"OTP_CODE_HASH_KEY_ID": "k2",
+				"OTP_CODE_HASH_KEYS":   "k1:pepper-0123456789",
+			},
+		},
+		{
+			name: "code hash key id without keys",
+			env:  map[string]string{"OTP_CODE_HASH_KEY_ID": "k1"},
+		},
+		{
+			name: "code hash key too short",
+			env: map[string]string{
+				"OTP_CODE_HASH_KEY_ID": "k1",
+				"OTP_CODE_HASH_KEYS":   "k1:short",
+			},
+		},
+		{
+			name: "code hash key malformed",
+			env: map[string]string{
+				"OTP_CODE_HASH_KEY_ID": "k1",
+				"OTP_CODE_HASH_KEYS":   "k1-pepper-0123456789",
+			},
+		},
+		{
+			name: "code hash key duplicate",
+			env: map[string]string{
+				"OTP_CODE_HASH_KEY_ID": "k1",
+				"OTP_CODE_HASH_KEYS":   "k1:pepper-0123456789,k1:pepper-9876543210",
+			},
+		},
+		{
+			name: "circuit min calls above window",
+			env: map[string]string{
+				"OTP_SMS_CIRCUIT_WINDOW_SIZE": "5",
+				"OTP_SMS_CIRCUIT_MIN_CALLS":   "6",
+			},
+		},
+		{
+			name: "circuit failure rate above one",
+			env:  map[string]string{"OTP_SMS_CIRCUIT_FAILURE_RATE": "1.5"},
+		},
+		{
+			name: "circuit slow call rate zero",
+			env:  map[string]string{"OTP_SMS_CIRCUIT_SLOW_CALL_RATE": "0"},
+		},
+		{
+			name: "circuit open duration zero",
+			env:  map[string]string{"OTP_SMS_CIRCUIT_OPEN_DURATION": "0s"},
+		},
+		{
+			name: "circuit half-open calls zero",
+			env:  map[string]string{"OTP_SMS_CIRCUIT_HALF_OPEN_CALLS": "0"},
+		},
+		{
+			name: "circuit failure rate override malformed",
+			env:  map[string]string{"OTP_SMS_CIRCUIT_FAILURE_RATES": "twilio=0.3"},
+		},
+		{
+			name: "circuit slow call duration override invalid",
+			env:  map[string]string{"OTP_SMS_CIRCUIT_SLOW_CALL_DURATIONS": "twilio:soon"},
+		},
+		{
+			name: "retry max attempts zero",
+			env:  map[string]string{"OTP_SMS_RETRY_MAX_ATTEMPTS": "0"},
+		},
+		{
+			name: "retry max delay less than base",
+			env: map[string]string{
+				"OTP_SMS_RETRY_BASE_DELAY": "500ms",
+				"OTP_SMS_RETRY_MAX_DELAY":  "100ms",
+			},
+		},
+		{
+			name: "retry budget ratio above one",
+			env:  map[string]string{"OTP_SMS_RETRY_BUDGET_RATIO": "2"},
+		},
+		{
+			name: "retry budget burst zero",
+			env:  map[string]string{"OTP_SMS_RETRY_BUDGET_BURST": "0"},
+		},
+		{
+			name: "fake sms error rate negative",
+			env:  map[string]string{"OTP_FAKE_SMS_ERROR_RATE": "-0.1"},
+		},
+		{
+			name: "fake sms error and hang rate above one",
+			env: map[string]string{
+				"OTP_FAKE_SMS_ERROR_RATE": "0.7",
+				"OTP_FAKE_SMS_HANG_RATE":  "0.4",
+			},
+		},
+		{
+			name: "fake sms unknown latency distribution",
+			env:  map[string]string{"OTP_FAKE_SMS_LATENCY_DISTRIBUTION": "gaussian"},
+		},
+		{
+			name: "fake sms pareto alpha zero",
+			env:  map[string]string{"OTP_FAKE_SMS_PARETO_ALPHA": "0"},
+		},
+		{
+			name: "fake sms pareto without min delay",
+			env: map[string]string{
+				"OTP_FAKE_SMS_LATENCY_DISTRIBUTION": "pareto",
+				"OTP_FAKE_SMS_MIN_DELAY":            "0s",
+			},
+		},
+		{
+			name: "fake sms phone outcomes malformed",
+			env:  map[string]string{"OTP_FAKE_SMS_PHONE_OUTCOMES": "0001"},
+		},
+		{
+			name: "ip rate limit max zero",
+			env:  map[string]string{"OTP_IP_RATE_LIMIT_MAX": "0"},
+		},
+		{
+			name: "ip rate limit window zero",
+			env:  map[string]string{"OTP_IP_RATE_LIMIT_WINDOW": "0s"},
+		},
+		{
+			name: "ip rate limit allowlist invalid cidr",
+			env:  map[string]string{"OTP_IP_RATE_LIMIT_ALLOWLIST": "10.0.0.0/33"},
+		},
+		{
+			name: "ip rate limit allowlist hostname",
+			env:  map[string]string{"OTP_IP_RATE_LIMIT_ALLOWLIST": "backend.internal"},
+		},
+		{
+			name: "lockout max failures zero",
+			env:  map[string]string{"OTP_LOCKOUT_MAX_FAILURES": "0"},
+		},
+		{
+			name: "lockout failure window zero",
+			env:  map[string]string{"OTP_LOCKOUT_FAILURE_WINDOW": "0s"},
+		},
+		{
+			name: "lockout durations invalid",
+			env:  map[string]string{"OTP_LOCKOUT_DURATIONS": "15m,forever"},
+		},
+		{
+			name: "lockout durations zero",
+			env:  map[string]string{"OTP_LOCKOUT_DURATIONS": "15m,0s"},
+		},
+		{
+			name: "send rate limit fallback max keys zero",
+			env:  map[string]string{"OTP_SEND_RATE_LIMIT_FALLBACK_MAX_KEYS": "0"},
+		},
+		{
+			name: "send rate limit fallback probe interval zero",
+			env:  map[string]string{"OTP_SEND_RATE_LIMIT_FALLBACK_PROBE_INTERVAL": "0s"},
+		},
+		{
+			name: "global phone rate limit max zero",
+			env:  map[string]string{"OTP_GLOBAL_PHONE_RATE_LIMIT_MAX": "0"},
+		},
+		{
+			name: "global phone rate limit window invalid",
+			env:  map[string]string{"OTP_GLOBAL_PHONE_RATE_LIMIT_WINDOW": "hourly"},
+		},
+		{
+			name: "phone key secret too short",
+			env:  map[string]string{"OTP_PHONE_KEY_SECRET": "short"},
+		},
+		{
+			name: "resend cooldown invalid",
+			env:  map[string]string{"OTP_RESEND_COOLDOWN": "0s"},
+		},
+		{
+			name: "max resends invalid",
+			env:  map[string]string{"OTP_MAX_RESENDS": "0"},
+		},
+		{
+			name: "resend mode unknown",
+			env:  map[string]string{"OTP_RESEND_MODE": "reuse"},
+		},
+		{
+			name: "resend code secret too short",
+			env:  map[string]string{"OTP_RESEND_CODE_SECRET": "short"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -461,6 +938,7 @@ func clearOTPEnv(t *testing.T) {
 		"OTP_MAX_ATTEMPTS",
 		"OTP_TENANT_CACHE_TTL",
 		"OTP_PROVIDER_TIMEOUT",
+		"OTP_PROVIDER_FAILOVER_TIMEOUT",
 		"OTP_FAKE_SMS_MIN_DELAY",
 		"OTP_FAKE_SMS_MAX_DELAY",
 		"OTP_FAKE_SMS_DEBUG_CODE_REDIS",
@@ -468,6 +946,58 @@ func clearOTPEnv(t *testing.T) {
 		"OTP_SEND_RATE_LIMIT_ENABLED",
 		"OTP_SEND_RATE_LIMIT_MAX",
 		"OTP_SEND_RATE_LIMIT_WINDOW",
+		"OTP_CODE_HASH_KEY_ID",
+		"OTP_CODE_HASH_KEYS",
+		"OTP_SMS_FAKE_PROVIDERS",
+		"OTP_KAVENEGAR_BASE_URL",
+		"OTP_KAVENEGAR_TEMPLATE",
+		"OTP_TWILIO_BASE_URL",
+		"OTP_TWILIO_SENDER",
+		"OTP_GHASEDAK_BASE_URL",
+		"OTP_GHASEDAK_TEMPLATE",
+		"OTP_SMS_CIRCUIT_WINDOW_SIZE",
+		"OTP_SMS_CIRCUIT_MIN_CALLS",
+		"OTP_SMS_CIRCUIT_FAILURE_RATE",
+		"OTP_SMS_CIRCUIT_SLOW_CALL_DURATION",
+		"OTP_SMS_CIRCUIT_SLOW_CALL_RATE",
+		"OTP_SMS_CIRCUIT_OPEN_DURATION",
+		"OTP_SMS_CIRCUIT_HALF_OPEN_CALLS",
+		"OTP_SMS_CIRCUIT_FAILURE_RATES",
+		"OTP_SMS_CIRCUIT_SLOW_CALL_DURATIONS",
+		"OTP_SMS_RETRY_MAX_ATTEMPTS",
+		"OTP_SMS_RETRY_BASE_DELAY",
+		"OTP_SMS_RETRY_MAX_DELAY",
+		"OTP_SMS_RETRY_BUDGET_RATIO",
+		"OTP_SMS_RETRY_BUDGET_BURST",
+		"OTP_FAKE_SMS_ERROR_RATE",
+		"OTP_FAKE_SMS_HANG_RATE",
+		"OTP_FAKE_SMS_LATENCY_DISTRIBUTION",
+		"OTP_FAKE_SMS_PARETO_ALPHA",
+		"OTP_FAKE_SMS_LATENCY_CAP",
+		"OTP_FAKE_SMS_PHONE_OUTCOMES",
+		"OTP_TENANT_RATE_LIMIT_ENABLED",
+		"OTP_SEND_RATE_LIMIT_ALGORITHM",
+		"OTP_IP_RATE_LIMIT_ENABLED",
+		"OTP_IP_RATE_LIMIT_MAX",
+		"OTP_IP_RATE_LIMIT_WINDOW",
+		"OTP_IP_RATE_LIMIT_ALLOWLIST",
+		"OTP_LOCKOUT_ENABLED",
+		"OTP_LOCKOUT_MAX_FAILURES",
+		"OTP_LOCKOUT_FAILURE_WINDOW",
+		"OTP_LOCKOUT_DURATIONS",
+		"OTP_SEND_RATE_LIMIT_FALLBACK_ENABLED",
+		"OTP_SEND_RATE_LIMIT_FALLBACK_MAX_KEYS",
+		"OTP_SEND_RATE_LIMIT_FALLBACK_PROBE_INTERVAL",
+		"OTP_GLOBAL_PHONE_RATE_LIMIT_ENABLED",
+		"OTP_GLOBAL_PHONE_RATE_LIMIT_MAX",
+		"OTP_GLOBAL_PHONE_RATE_LIMIT_WINDOW",
+		"OTP_DEFAULT_PHONE_REGION",
+		"OTP_PHONE_KEY_SECRET",
+		"OTP_PHONE_KEY_LEGACY_READS",
+		"OTP_RESEND_COOLDOWN",
+		"OTP_MAX_RESENDS",
+		"OTP_RESEND_MODE",
+		"OTP_RESEND_CODE_SECRET",
 	} {
 		t.Setenv(key, "")
 	}
@@ -807,3 +1337,37 @@ func TestParseRatioRoutes(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadServerConfigAdminListener(t *testing.T) {
+	t.Setenv("SERVER_HOST", "0.0.0.0")
+	t.Setenv("SERVER_PORT", "3000")
+	t.Setenv("SERVER_READ_TIMEOUT", "15s")
+	t.Setenv("SERVER_WRITE_TIMEOUT", "15s")
+	t.Setenv("SERVER_GRACEFUL_SHUTDOWN_TIMEOUT", "10s")
+
+	cfg := &Config{}
+	if err := loadServerConfig(cfg); err != nil {
+		t.Fatalf("Failed to load server config: %v", err)
+	}
+	if cfg.Server.AdminEnabled {
+		t.Error("Expected the admin listener to be disabled by default")
+	}
+	if cfg.Server.AdminHost != "127.0.0.1" || cfg.Server.AdminPort != 8081 {
+		t.Errorf("Expected admin listener default 127.0.0.1:8081, got %s:%d", cfg.Server.AdminHost, cfg.Server.AdminPort)
+	}
+
+	t.Setenv("SERVER_ADMIN_ENABLED", "true")
+	t.Setenv("SERVER_ADMIN_HOST", "10.0.0.5")
+	t.Setenv("SERVER_ADMIN_PORT", "9000")
+	if err := loadServerConfig(cfg); err != nil {
+		t.Fatalf("Failed to load server config: %v", err)
+	}
+	if !cfg.Server.AdminEnabled || cfg.Server.AdminHost != "10.0.0.5" || cfg.Server.AdminPort != 9000 {
+		t.Errorf("Expected admin listener from env, got %+v", cfg.Server)
+	}
+
+	t.Setenv("SERVER_ADMIN_PORT", "3000")
+	if err := loadServerConfig(cfg); err == nil {
+		t.Error("Expected error for an admin port equal to the server port")
+	}
+}
@@ -0,0 +1,7 @@
+-- +migrate Up
+ALTER TABLE otp_requests
+  ADD COLUMN IF NOT EXISTS resend_count INTEGER NOT NULL DEFAULT 0;
+
+-- +migrate Down
+ALTER TABLE otp_requests
+  DROP COLUMN IF EXISTS resend_count;
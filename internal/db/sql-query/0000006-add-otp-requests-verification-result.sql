@@ -0,0 +1,30 @@
+-- +migrate Up
+ALTER TABLE otp_requests
+  ADD COLUMN IF NOT EXISTS attempt_count INTEGER NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS verified_at TIMESTAMPTZ;
+
+-- Send-to-verify conversion per tenant and day. Delivered requests are the ones that
+-- reached sent; requests still at sent were abandoned or have not been verified yet.
+CREATE OR REPLACE VIEW otp_request_conversion_daily AS
+SELECT
+  tenant_id,
+  date_trunc('day', created_at) AS day,
+  count(*) FILTER (WHERE status IN ('sent', 'verified', 'verify_failed')) AS delivered,
+  count(*) FILTER (WHERE status = 'verified') AS verified,
+  count(*) FILTER (WHERE status = 'verify_failed') AS verify_failed,
+  round(
+    count(*) FILTER (WHERE status = 'verified')::numeric
+      / NULLIF(count(*) FILTER (WHERE status IN ('sent', 'verified', 'verify_failed')), 0),
+    4
+  ) AS conversion_rate,
+  avg(extract(epoch FROM verified_at - created_at)) AS avg_seconds_to_verify,
+  percentile_cont(0.5) WITHIN GROUP (ORDER BY extract(epoch FROM verified_at - created_at)) AS median_seconds_to_verify
+FROM otp_requests
+GROUP BY tenant_id, date_trunc('day', created_at);
+
+-- +migrate Down
+DROP VIEW IF EXISTS otp_request_conversion_daily;
+
+ALTER TABLE otp_requests
+  DROP COLUMN IF EXISTS verified_at,
+  DROP COLUMN IF EXISTS attempt_count;
@@ -0,0 +1,42 @@
+-- +migrate Up
+-- Cancelled requests were delivered before they were revoked, so they count as
+-- delivered and lower the conversion rate like any other unverified request.
+DROP VIEW IF EXISTS otp_request_conversion_daily;
+
+CREATE VIEW otp_request_conversion_daily AS
+SELECT
+  tenant_id,
+  date_trunc('day', created_at) AS day,
+  count(*) FILTER (WHERE status IN ('sent', 'verified', 'verify_failed', 'cancelled')) AS delivered,
+  count(*) FILTER (WHERE status = 'verified') AS verified,
+  count(*) FILTER (WHERE status = 'verify_failed') AS verify_failed,
+  count(*) FILTER (WHERE status = 'cancelled') AS cancelled,
+  round(
+    count(*) FILTER (WHERE status = 'verified')::numeric
+      / NULLIF(count(*) FILTER (WHERE status IN ('sent', 'verified', 'verify_failed', 'cancelled')), 0),
+    4
+  ) AS conversion_rate,
+  avg(extract(epoch FROM verified_at - created_at)) AS avg_seconds_to_verify,
+  percentile_cont(0.5) WITHIN GROUP (ORDER BY extract(epoch FROM verified_at - created_at)) AS median_seconds_to_verify
+FROM otp_requests
+GROUP BY tenant_id, date_trunc('day', created_at);
+
+-- +migrate Down
+DROP VIEW IF EXISTS otp_request_conversion_daily;
+
+CREATE VIEW otp_request_conversion_daily AS
+SELECT
+  tenant_id,
+  date_trunc('day', created_at) AS day,
+  count(*) FILTER (WHERE status IN ('sent', 'verified', 'verify_failed')) AS delivered,
+  count(*) FILTER (WHERE status = 'verified') AS verified,
+  count(*) FILTER (WHERE status = 'verify_failed') AS verify_failed,
+  round(
+    count(*) FILTER (WHERE status = 'verified')::numeric
+      / NULLIF(count(*) FILTER (WHERE status IN ('sent', 'verified', 'verify_failed')), 0),
+    4
+  ) AS conversion_rate,
+  avg(extract(epoch FROM verified_at - created_at)) AS avg_seconds_to_verify,
+  percentile_cont(0.5) WITHIN GROUP (ORDER BY extract(epoch FROM verified_at - created_at)) AS median_seconds_to_verify
+FROM otp_requests
+GROUP BY tenant_id, date_trunc('day', created_at);
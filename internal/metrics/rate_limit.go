@@ -0,0 +1,25 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// OTPSendRateLimiterMode reports which OTP send rate limiter is active: 0 redis, 1 memory fallback
+	OTPSendRateLimiterMode = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "otp_send_rate_limiter_mode",
+			Help: "Active OTP send rate limiter (0=redis, 1=memory fallback)",
+		},
+	)
+
+	// OTPSendRateLimiterModeTransitions counts switches between the Redis and fallback limiters
+	OTPSendRateLimiterModeTransitions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "otp_send_rate_limiter_mode_transitions_total",
+			Help: "Total number of OTP send rate limiter switches between redis and memory fallback",
+		},
+		[]string{"from", "to"},
+	)
+)
@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// SMSProviderCircuitState reports each SMS provider circuit: 0 closed, 1 half-open, 2 open
+	SMSProviderCircuitState = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "otp_sms_provider_circuit_state",
+			Help: "SMS provider circuit breaker state (0=closed, 1=half_open, 2=open)",
+		},
+		[]string{"provider"},
+	)
+
+	// SMSProviderCircuitTransitions counts SMS provider circuit state changes
+	SMSProviderCircuitTransitions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "otp_sms_provider_circuit_transitions_total",
+			Help: "Total number of SMS provider circuit breaker state transitions",
+		},
+		[]string{"provider", "from", "to"},
+	)
+
+	// SMSProviderRetries counts SMS provider retry decisions by outcome
+	SMSProviderRetries = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "otp_sms_provider_retries_total",
+			Help: "Total number of SMS provider retry decisions (retried, budget_exhausted, deadline_exceeded)",
+		},
+		[]string{"provider", "outcome"},
+	)
+)
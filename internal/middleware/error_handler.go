@@ -32,6 +32,7 @@ func ErrorHandlerMiddleware() gin.HandlerFunc {
 			var statusCode int
 			var message string
 			var details string
+			var retryAfterSeconds int
 
 			// Check if it's an AppError
 			var ok bool
@@ -39,6 +40,7 @@ func ErrorHandlerMiddleware() gin.HandlerFunc {
 				statusCode = appErr.HTTPStatus()
 				message = appErr.Message
 				details = appErr.Details
+				retryAfterSeconds = appErr.RetryAfterSeconds
 			} else {
 				// Generic error - don't expose internal details
 				statusCode = http.StatusInternalServerError
@@ -57,11 +59,12 @@ func ErrorHandlerMiddleware() gin.HandlerFunc {
 
 			// Send standardized error response
 			c.JSON(statusCode, apperrors.ErrorResponse{
-				Error:     http.StatusText(statusCode),
-				Message:   message,
-				Code:      statusCode,
-				Details:   details,
-				RequestID: correlationID,
+				Error:             http.StatusText(statusCode),
+				Message:           message,
+				Code:              statusCode,
+				Details:           details,
+				RequestID:         correlationID,
+				RetryAfterSeconds: retryAfterSeconds,
 			})
 
 			// Abort the request
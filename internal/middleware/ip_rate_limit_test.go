@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-backend-service/internal/otp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeIPRateLimiter struct {
+	err    error
+	scopes []string
+	ips    []string
+}
+
+func (l *fakeIPRateLimiter) AllowIP(ctx context.Context, scope string, ip string) error {
+	l.scopes = append(l.scopes, scope)
+	l.ips = append(l.ips, ip)
+	return l.err
+}
+
+func newIPRateLimitTestRouter(t *testing.T, limit *IPRateLimit, trustedProxies []string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	require.NoError(t, router.SetTrustedProxies(trustedProxies))
+	router.Use(ErrorHandlerMiddleware())
+	router.POST("/v1/otp/send", limit.Middleware("send"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func performIPRequest(router *gin.Engine, remoteAddr string, forwardedFor string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/otp/send", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIPRateLimitUsesForwardedIPOnlyFromTrustedProxies(t *testing.T) {
+	limiter := &fakeIPRateLimiter{}
+	router := newIPRateLimitTestRouter(t, NewIPRateLimit(limiter, nil), []string{"172.16.0.0/12"})
+
+	performIPRequest(router, "172.18.0.5:40000", "198.51.100.7")
+	performIPRequest(router, "198.51.100.99:40000", "198.51.100.7")
+
+	assert.Equal(t, []string{"send", "send"}, limiter.scopes)
+	// A spoofed header from an untrusted peer is ignored.
+	assert.Equal(t, []string{"198.51.100.7", "198.51.100.99"}, limiter.ips)
+}
+
+func TestIPRateLimitSkipsAllowlistedIPs(t *testing.T) {
+	allowlist, err := ParseIPAllowlist([]string{"10.0.0.0/8", "192.0.2.1"})
+	require.NoError(t, err)
+	limiter := &fakeIPRateLimiter{err: otp.ErrIPRateLimited}
+	router := newIPRateLimitTestRouter(t, NewIPRateLimit(limiter, allowlist), nil)
+
+	assert.Equal(t, http.StatusOK, performIPRequest(router, "10.1.2.3:40000", "").Code)
+	assert.Equal(t, http.StatusOK, performIPRequest(router, "192.0.2.1:40000", "").Code)
+	assert.Empty(t, limiter.ips)
+}
+
+func TestIPRateLimitRejectsWithRateLimitHeaders(t *testing.T) {
+	limiter := &fakeIPRateLimiter{err: &otp.LimitError{Err: otp.ErrIPRateLimited, Limit: 30, ResetAfter: 1500 * time.Millisecond}}
+	router := newIPRateLimitTestRouter(t, NewIPRateLimit(limiter, nil), nil)
+
+	w := performIPRequest(router, "198.51.100.8:40000", "")
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.Equal(t, "30", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Contains(t, w.Body.String(), `"retry_after_seconds":2`)
+}
+
+func TestIPRateLimitFailsOpenOnLimiterError(t *testing.T) {
+	limiter := &fakeIPRateLimiter{err: errors.New("redis down")}
+	router := newIPRateLimitTestRouter(t, NewIPRateLimit(limiter, nil), nil)
+
+	assert.Equal(t, http.StatusOK, performIPRequest(router, "198.51.100.9:40000", "").Code)
+}
+
+func TestIPRateLimitNilAllowsEverything(t *testing.T) {
+	var limit *IPRateLimit
+	router := newIPRateLimitTestRouter(t, limit, nil)
+
+	assert.Equal(t, http.StatusOK, performIPRequest(router, "198.51.100.10:40000", "").Code)
+}
+
+func TestParseIPAllowlist(t *testing.T) {
+	allowlist, err := ParseIPAllowlist([]string{" 10.0.0.0/8 ", "2001:db8::/32", "192.0.2.1", ""})
+	require.NoError(t, err)
+
+	assert.True(t, allowlist.Contains("10.255.0.1"))
+	assert.True(t, allowlist.Contains("::ffff:10.0.0.1"))
+	assert.True(t, allowlist.Contains("2001:db8::1"))
+	assert.True(t, allowlist.Contains("192.0.2.1"))
+	assert.False(t, allowlist.Contains("192.0.2.2"))
+	assert.False(t, allowlist.Contains("not-an-ip"))
+
+	_, err = ParseIPAllowlist([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+	_, err = ParseIPAllowlist([]string{"example.com"})
+	assert.Error(t, err)
+}
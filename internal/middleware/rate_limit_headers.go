@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-backend-service/internal/otp"
+	apperrors "go-backend-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TooManyRequests responds 429 and, when err carries an *otp.LimitError, tells the client
+// when to retry with Retry-After and the IETF RateLimit-Limit/Remaining/Reset headers.
+func TooManyRequests(c *gin.Context, err error, message string) {
+	appErr := apperrors.NewAppError(http.StatusTooManyRequests, message)
+
+	var limitErr *otp.LimitError
+	if errors.As(err, &limitErr) {
+		retryAfter := retryAfterSeconds(limitErr.ResetAfter)
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.Header("RateLimit-Limit", strconv.Itoa(limitErr.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(limitErr.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(retryAfter))
+		appErr.RetryAfterSeconds = retryAfter
+	}
+
+	ErrorHandler(c, appErr)
+}
+
+// retryAfterSeconds rounds up to whole seconds, and never below one so that clients
+// do not retry immediately into the same limit.
+func retryAfterSeconds(d time.Duration) int {
+	seconds := int((d + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
@@ -0,0 +1,25 @@
+package otp
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+)
+
+// AdminTokenHashMetadataKey is the tenant_settings.metadata key holding the hex SHA-256
+// of the tenant's admin token, for example {"admin_token_sha256": "9f86d081..."}. Tenant
+// admins present the token itself; only its hash is stored and cached.
+const AdminTokenHashMetadataKey = "admin_token_sha256"
+
+// checkAdminToken reports whether token is the tenant's admin token. A tenant without a
+// stored hash rejects every token, so unlock stays closed until the tenant is set up.
+func checkAdminToken(tenant *TenantSettings, token string) bool {
+	stored, _ := tenant.Metadata[AdminTokenHashMetadataKey].(string)
+	if strings.TrimSpace(stored) == "" || token == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(token))
+	presented := hex.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(presented), []byte(strings.ToLower(strings.TrimSpace(stored)))) == 1
+}
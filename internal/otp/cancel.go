@@ -0,0 +1,92 @@
+package otp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CancelOTP revokes the active OTP issued under a request ID, for tenants whose user
+// abandoned the flow or whose support flagged fraud. The request log is marked cancelled
+// with the reason, the revocation is audited as a verification log, and until the OTP
+// would have expired verifications of it fail with cancelled instead of not_found.
+func (s *Service) CancelOTP(ctx context.Context, req CancelRequest) error {
+	if err := validateCancelRequest(req); err != nil {
+		return err
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		reason = ReasonCancelled
+	}
+
+	state, err := s.store.GetByRequestID(ctx, req.TenantID, req.RequestID)
+	if err != nil {
+		if errors.Is(err, ErrOTPNotFound) {
+			return err
+		}
+		return fmt.Errorf("get otp state by request id: %w", err)
+	}
+	now := time.Now().UTC()
+	if !now.Before(state.ExpiresAt) {
+		return ErrOTPNotFound
+	}
+
+	if err := s.store.Cancel(ctx, req.TenantID, state.Phone, state.Purpose, state.RequestID, state.ExpiresAt.Sub(now)); err != nil {
+		if errors.Is(err, ErrOTPNotFound) {
+			// The OTP was consumed or replaced since the lookup.
+			return err
+		}
+		return fmt.Errorf("cancel otp: %w", err)
+	}
+
+	s.logVerification(ctx, OTPVerificationLog{
+		RequestID:    state.RequestID,
+		TenantID:     req.TenantID,
+		Phone:        state.Phone,
+		Result:       VerificationResultCancelled,
+		Reason:       reason,
+		AttemptCount: state.AttemptCount,
+		CreatedAt:    now,
+	})
+	if s.requestLogger != nil {
+		// Best effort like the other request log results: the OTP is already revoked.
+		_ = s.requestLogger.UpdateVerificationResult(ctx, OTPVerificationResultLog{
+			RequestID:    state.RequestID,
+			Status:       RequestStatusCancelled,
+			ErrorMessage: reason,
+			AttemptCount: state.AttemptCount,
+			UpdatedAt:    now,
+		})
+	}
+	return nil
+}
+
+// verifyMissing answers a verification of a phone without an active OTP, telling a
+// cancelled OTP apart from one that never existed, expired or was consumed.
+func (s *Service) verifyMissing(ctx context.Context, req VerifyRequest) (*VerifyResponse, error) {
+	requestID, err := s.store.CancelledRequestID(ctx, req.TenantID, req.Phone, req.Purpose)
+	if err != nil {
+		if errors.Is(err, ErrOTPNotFound) {
+			s.logVerification(ctx, verificationLog(req, "", VerificationResultFailed, ReasonNotFound, 0))
+			return failedVerifyResponse("", ReasonNotFound), nil
+		}
+		return nil, fmt.Errorf("get cancelled otp: %w", err)
+	}
+	s.logVerification(ctx, verificationLog(req, requestID, VerificationResultFailed, ReasonCancelled, 0))
+	return failedVerifyResponse(requestID, ReasonCancelled), nil
+}
+
+func validateCancelRequest(req CancelRequest) error {
+	if req.TenantID <= 0 {
+		return fmt.Errorf("tenant_id must be greater than 0")
+	}
+	if strings.TrimSpace(req.RequestID) == "" {
+		return fmt.Errorf("request_id must not be empty")
+	}
+	if len(strings.TrimSpace(req.Reason)) > MaxCancelReasonLength {
+		return fmt.Errorf("reason must be at most %d bytes", MaxCancelReasonLength)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package otp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceCancelOTP(t *testing.T) {
+	state := activeOTPState("123456")
+	state.AttemptCount = 1
+	store := &fakeOTPStore{state: state}
+	requestLogger := &fakeRequestLogger{}
+	verifyLogger := &fakeVerificationLogger{}
+	service := NewService(nil, store, nil, requestLogger, verifyLogger, Config{})
+
+	err := service.CancelOTP(context.Background(), CancelRequest{
+		TenantID:  42,
+		RequestID: "request-verify",
+		Reason:    " fraud suspected ",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.cancelCalls)
+	assert.Nil(t, store.state)
+	assert.Equal(t, "request-verify", store.cancelledID)
+	assert.InDelta(t, time.Until(state.ExpiresAt), store.ttl, float64(time.Second))
+	require.Len(t, verifyLogger.logs, 1)
+	assert.Equal(t, "request-verify", verifyLogger.logs[0].RequestID)
+	assert.Equal(t, "+989121234567", verifyLogger.logs[0].Phone)
+	assert.Equal(t, VerificationResultCancelled, verifyLogger.logs[0].Result)
+	assert.Equal(t, "fraud suspected", verifyLogger.logs[0].Reason)
+	assert.Equal(t, 1, verifyLogger.logs[0].AttemptCount)
+	require.Len(t, requestLogger.resultLogs, 1)
+	assert.Equal(t, RequestStatusCancelled, requestLogger.resultLogs[0].Status)
+	assert.Equal(t, "fraud suspected", requestLogger.resultLogs[0].ErrorMessage)
+	assert.Equal(t, 1, requestLogger.resultLogs[0].AttemptCount)
+	assert.True(t, requestLogger.resultLogs[0].VerifiedAt.IsZero())
+}
+
+func TestServiceCancelOTPDefaultReason(t *testing.T) {
+	requestLogger := &fakeRequestLogger{}
+	verifyLogger := &fakeVerificationLogger{}
+	service := NewService(nil, &fakeOTPStore{state: activeOTPState("123456")}, nil, requestLogger, verifyLogger, Config{})
+
+	err := service.CancelOTP(context.Background(), CancelRequest{TenantID: 42, RequestID: "request-verify"})
+
+	require.NoError(t, err)
+	require.Len(t, verifyLogger.logs, 1)
+	assert.Equal(t, ReasonCancelled, verifyLogger.logs[0].Reason)
+	require.Len(t, requestLogger.resultLogs, 1)
+	assert.Equal(t, ReasonCancelled, requestLogger.resultLogs[0].ErrorMessage)
+}
+
+func TestServiceCancelOTPNotFound(t *testing.T) {
+	expired := activeOTPState("123456")
+	expired.ExpiresAt = time.Now().UTC().Add(-time.Second)
+	tests := []struct {
+		name      string
+		store     *fakeOTPStore
+		requestID string
+	}{
+		{name: "missing", store: &fakeOTPStore{}, requestID: "request-verify"},
+		{name: "stale request id", store: &fakeOTPStore{state: activeOTPState("123456")}, requestID: "request-old"},
+		{name: "expired", store: &fakeOTPStore{state: expired}, requestID: "request-verify"},
+		{name: "consumed concurrently", store: &fakeOTPStore{state: activeOTPState("123456"), cancelErr: ErrOTPNotFound}, requestID: "request-verify"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requestLogger := &fakeRequestLogger{}
+			verifyLogger := &fakeVerificationLogger{}
+			service := NewService(nil, tt.store, nil, requestLogger, verifyLogger, Config{})
+
+			err := service.CancelOTP(context.Background(), CancelRequest{TenantID: 42, RequestID: tt.requestID})
+
+			assert.ErrorIs(t, err, ErrOTPNotFound)
+			assert.Empty(t, tt.store.cancelledID)
+			assert.Empty(t, verifyLogger.logs)
+			assert.Empty(t, requestLogger.resultLogs)
+		})
+	}
+}
+
+func TestServiceCancelOTPStoreError(t *testing.T) {
+	store := &fakeOTPStore{state: activeOTPState("123456"), cancelErr: errors.New("redis down")}
+	service := NewService(nil, store, nil, nil, nil, Config{})
+
+	err := service.CancelOTP(context.Background(), CancelRequest{TenantID: 42, RequestID: "request-verify"})
+
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrOTPNotFound)
+}
+
+func TestServiceCancelOTPInvalidRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		req  CancelRequest
+	}{
+		{name: "missing tenant", req: CancelRequest{RequestID: "request-verify"}},
+		{name: "missing request id", req: CancelRequest{TenantID: 42, RequestID: " "}},
+		{name: "reason too long", req: CancelRequest{TenantID: 42, RequestID: "request-verify", Reason: strings.Repeat("x", MaxCancelReasonLength+1)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeOTPStore{state: activeOTPState("123456")}
+			service := NewService(nil, store, nil, nil, nil, Config{})
+
+			err := service.CancelOTP(context.Background(), tt.req)
+
+			require.Error(t, err)
+			assert.Equal(t, 0, store.getCalls)
+		})
+	}
+}
+
+func TestServiceVerifyOTPAfterCancel(t *testing.T) {
+	store := &fakeOTPStore{state: activeOTPState("123456")}
+	verifyLogger := &fakeVerificationLogger{}
+	service := NewService(nil, store, nil, nil, verifyLogger, Config{})
+	require.NoError(t, service.CancelOTP(context.Background(), CancelRequest{TenantID: 42, RequestID: "request-verify"}))
+	store.getErr = ErrOTPNotFound
+
+	resp, err := service.VerifyOTP(context.Background(), VerifyRequest{TenantID: 42, Phone: "+989121234567", Code: "123456"})
+
+	require.NoError(t, err)
+	assert.False(t, resp.Verified)
+	assert.Equal(t, ReasonCancelled, resp.Reason)
+	assert.Equal(t, "request-verify", resp.RequestID)
+	require.Len(t, verifyLogger.logs, 2)
+	assert.Equal(t, VerificationResultFailed, verifyLogger.logs[1].Result)
+	assert.Equal(t, ReasonCancelled, verifyLogger.logs[1].Reason)
+	assert.Equal(t, "request-verify", verifyLogger.logs[1].RequestID)
+}
+
+func TestServiceVerifyOTPByRequestIDAfterCancel(t *testing.T) {
+	store := &fakeOTPStore{state: activeOTPState("123456")}
+	verifyLogger := &fakeVerificationLogger{}
+	service := NewService(nil, store, nil, nil, verifyLogger, Config{})
+	require.NoError(t, service.CancelOTP(context.Background(), CancelRequest{TenantID: 42, RequestID: "request-verify"}))
+
+	resp, err := service.VerifyOTPByRequestID(context.Background(), VerifyByRequestIDRequest{
+		TenantID:  42,
+		RequestID: "request-verify",
+		Code:      "123456",
+	})
+
+	require.NoError(t, err)
+	assert.False(t, resp.Verified)
+	assert.Equal(t, ReasonCancelled, resp.Reason)
+	assert.Equal(t, "request-verify", resp.RequestID)
+	require.Len(t, verifyLogger.logs, 2)
+	assert.Equal(t, ReasonCancelled, verifyLogger.logs[1].Reason)
+	assert.Empty(t, verifyLogger.logs[1].Phone)
+}
+
+func TestServiceVerifyOTPByRequestIDOtherCancelledRequest(t *testing.T) {
+	store := &fakeOTPStore{cancelledID: "request-other"}
+	service := NewService(nil, store, nil, nil, nil, Config{})
+
+	resp, err := service.VerifyOTPByRequestID(context.Background(), VerifyByRequestIDRequest{
+		TenantID:  42,
+		RequestID: "request-verify",
+		Code:      "123456",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, ReasonNotFound, resp.Reason)
+	assert.Empty(t, resp.RequestID)
+}
@@ -0,0 +1,70 @@
+package otp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+const minCodeSealKeyLength = 16
+
+// CodeSealer encrypts OTP codes with AES-256-GCM so that tenants resending the same code
+// can re-deliver it. Codes are only sealed for such tenants; everything else keeps
+// storing the hash alone.
+type CodeSealer struct {
+	aead cipher.AEAD
+}
+
+// NewCodeSealer creates a sealer keyed by the SHA-256 of a server-side secret.
+func NewCodeSealer(secret []byte) (*CodeSealer, error) {
+	if len(secret) < minCodeSealKeyLength {
+		return nil, fmt.Errorf("otp code sealer: secret must be at least %d bytes", minCodeSealKeyLength)
+	}
+	key := sha256.Sum256(secret)
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("otp code sealer: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("otp code sealer: %w", err)
+	}
+	return &CodeSealer{aead: aead}, nil
+}
+
+// Seal encrypts code bound to requestID. A nil sealer returns an empty string, which
+// makes resends rotate the code.
+func (s *CodeSealer) Seal(code string, requestID string) (string, error) {
+	if s == nil {
+		return "", nil
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("otp code sealer: %w", err)
+	}
+	sealed := s.aead.Seal(nonce, nonce, []byte(code), []byte(requestID))
+	return base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a code sealed for requestID.
+func (s *CodeSealer) Open(sealed string, requestID string) (string, error) {
+	if s == nil {
+		return "", fmt.Errorf("otp code sealer: not configured")
+	}
+	data, err := base64.RawStdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", fmt.Errorf("otp code sealer: %w", err)
+	}
+	if len(data) < s.aead.NonceSize() {
+		return "", fmt.Errorf("otp code sealer: sealed code too short")
+	}
+	nonce, ciphertext := data[:s.aead.NonceSize()], data[s.aead.NonceSize():]
+	code, err := s.aead.Open(nil, nonce, ciphertext, []byte(requestID))
+	if err != nil {
+		return "", fmt.Errorf("otp code sealer: %w", err)
+	}
+	return string(code), nil
+}
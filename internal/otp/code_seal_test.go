@@ -0,0 +1,41 @@
+package otp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeSealerRoundTrip(t *testing.T) {
+	sealer, err := NewCodeSealer([]byte("0123456789abcdef"))
+	require.NoError(t, err)
+
+	sealed, err := sealer.Seal("123456", "request-1")
+	require.NoError(t, err)
+	assert.NotContains(t, sealed, "123456")
+
+	code, err := sealer.Open(sealed, "request-1")
+	require.NoError(t, err)
+	assert.Equal(t, "123456", code)
+
+	// The code is bound to its request.
+	_, err = sealer.Open(sealed, "request-2")
+	assert.Error(t, err)
+}
+
+func TestCodeSealerRejectsShortSecret(t *testing.T) {
+	_, err := NewCodeSealer([]byte("short"))
+	assert.Error(t, err)
+}
+
+func TestNilCodeSealer(t *testing.T) {
+	var sealer *CodeSealer
+
+	sealed, err := sealer.Seal("123456", "request-1")
+	require.NoError(t, err)
+	assert.Empty(t, sealed)
+
+	_, err = sealer.Open("anything", "request-1")
+	assert.Error(t, err)
+}
@@ -9,6 +9,13 @@ type Config struct {
 	MaxAttempts     int
 	TenantCacheTTL  time.Duration
 	ProviderTimeout time.Duration
+	// FailoverTimeout bounds the whole delivery across the tenant's provider failover chain.
+	FailoverTimeout time.Duration
+	// Resends of an active OTP: minimum gap between deliveries, cap per OTP and whether
+	// a resend rotates the code or re-delivers it.
+	ResendCooldown time.Duration
+	MaxResends     int
+	ResendMode     string
 }
 
 // DefaultConfig returns conservative defaults for the first real OTP flow.
@@ -19,5 +26,9 @@ func DefaultConfig() Config {
 		MaxAttempts:     3,
 		TenantCacheTTL:  5 * time.Minute,
 		ProviderTimeout: 2 * time.Second,
+		FailoverTimeout: 5 * time.Second,
+		ResendCooldown:  30 * time.Second,
+		MaxResends:      3,
+		ResendMode:      ResendModeRotate,
 	}
 }
@@ -0,0 +1,115 @@
+package otp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// smsDelivery is the outcome of sending one OTP across the tenant's provider chain.
+type smsDelivery struct {
+	result   *SMSResult
+	provider string
+	attempts []map[string]interface{}
+}
+
+// deliver sends req through the tenant's primary provider and, on failure, through each
+// configured failover provider in order. Every attempt is bounded by ProviderTimeout and
+// the whole chain by FailoverTimeout. provider is the one that delivered, or the last one tried.
+func (s *Service) deliver(ctx context.Context, tenant *TenantSettings, req SMSRequest) (smsDelivery, error) {
+	chain := providerChain(tenant)
+	delivery := smsDelivery{provider: chain[0]}
+
+	if len(chain) > 1 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.FailoverTimeout)
+		defer cancel()
+	}
+
+	var lastErr error
+	for i, provider := range chain {
+		if i > 0 && ctx.Err() != nil {
+			break
+		}
+
+		attemptReq := providerRequest(req, tenant, provider, i == 0)
+		started := time.Now()
+		result, err := s.sendWithTimeout(ctx, attemptReq)
+		attempt := map[string]interface{}{
+			"provider":   provider,
+			"latency_ms": time.Since(started).Milliseconds(),
+		}
+		delivery.provider = provider
+
+		if err == nil {
+			attempt["status"] = RequestStatusSent
+			delivery.attempts = append(delivery.attempts, attempt)
+			delivery.result = result
+			return delivery, nil
+		}
+
+		attempt["status"] = RequestStatusFailed
+		attempt["error"] = err.Error()
+		delivery.attempts = append(delivery.attempts, attempt)
+		lastErr = err
+		if !shouldFailover(err) {
+			break
+		}
+	}
+
+	return delivery, lastErr
+}
+
+func (s *Service) sendWithTimeout(ctx context.Context, req SMSRequest) (*SMSResult, error) {
+	providerCtx, cancel := context.WithTimeout(ctx, s.config.ProviderTimeout)
+	defer cancel()
+	return s.smsProvider.SendOTP(providerCtx, req)
+}
+
+// shouldFailover reports whether another provider could succeed where this one failed.
+// Recipient errors describe the phone number, not the provider, so they are final.
+func shouldFailover(err error) bool {
+	return !errors.Is(err, ErrSMSInvalidRecipient) &&
+		!errors.Is(err, ErrSMSRecipientLandline) &&
+		!errors.Is(err, context.Canceled)
+}
+
+// providerChain returns the tenant's primary provider followed by its distinct failover
+// providers. Failover providers without credentials of their own in sms_providers are
+// skipped, so no provider is ever handed another provider's secrets.
+func providerChain(tenant *TenantSettings) []string {
+	chain := []string{tenant.SMSProvider}
+	seen := map[string]bool{strings.ToLower(tenant.SMSProvider): true}
+	for _, provider := range tenant.SMSFailover {
+		key := strings.ToLower(strings.TrimSpace(provider))
+		if key == "" || seen[key] || !tenant.SMSProviders[key].HasCredentials {
+			continue
+		}
+		seen[key] = true
+		chain = append(chain, provider)
+	}
+	return chain
+}
+
+// providerRequest fills in the template and sender of req for provider. The primary provider
+// keeps the tenant-wide sms_template, sms_template_params and sms_sender unless its
+// sms_providers entry overrides them; failover providers only use their own entry.
+func providerRequest(req SMSRequest, tenant *TenantSettings, provider string, primary bool) SMSRequest {
+	req.Provider = provider
+	if !primary {
+		req.Template, req.TemplateParams, req.Sender = "", nil, ""
+	}
+
+	settings := tenant.SMSProviders[strings.ToLower(provider)]
+	if settings.Template != "" {
+		req.Template = settings.Template
+	}
+	if len(settings.TemplateParams) > 0 {
+		req.TemplateParams = settings.TemplateParams
+	}
+	if settings.Sender != "" {
+		req.Sender = settings.Sender
+	}
+	return req
+}
@@ -1,12 +1,24 @@
 package otp
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrTenantNotFound      = errors.New("tenant not found")
 	ErrTenantDisabled      = errors.New("tenant disabled")
+	ErrInvalidPhone        = errors.New("invalid phone number")
+	ErrInvalidPurpose      = errors.New("invalid otp purpose")
+	ErrPurposeNotAllowed   = errors.New("otp purpose not allowed for tenant")
 	ErrOTPAlreadyActive    = errors.New("otp already active")
+	ErrOTPResendCooldown   = errors.New("otp resend cooldown active")
+	ErrOTPResendLimit      = errors.New("otp resend limit reached")
 	ErrOTPRateLimited      = errors.New("otp rate limited")
+	ErrTenantRateLimited   = errors.New("tenant otp send quota exceeded")
+	ErrIPRateLimited       = errors.New("client ip rate limited")
+	ErrPhoneLocked         = errors.New("phone locked after repeated failed verifications")
+	ErrAdminUnauthorized   = errors.New("invalid tenant admin token")
 	ErrOTPNotFound         = errors.New("otp not found")
 	ErrOTPExpired          = errors.New("otp expired")
 	ErrInvalidCode         = errors.New("invalid otp code")
@@ -14,3 +26,49 @@ var (
 	ErrSMSProviderFailed   = errors.New("sms provider failed")
 	ErrNotImplemented      = errors.New("otp flow not implemented")
 )
+
+// SMS failure kinds reported by provider adapters through SMSProviderError.
+var (
+	ErrSMSInvalidCredentials    = errors.New("sms provider credentials invalid")
+	ErrSMSInsufficientCredit    = errors.New("sms provider account out of credit")
+	ErrSMSInvalidRecipient      = errors.New("sms recipient invalid")
+	ErrSMSRecipientLandline     = errors.New("sms recipient is not a mobile number")
+	ErrSMSRecipientUnreachable  = errors.New("sms recipient unreachable")
+	ErrSMSRejected              = errors.New("sms provider rejected request")
+	ErrSMSProviderUnavailable   = errors.New("sms provider unavailable")
+	ErrSMSProviderNotConfigured = errors.New("sms provider not configured")
+	ErrSMSCircuitOpen           = errors.New("sms provider circuit open")
+)
+
+// SMSProviderError describes a failed provider call. It matches its Kind and the
+// underlying Err with errors.Is, so callers can classify failures without parsing messages.
+type SMSProviderError struct {
+	Provider   string
+	Kind       error
+	Code       string
+	StatusCode int
+	Retryable  bool
+	Message    string
+	Err        error
+}
+
+func (e *SMSProviderError) Error() string {
+	msg := fmt.Sprintf("%s: %v", e.Provider, e.Kind)
+	if e.Code != "" {
+		msg += fmt.Sprintf(" (code %s)", e.Code)
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *SMSProviderError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
@@ -0,0 +1,31 @@
+package otp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSMSProviderErrorMatchesKindAndCause(t *testing.T) {
+	err := error(&SMSProviderError{
+		Provider:   "kavenegar",
+		Kind:       ErrSMSProviderUnavailable,
+		Code:       "504",
+		StatusCode: 504,
+		Retryable:  true,
+		Message:    "gateway timeout",
+		Err:        context.DeadlineExceeded,
+	})
+
+	assert.ErrorIs(t, err, ErrSMSProviderUnavailable)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NotErrorIs(t, err, ErrSMSInvalidRecipient)
+	assert.Equal(t, "kavenegar: sms provider unavailable (code 504): gateway timeout: context deadline exceeded", err.Error())
+
+	wrapped := errors.Join(ErrSMSProviderFailed, err)
+	var providerErr *SMSProviderError
+	assert.ErrorAs(t, wrapped, &providerErr)
+	assert.True(t, providerErr.Retryable)
+}
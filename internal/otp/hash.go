@@ -1,12 +1,18 @@
 package otp
 
 import (
+	"crypto/hmac"
 	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/hex"
+	"fmt"
+	"strings"
 )
 
+const minCodeHashKeyLength = 16
+
 // HashCode returns a stable SHA-256 hash for an OTP code.
+// It is the legacy unkeyed format; states written with it carry an empty key ID.
 func HashCode(code string) string {
 	sum := sha256.Sum256([]byte(code))
 	return hex.EncodeToString(sum[:])
@@ -18,7 +24,69 @@ func VerifyCode(code string, storedHash string) bool {
 	return subtle.ConstantTimeCompare([]byte(codeHash), []byte(storedHash)) == 1
 }
 
-// TODO: Implement  HMAC
-/*
-HashCode از SHA-256 و VerifyCode از constant-time compare استفاده کرده؛ برای Phase 1 قابل قبول است، ولی بعداً بهتر است salt/pepper یا HMAC اضافه کنیم.
-*/
+// CodeHasher hashes OTP codes with HMAC-SHA256 using a server-side pepper.
+// Several keys can be loaded at once so codes hashed with a previous key keep
+// verifying while a new key is rolled out.
+type CodeHasher struct {
+	currentKeyID string
+	keys         map[string][]byte
+}
+
+// NewCodeHasher creates a keyed hasher that signs new codes with currentKeyID.
+func NewCodeHasher(currentKeyID string, keys map[string][]byte) (*CodeHasher, error) {
+	if strings.TrimSpace(currentKeyID) == "" {
+		return nil, fmt.Errorf("otp code hasher: current key id must not be empty")
+	}
+	if _, ok := keys[currentKeyID]; !ok {
+		return nil, fmt.Errorf("otp code hasher: current key id %q has no key", currentKeyID)
+	}
+
+	copied := make(map[string][]byte, len(keys))
+	for keyID, key := range keys {
+		if strings.TrimSpace(keyID) == "" {
+			return nil, fmt.Errorf("otp code hasher: key id must not be empty")
+		}
+		if len(key) < minCodeHashKeyLength {
+			return nil, fmt.Errorf("otp code hasher: key %q must be at least %d bytes", keyID, minCodeHashKeyLength)
+		}
+		copied[keyID] = append([]byte(nil), key...)
+	}
+
+	return &CodeHasher{
+		currentKeyID: currentKeyID,
+		keys:         copied,
+	}, nil
+}
+
+// Hash returns the HMAC of code under the current key together with that key's ID.
+// A nil hasher falls back to the legacy SHA-256 format with an empty key ID.
+func (h *CodeHasher) Hash(code string) (string, string) {
+	if h == nil {
+		return HashCode(code), ""
+	}
+	return hmacCode(h.keys[h.currentKeyID], code), h.currentKeyID
+}
+
+// Verify compares code with storedHash using the key identified by keyID.
+// An empty keyID verifies against the legacy SHA-256 format; unknown key IDs never verify.
+func (h *CodeHasher) Verify(code string, storedHash string, keyID string) bool {
+	if keyID == "" {
+		return VerifyCode(code, storedHash)
+	}
+	if h == nil {
+		return false
+	}
+
+	key, ok := h.keys[keyID]
+	if !ok {
+		return false
+	}
+	codeHash := hmacCode(key, code)
+	return subtle.ConstantTimeCompare([]byte(codeHash), []byte(storedHash)) == 1
+}
+
+func hmacCode(key []byte, code string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
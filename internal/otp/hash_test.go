@@ -74,3 +74,114 @@ func TestVerifyCode(t *testing.T) {
 		})
 	}
 }
+
+func TestNewCodeHasherValidation(t *testing.T) {
+	validKey := []byte("0123456789abcdef")
+	tests := []struct {
+		name         string
+		currentKeyID string
+		keys         map[string][]byte
+	}{
+		{name: "empty current key id", currentKeyID: "", keys: map[string][]byte{"k1": validKey}},
+		{name: "current key id missing", currentKeyID: "k2", keys: map[string][]byte{"k1": validKey}},
+		{name: "short key", currentKeyID: "k1", keys: map[string][]byte{"k1": []byte("short")}},
+		{name: "short previous key", currentKeyID: "k1", keys: map[string][]byte{"k1": validKey, "k0": []byte("short")}},
+		{name: "empty key id", currentKeyID: "k1", keys: map[string][]byte{"k1": validKey, "": validKey}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewCodeHasher(tt.currentKeyID, tt.keys); err == nil {
+				t.Fatal("NewCodeHasher should return an error")
+			}
+		})
+	}
+}
+
+func TestCodeHasherHash(t *testing.T) {
+	hasher := newTestCodeHasher(t, "k1", map[string]string{"k1": "pepper-one-0123456789"})
+	other := newTestCodeHasher(t, "k1", map[string]string{"k1": "pepper-two-0123456789"})
+
+	first, keyID := hasher.Hash("123456")
+	second, _ := hasher.Hash("123456")
+	otherPepper, _ := other.Hash("123456")
+
+	if keyID != "k1" {
+		t.Fatalf("Hash key id = %q, want %q", keyID, "k1")
+	}
+	if first != second {
+		t.Fatalf("Hash should be deterministic for the same key: first=%q second=%q", first, second)
+	}
+	if first == HashCode("123456") {
+		t.Fatal("Hash should differ from the unkeyed SHA-256 hash")
+	}
+	if first == otherPepper {
+		t.Fatal("Hash should differ between peppers")
+	}
+}
+
+func TestCodeHasherVerify(t *testing.T) {
+	oldHasher := newTestCodeHasher(t, "k1", map[string]string{"k1": "pepper-one-0123456789"})
+	oldHash, oldKeyID := oldHasher.Hash("123456")
+
+	rotated := newTestCodeHasher(t, "k2", map[string]string{
+		"k1": "pepper-one-0123456789",
+		"k2": "pepper-two-0123456789",
+	})
+	newHash, newKeyID := rotated.Hash("123456")
+
+	tests := []struct {
+		name       string
+		hasher     *CodeHasher
+		code       string
+		storedHash string
+		keyID      string
+		want       bool
+	}{
+		{name: "current key", hasher: rotated, code: "123456", storedHash: newHash, keyID: newKeyID, want: true},
+		{name: "previous key during rotation", hasher: rotated, code: "123456", storedHash: oldHash, keyID: oldKeyID, want: true},
+		{name: "wrong code", hasher: rotated, code: "654321", storedHash: newHash, keyID: newKeyID, want: false},
+		{name: "hash verified with other key id", hasher: rotated, code: "123456", storedHash: oldHash, keyID: newKeyID, want: false},
+		{name: "retired key id", hasher: oldHasher, code: "123456", storedHash: newHash, keyID: newKeyID, want: false},
+		{name: "legacy sha256 state", hasher: rotated, code: "123456", storedHash: HashCode("123456"), keyID: "", want: true},
+		{name: "legacy sha256 wrong code", hasher: rotated, code: "654321", storedHash: HashCode("123456"), keyID: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hasher.Verify(tt.code, tt.storedHash, tt.keyID); got != tt.want {
+				t.Fatalf("Verify(%q, %q, %q) = %v, want %v", tt.code, tt.storedHash, tt.keyID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNilCodeHasherUsesLegacyHash(t *testing.T) {
+	var hasher *CodeHasher
+
+	codeHash, keyID := hasher.Hash("123456")
+
+	if codeHash != HashCode("123456") || keyID != "" {
+		t.Fatalf("nil Hash = (%q, %q), want legacy hash with empty key id", codeHash, keyID)
+	}
+	if !hasher.Verify("123456", codeHash, "") {
+		t.Fatal("nil hasher should verify legacy hashes")
+	}
+	if hasher.Verify("123456", codeHash, "k1") {
+		t.Fatal("nil hasher should not verify keyed hashes")
+	}
+}
+
+func newTestCodeHasher(t *testing.T, currentKeyID string, secrets map[string]string) *CodeHasher {
+	t.Helper()
+
+	keys := make(map[string][]byte, len(secrets))
+	for keyID, secret := range secrets {
+		keys[keyID] = []byte(secret)
+	}
+	hasher, err := NewCodeHasher(currentKeyID, keys)
+	if err != nil {
+		t.Fatalf("NewCodeHasher returned error: %v", err)
+	}
+	return hasher
+}
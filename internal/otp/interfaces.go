@@ -10,12 +10,45 @@ type TenantSettingsProvider interface {
 	GetTenantSettings(ctx context.Context, tenantID int64) (*TenantSettings, error)
 }
 
-// OTPStore persists short-lived OTP verification state.
+// OTPStore persists short-lived OTP verification state. A tenant's phone has one active
+// OTP per purpose, and each purpose's OTP is independent of the others.
 type OTPStore interface {
 	Save(ctx context.Context, state OTPState, ttl time.Duration) error
-	Get(ctx context.Context, tenantID int64, phone string) (*OTPState, error)
-	IncrementAttempts(ctx context.Context, tenantID int64, phone string) (int, error)
-	Delete(ctx context.Context, tenantID int64, phone string) error
+	// Reserve stores state only when no active OTP exists for the tenant, phone and
+	// purpose, returning ErrOTPAlreadyActive otherwise. It must be atomic across instances.
+	Reserve(ctx context.Context, state OTPState, ttl time.Duration) error
+	Get(ctx context.Context, tenantID int64, phone string, purpose string) (*OTPState, error)
+	// GetByRequestID looks the active OTP up through its request ID, filling in the
+	// phone. It returns ErrOTPNotFound unless the tenant's OTP for that phone and purpose
+	// still has requestID, so a stale request ID never resolves to a newer OTP.
+	GetByRequestID(ctx context.Context, tenantID int64, requestID string) (*OTPState, error)
+	// IncrementAttempts counts a failed attempt against the active OTP while it still
+	// has requestID, returning ErrOTPNotFound otherwise.
+	IncrementAttempts(ctx context.Context, tenantID int64, phone string, purpose string, requestID string) (int, error)
+	// Resend stores the code, resend count, last sent time and expiry of state in the
+	// active OTP, keeping its attempt count. It applies only while the stored OTP has
+	// state.RequestID and state.ResendCount-1 resends, returning ErrOTPNotFound for a
+	// missing or different OTP and ErrOTPResendCooldown when a concurrent resend won.
+	Resend(ctx context.Context, state OTPState, ttl time.Duration) error
+	// RevertResend puts the code, resend count, last sent time and expiry of previous
+	// back into the active OTP, keeping its attempt count, once the resend that replaced
+	// them could not be delivered. It applies only while the stored OTP has
+	// previous.RequestID and previous.ResendCount+1 resends, returning ErrOTPNotFound
+	// or ErrOTPResendCooldown otherwise.
+	RevertResend(ctx context.Context, previous OTPState) error
+	Delete(ctx context.Context, tenantID int64, phone string, purpose string) error
+	// DeleteRequest deletes the active OTP only while it still has requestID, returning
+	// ErrOTPNotFound otherwise, so each OTP is consumed at most once.
+	DeleteRequest(ctx context.Context, tenantID int64, phone string, purpose string, requestID string) error
+	// Cancel deletes the active OTP like DeleteRequest and, in the same step, remembers
+	// the cancellation for ttl so that CancelledRequestID and IsCancelled report it. A
+	// new OTP for the phone clears what CancelledRequestID reports.
+	Cancel(ctx context.Context, tenantID int64, phone string, purpose string, requestID string, ttl time.Duration) error
+	// CancelledRequestID returns the request ID of the phone's cancelled OTP for purpose,
+	// or ErrOTPNotFound when none was cancelled since its last send.
+	CancelledRequestID(ctx context.Context, tenantID int64, phone string, purpose string) (string, error)
+	// IsCancelled reports whether the tenant's OTP issued under requestID was cancelled.
+	IsCancelled(ctx context.Context, tenantID int64, requestID string) (bool, error)
 }
 
 // SMSProvider sends OTP codes through an external or simulated provider.
@@ -23,15 +56,46 @@ type SMSProvider interface {
 	SendOTP(ctx context.Context, req SMSRequest) (*SMSResult, error)
 }
 
-// SendRateLimiter checks whether an OTP send request is allowed.
+// SendRateLimiter checks whether an OTP send request is allowed, counting each purpose
+// of a tenant's phone separately. Rejections should be a *LimitError wrapping
+// ErrOTPRateLimited so clients learn when to retry.
 type SendRateLimiter interface {
-	AllowSend(ctx context.Context, tenantID int64, phone string) error
+	AllowSend(ctx context.Context, tenantID int64, phone string, purpose string) error
 }
 
-// OTPRequestLogger persists send request and provider result logs.
+// GlobalPhoneRateLimiter checks an OTP send against a per-phone limit shared by all
+// tenants. Rejections should be a *LimitError wrapping ErrOTPRateLimited.
+type GlobalPhoneRateLimiter interface {
+	AllowPhoneSend(ctx context.Context, phone string) error
+}
+
+// TenantSendRateLimiter checks a send against the tenant-wide per-minute quota.
+// Rejections should be a *LimitError wrapping ErrTenantRateLimited.
+type TenantSendRateLimiter interface {
+	AllowTenantSend(ctx context.Context, tenantID int64, limitPerMin int) error
+}
+
+// PhoneLockout counts failed verifications per tenant and phone across OTP lifetimes
+// and locks the phone out for escalating durations once too many fail.
+type PhoneLockout interface {
+	// Check returns a *LimitError wrapping ErrPhoneLocked while the phone is locked.
+	Check(ctx context.Context, tenantID int64, phone string) error
+	// RecordFailure counts a failed verification. It returns a *LimitError wrapping
+	// ErrPhoneLocked when this failure locked the phone.
+	RecordFailure(ctx context.Context, tenantID int64, phone string) error
+	// Reset forgets failures after a successful verification; an active lock stays.
+	Reset(ctx context.Context, tenantID int64, phone string) error
+	// Unlock lifts the lock and forgets the failures and escalation level.
+	Unlock(ctx context.Context, tenantID int64, phone string) error
+}
+
+// OTPRequestLogger persists send request, provider result and verification result logs.
 type OTPRequestLogger interface {
 	CreateRequest(ctx context.Context, log OTPRequestLog) error
 	UpdateProviderResult(ctx context.Context, log OTPProviderResultLog) error
+	// UpdateVerificationResult records the terminal verification outcome of a request,
+	// including its cancellation.
+	UpdateVerificationResult(ctx context.Context, log OTPVerificationResultLog) error
 }
 
 // OTPVerificationLogger persists verification attempt logs.
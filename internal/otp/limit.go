@@ -0,0 +1,23 @@
+package otp
+
+import (
+	"fmt"
+	"time"
+)
+
+// LimitError reports a request rejected by a limit, together with the limit state, so
+// callers can tell clients when to retry. It matches Err with errors.Is.
+type LimitError struct {
+	Err        error
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v (limit %d, remaining %d, resets in %s)", e.Err, e.Limit, e.Remaining, e.ResetAfter)
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,23 @@
+package otp
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitErrorMatchesWrappedError(t *testing.T) {
+	err := fmt.Errorf("send: %w", &LimitError{Err: ErrOTPRateLimited, Limit: 5, ResetAfter: 30 * time.Second})
+
+	assert.ErrorIs(t, err, ErrOTPRateLimited)
+	assert.False(t, errors.Is(err, ErrTenantRateLimited))
+	var limitErr *LimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, 5, limitErr.Limit)
+	assert.Contains(t, err.Error(), "otp rate limited")
+	assert.Contains(t, err.Error(), "30s")
+}
@@ -8,12 +8,39 @@ const (
 	RequestStatusSent     = "sent"
 	RequestStatusFailed   = "failed"
 	RequestStatusVerified = "verified"
+	RequestStatusRejected = "rejected"
+	// RequestStatusVerifyFailed marks a delivered request whose OTP can no longer be
+	// verified; the verification reason is the error message.
+	RequestStatusVerifyFailed = "verify_failed"
+	// RequestStatusCancelled marks a request whose OTP the tenant revoked; the cancel
+	// reason is the error message.
+	RequestStatusCancelled = "cancelled"
 )
 
+// Send rejection reasons recorded as the error message of rejected request logs.
+const (
+	SendReasonPhoneRateLimited       = "phone_rate_limited"
+	SendReasonGlobalPhoneRateLimited = "global_phone_rate_limited"
+	SendReasonTenantRateLimited      = "tenant_rate_limited"
+)
+
+// Resend modes: rotate sends a new code, same re-delivers the original one.
+const (
+	ResendModeRotate = "rotate"
+	ResendModeSame   = "same"
+)
+
+// PurposeDefault is the purpose of OTPs sent without one. Its OTPs keep the key names
+// used before purposes existed.
+const PurposeDefault = "default"
+
 // Verification result constants.
 const (
 	VerificationResultSuccess = "success"
 	VerificationResultFailed  = "failed"
+	// VerificationResultCancelled records the revocation of an OTP; its reason is the
+	// caller's cancel reason.
+	VerificationResultCancelled = "cancelled"
 )
 
 // Verification reason constants.
@@ -22,30 +49,73 @@ const (
 	ReasonExpired             = "expired"
 	ReasonNotFound            = "not_found"
 	ReasonMaxAttemptsExceeded = "max_attempts_exceeded"
+	ReasonPhoneLocked         = "phone_locked"
 	ReasonVerified            = "verified"
+	ReasonCancelled           = "cancelled"
 )
 
-// SendRequest is the application-level input for sending an OTP.
+// MaxCancelReasonLength caps the free-text reason of a cancelled OTP.
+const MaxCancelReasonLength = 200
+
+// SendRequest is the application-level input for sending an OTP. Purpose binds the OTP
+// to one flow of the tenant, such as login or password_reset; empty means PurposeDefault.
 type SendRequest struct {
 	Phone    string                 `json:"phone"`
 	TenantID int64                  `json:"tenant_id"`
+	Purpose  string                 `json:"purpose,omitempty"`
 	Token    string                 `json:"token,omitempty"`
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
-// SendResponse is returned after an OTP send request is accepted.
+// SendResponse is returned after an OTP send or resend request is accepted.
 type SendResponse struct {
-	RequestID string    `json:"request_id"`
-	ExpiredAt time.Time `json:"expired_at"`
+	RequestID   string    `json:"request_id"`
+	ExpiredAt   time.Time `json:"expired_at"`
+	ResendCount int       `json:"resend_count,omitempty"`
 }
 
-// VerifyRequest is the application-level input for verifying an OTP.
+// ResendRequest is the application-level input for resending the active OTP.
+type ResendRequest struct {
+	TenantID int64                  `json:"tenant_id"`
+	Phone    string                 `json:"phone"`
+	Purpose  string                 `json:"purpose,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// VerifyRequest is the application-level input for verifying an OTP. Purpose must be
+// the one the OTP was sent for; empty means PurposeDefault.
 type VerifyRequest struct {
 	TenantID int64  `json:"tenant_id"`
 	Phone    string `json:"phone"`
+	Purpose  string `json:"purpose,omitempty"`
 	Code     string `json:"code"`
 }
 
+// VerifyByRequestIDRequest is the application-level input for verifying an OTP by the
+// request ID returned from send, without the phone. Purpose works as in VerifyRequest.
+type VerifyByRequestIDRequest struct {
+	TenantID  int64  `json:"tenant_id"`
+	RequestID string `json:"request_id"`
+	Purpose   string `json:"purpose,omitempty"`
+	Code      string `json:"code"`
+}
+
+// UnlockRequest is the application-level input for lifting a phone lockout. AdminToken
+// is the tenant admin token presented by the caller; it is never serialized.
+type UnlockRequest struct {
+	TenantID   int64  `json:"tenant_id"`
+	Phone      string `json:"phone"`
+	AdminToken string `json:"-"`
+}
+
+// CancelRequest is the application-level input for revoking the active OTP issued under
+// a request ID. Reason is optional and defaults to ReasonCancelled.
+type CancelRequest struct {
+	TenantID  int64  `json:"tenant_id"`
+	RequestID string `json:"request_id"`
+	Reason    string `json:"reason,omitempty"`
+}
+
 // VerifyResponse represents the outcome of an OTP verification attempt.
 type VerifyResponse struct {
 	Verified  bool   `json:"verified"`
@@ -53,40 +123,78 @@ type VerifyResponse struct {
 	Reason    string `json:"reason,omitempty"`
 }
 
+// OTPStatus describes an active OTP without its code or phone.
+type OTPStatus struct {
+	RequestID         string    `json:"request_id"`
+	Purpose           string    `json:"purpose"`
+	AttemptCount      int       `json:"attempt_count"`
+	MaxAttempts       int       `json:"max_attempts"`
+	RemainingAttempts int       `json:"remaining_attempts"`
+	ResendCount       int       `json:"resend_count"`
+	CreatedAt         time.Time `json:"created_at"`
+	LastSentAt        time.Time `json:"last_sent_at"`
+	ExpiredAt         time.Time `json:"expired_at"`
+}
+
 // TenantSettings contains the subset of tenant configuration needed by OTP flows.
 type TenantSettings struct {
-	ID              int64                  `json:"id"`
-	TenantCode      string                 `json:"tenant_code"`
-	Name            string                 `json:"name"`
-	Status          string                 `json:"status"`
-	OTPEnabled      bool                   `json:"otp_enabled"`
-	SMSProvider     string                 `json:"sms_provider"`
-	RateLimitPerMin int                    `json:"rate_limit_per_min"`
-	Timezone        string                 `json:"timezone"`
-	Metadata        map[string]interface{} `json:"metadata,omitempty"`
-	ExpiresAt       *time.Time             `json:"expires_at,omitempty"`
-}
-
-// OTPState is the Redis-backed verification state. CodeHash must never contain plaintext OTP.
+	ID                int64                          `json:"id"`
+	TenantCode        string                         `json:"tenant_code"`
+	Name              string                         `json:"name"`
+	Status            string                         `json:"status"`
+	OTPEnabled        bool                           `json:"otp_enabled"`
+	SMSProvider       string                         `json:"sms_provider"`
+	SMSTemplate       string                         `json:"sms_template,omitempty"`
+	SMSTemplateParams []string                       `json:"sms_template_params,omitempty"`
+	SMSSender         string                         `json:"sms_sender,omitempty"`
+	SMSFailover       []string                       `json:"sms_failover,omitempty"`
+	SMSProviders      map[string]ProviderSMSSettings `json:"sms_providers,omitempty"`
+	RateLimitPerMin   int                            `json:"rate_limit_per_min"`
+	Timezone          string                         `json:"timezone"`
+	Metadata          map[string]interface{}         `json:"metadata,omitempty"`
+	OTPPolicy         *Policy                        `json:"otp_policy,omitempty"`
+	ExpiresAt         *time.Time                     `json:"expires_at,omitempty"`
+}
+
+// ProviderSMSSettings is a tenant's own configuration for one SMS provider, keyed by the
+// lower-case provider name in TenantSettings.SMSProviders. The API key itself is never
+// carried here; HasCredentials only records that the tenant configured one.
+type ProviderSMSSettings struct {
+	Template       string   `json:"template,omitempty"`
+	TemplateParams []string `json:"template_params,omitempty"`
+	Sender         string   `json:"sender,omitempty"`
+	HasCredentials bool     `json:"has_credentials"`
+}
+
+// OTPState is the Redis-backed verification state. CodeHash must never contain plaintext OTP;
+// SealedCode is the encrypted code, set only for tenants that resend the same code.
 type OTPState struct {
-	RequestID    string    `json:"request_id"`
-	TenantID     int64     `json:"tenant_id"`
-	Phone        string    `json:"phone"`
-	CodeHash     string    `json:"code_hash"`
-	AttemptCount int       `json:"attempt_count"`
-	MaxAttempts  int       `json:"max_attempts"`
-	CreatedAt    time.Time `json:"created_at"`
-	ExpiresAt    time.Time `json:"expires_at"`
+	RequestID     string    `json:"request_id"`
+	TenantID      int64     `json:"tenant_id"`
+	Phone         string    `json:"phone"`
+	Purpose       string    `json:"purpose"`
+	CodeHash      string    `json:"code_hash"`
+	CodeHashKeyID string    `json:"code_hash_key_id,omitempty"`
+	SealedCode    string    `json:"sealed_code,omitempty"`
+	AttemptCount  int       `json:"attempt_count"`
+	MaxAttempts   int       `json:"max_attempts"`
+	ResendCount   int       `json:"resend_count"`
+	CreatedAt     time.Time `json:"created_at"`
+	LastSentAt    time.Time `json:"last_sent_at"`
+	ExpiresAt     time.Time `json:"expires_at"`
 }
 
 // SMSRequest is sent to an SMS provider adapter.
 type SMSRequest struct {
-	RequestID string                 `json:"request_id"`
-	TenantID  int64                  `json:"tenant_id"`
-	Phone     string                 `json:"phone"`
-	Code      string                 `json:"code"`
-	Provider  string                 `json:"provider"`
-	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	RequestID      string                 `json:"request_id"`
+	TenantID       int64                  `json:"tenant_id"`
+	Phone          string                 `json:"phone"`
+	Code           string                 `json:"code"`
+	Provider       string                 `json:"provider"`
+	Template       string                 `json:"template,omitempty"`
+	TemplateParams []string               `json:"template_params,omitempty"`
+	Sender         string                 `json:"sender,omitempty"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
 }
 
 // SMSResult describes the provider response in a transport-neutral shape.
@@ -113,15 +221,28 @@ type OTPRequestLog struct {
 }
 
 // OTPProviderResultLog captures the provider result for a previously created request.
+// Resends report the latest delivery and their ResendCount.
 type OTPProviderResultLog struct {
 	RequestID        string                 `json:"request_id"`
 	Status           string                 `json:"status"`
 	ProviderName     string                 `json:"provider_name"`
 	ProviderResponse map[string]interface{} `json:"provider_response,omitempty"`
 	ErrorMessage     string                 `json:"error_message,omitempty"`
+	ResendCount      int                    `json:"resend_count,omitempty"`
 	UpdatedAt        time.Time              `json:"updated_at"`
 }
 
+// OTPVerificationResultLog captures how verification of a previously created request
+// ended. AttemptCount is the number of failed attempts; VerifiedAt is zero unless verified.
+type OTPVerificationResultLog struct {
+	RequestID    string    `json:"request_id"`
+	Status       string    `json:"status"`
+	ErrorMessage string    `json:"error_message,omitempty"`
+	AttemptCount int       `json:"attempt_count"`
+	VerifiedAt   time.Time `json:"verified_at,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
 // OTPVerificationLog captures the reportable verification attempt data.
 type OTPVerificationLog struct {
 	RequestID     string    `json:"request_id"`
@@ -0,0 +1,162 @@
+package otp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PhoneRegionMetadataKey is the tenant_settings.metadata key holding the tenant's default
+// phone region as an ISO 3166-1 alpha-2 code, for example {"phone_region": "IR"}.
+const PhoneRegionMetadataKey = "phone_region"
+
+// phoneRegion describes how national numbers are written in one region.
+type phoneRegion struct {
+	countryCode string
+	trunkPrefix string
+	// National significant number lengths, without trunk prefix.
+	minLength int
+	maxLength int
+}
+
+func (r phoneRegion) validLength(n int) bool {
+	return n >= r.minLength && n <= r.maxLength
+}
+
+// phoneRegions lists the regions national numbers can be parsed for. International
+// numbers with other country codes are accepted on E.164 length alone.
+var phoneRegions = map[string]phoneRegion{
+	"AE": {countryCode: "971", trunkPrefix: "0", minLength: 8, maxLength: 9},
+	"CA": {countryCode: "1", trunkPrefix: "1", minLength: 10, maxLength: 10},
+	"DE": {countryCode: "49", trunkPrefix: "0", minLength: 10, maxLength: 11},
+	"GB": {countryCode: "44", trunkPrefix: "0", minLength: 10, maxLength: 10},
+	"IQ": {countryCode: "964", trunkPrefix: "0", minLength: 8, maxLength: 10},
+	"IR": {countryCode: "98", trunkPrefix: "0", minLength: 10, maxLength: 10},
+	"TR": {countryCode: "90", trunkPrefix: "0", minLength: 10, maxLength: 10},
+	"US": {countryCode: "1", trunkPrefix: "1", minLength: 10, maxLength: 10},
+}
+
+// timezoneRegions derives a region from tenant_settings.timezone when the tenant has
+// no explicit phone_region.
+var timezoneRegions = map[string]string{
+	"Asia/Baghdad":     "IQ",
+	"Asia/Dubai":       "AE",
+	"Asia/Tehran":      "IR",
+	"Europe/Berlin":    "DE",
+	"Europe/Istanbul":  "TR",
+	"Europe/London":    "GB",
+	"America/Chicago":  "US",
+	"America/New_York": "US",
+	"America/Toronto":  "CA",
+}
+
+const (
+	minE164Digits = 8
+	maxE164Digits = 15
+)
+
+// PhoneNormalizer parses national and international phone numbers into canonical E.164,
+// so that every spelling of a number maps to the same Redis keys, limits and log rows.
+type PhoneNormalizer struct {
+	defaultRegion string
+}
+
+// NewPhoneNormalizer creates a normalizer. defaultRegion is used for tenants without a
+// region of their own; empty means such tenants must send international numbers.
+func NewPhoneNormalizer(defaultRegion string) (*PhoneNormalizer, error) {
+	defaultRegion = strings.ToUpper(strings.TrimSpace(defaultRegion))
+	if defaultRegion != "" {
+		if _, ok := phoneRegions[defaultRegion]; !ok {
+			return nil, fmt.Errorf("unsupported default phone region %q", defaultRegion)
+		}
+	}
+	return &PhoneNormalizer{defaultRegion: defaultRegion}, nil
+}
+
+// Region returns the tenant's phone region: metadata phone_region, else the region of
+// its timezone, else the normalizer default. A nil normalizer has no default.
+func (n *PhoneNormalizer) Region(tenant *TenantSettings) string {
+	if tenant != nil {
+		if region, ok := tenant.Metadata[PhoneRegionMetadataKey].(string); ok {
+			region = strings.ToUpper(strings.TrimSpace(region))
+			if _, supported := phoneRegions[region]; supported {
+				return region
+			}
+		}
+		if region, ok := timezoneRegions[tenant.Timezone]; ok {
+			return region
+		}
+	}
+	if n == nil {
+		return ""
+	}
+	return n.defaultRegion
+}
+
+// Normalize returns phone in E.164 form such as "+989121234567". National numbers are
+// read in region. Separators are ignored and Persian/Arabic-Indic digits are accepted.
+// Invalid numbers return an error wrapping ErrInvalidPhone.
+func (n *PhoneNormalizer) Normalize(phone string, region string) (string, error) {
+	digits, international, err := phoneDigits(phone)
+	if err != nil {
+		return "", err
+	}
+
+	if !international {
+		spec, ok := phoneRegions[region]
+		if !ok {
+			return "", fmt.Errorf("%w: national number without a phone region", ErrInvalidPhone)
+		}
+		switch {
+		case strings.HasPrefix(digits, spec.trunkPrefix) && spec.validLength(len(digits)-len(spec.trunkPrefix)):
+			digits = spec.countryCode + digits[len(spec.trunkPrefix):]
+		case strings.HasPrefix(digits, spec.countryCode) && spec.validLength(len(digits)-len(spec.countryCode)):
+			// Already carries the country code, only the leading + is missing.
+		case spec.validLength(len(digits)) && !strings.HasPrefix(digits, spec.trunkPrefix):
+			digits = spec.countryCode + digits
+		default:
+			return "", fmt.Errorf("%w: wrong length for region %s", ErrInvalidPhone, region)
+		}
+	}
+
+	if len(digits) < minE164Digits || len(digits) > maxE164Digits || digits[0] == '0' {
+		return "", fmt.Errorf("%w: not a valid E.164 number", ErrInvalidPhone)
+	}
+	for _, spec := range phoneRegions {
+		if strings.HasPrefix(digits, spec.countryCode) && !spec.validLength(len(digits)-len(spec.countryCode)) {
+			return "", fmt.Errorf("%w: wrong length for country code +%s", ErrInvalidPhone, spec.countryCode)
+		}
+	}
+	return "+" + digits, nil
+}
+
+// phoneDigits strips separators and reports whether the number had an international
+// prefix ("+" or "00").
+func phoneDigits(phone string) (string, bool, error) {
+	var b strings.Builder
+	international := false
+	for i, r := range strings.TrimSpace(phone) {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r >= '۰' && r <= '۹':
+			b.WriteRune('0' + (r - '۰'))
+		case r >= '٠' && r <= '٩':
+			b.WriteRune('0' + (r - '٠'))
+		case r == '+' && i == 0:
+			international = true
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", false, fmt.Errorf("%w: unexpected character %q", ErrInvalidPhone, r)
+		}
+	}
+
+	digits := b.String()
+	if !international && strings.HasPrefix(digits, "00") {
+		digits = digits[2:]
+		international = true
+	}
+	if digits == "" {
+		return "", false, fmt.Errorf("%w: no digits", ErrInvalidPhone)
+	}
+	return digits, international, nil
+}
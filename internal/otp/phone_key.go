@@ -0,0 +1,83 @@
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+const (
+	minPhoneKeySecretLength = 16
+	// phoneKeyBytes keeps 128 bits of the HMAC, plenty to avoid collisions in key names.
+	phoneKeyBytes = 16
+)
+
+// PhoneKeyHasher derives the phone part of Redis key names from an HMAC of the normalized
+// phone, so that a key dump does not reveal phone numbers. Tenant-scoped keys mix in the
+// tenant ID, so the same phone gets unrelated keys in different tenants. Redis values
+// that must hold the phone itself are sealed with a key derived from the same secret.
+type PhoneKeyHasher struct {
+	secret []byte
+	sealer *CodeSealer
+}
+
+// NewPhoneKeyHasher creates a hasher from a server-side secret.
+func NewPhoneKeyHasher(secret []byte) (*PhoneKeyHasher, error) {
+	if len(secret) < minPhoneKeySecretLength {
+		return nil, fmt.Errorf("otp phone key hasher: secret must be at least %d bytes", minPhoneKeySecretLength)
+	}
+	hasher := &PhoneKeyHasher{secret: append([]byte(nil), secret...)}
+	sealer, err := NewCodeSealer(hasher.mac("phone-seal"))
+	if err != nil {
+		return nil, fmt.Errorf("otp phone key hasher: %w", err)
+	}
+	hasher.sealer = sealer
+	return hasher, nil
+}
+
+// TenantKey returns the key component for a tenant-scoped key. A nil hasher returns the
+// phone itself, the legacy key format.
+func (h *PhoneKeyHasher) TenantKey(tenantID int64, phone string) string {
+	if h == nil {
+		return phone
+	}
+	return h.sum("tenant:" + strconv.FormatInt(tenantID, 10) + ":" + phone)
+}
+
+// GlobalKey returns the key component for a key shared by all tenants. A nil hasher
+// returns the phone itself, the legacy key format.
+func (h *PhoneKeyHasher) GlobalKey(phone string) string {
+	if h == nil {
+		return phone
+	}
+	return h.sum("global:" + phone)
+}
+
+// SealPhone encrypts phone bound to requestID, for Redis values that must name the
+// phone. A nil hasher returns the phone itself, as key names then carry it anyway.
+func (h *PhoneKeyHasher) SealPhone(phone string, requestID string) (string, error) {
+	if h == nil {
+		return phone, nil
+	}
+	return h.sealer.Seal(phone, requestID)
+}
+
+// OpenPhone decrypts a phone sealed for requestID.
+func (h *PhoneKeyHasher) OpenPhone(sealed string, requestID string) (string, error) {
+	if h == nil {
+		return "", fmt.Errorf("otp phone key hasher: not configured")
+	}
+	return h.sealer.Open(sealed, requestID)
+}
+
+func (h *PhoneKeyHasher) sum(value string) string {
+	return hex.EncodeToString(h.mac(value)[:phoneKeyBytes])
+}
+
+func (h *PhoneKeyHasher) mac(value string) []byte {
+	mac := hmac.New(sha256.New, h.secret)
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
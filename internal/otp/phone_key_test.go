@@ -0,0 +1,73 @@
+package otp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPhoneKeyHasherValidation(t *testing.T) {
+	_, err := NewPhoneKeyHasher([]byte("too-short"))
+	require.Error(t, err)
+
+	_, err = NewPhoneKeyHasher([]byte("0123456789abcdef"))
+	require.NoError(t, err)
+}
+
+func TestPhoneKeyHasher(t *testing.T) {
+	hasher, err := NewPhoneKeyHasher([]byte("phone-key-secret-1"))
+	require.NoError(t, err)
+	other, err := NewPhoneKeyHasher([]byte("phone-key-secret-2"))
+	require.NoError(t, err)
+	phone := "+989121234567"
+
+	key := hasher.TenantKey(42, phone)
+
+	assert.Len(t, key, 32)
+	assert.NotContains(t, key, strings.TrimPrefix(phone, "+"))
+	assert.Equal(t, key, hasher.TenantKey(42, phone))
+	assert.NotEqual(t, key, hasher.TenantKey(43, phone))
+	assert.NotEqual(t, key, hasher.TenantKey(42, "+989121234568"))
+	assert.NotEqual(t, key, other.TenantKey(42, phone))
+	assert.NotEqual(t, key, hasher.GlobalKey(phone))
+	assert.Equal(t, hasher.GlobalKey(phone), hasher.GlobalKey(phone))
+}
+
+func TestNilPhoneKeyHasherUsesLegacyKeys(t *testing.T) {
+	var hasher *PhoneKeyHasher
+
+	assert.Equal(t, "+989121234567", hasher.TenantKey(42, "+989121234567"))
+	assert.Equal(t, "+989121234567", hasher.GlobalKey("+989121234567"))
+}
+
+func TestPhoneKeyHasherSealPhone(t *testing.T) {
+	hasher, err := NewPhoneKeyHasher([]byte("phone-key-secret-1"))
+	require.NoError(t, err)
+	other, err := NewPhoneKeyHasher([]byte("phone-key-secret-2"))
+	require.NoError(t, err)
+
+	sealed, err := hasher.SealPhone("+989121234567", "request-1")
+	require.NoError(t, err)
+
+	assert.NotContains(t, sealed, "9121234567")
+	phone, err := hasher.OpenPhone(sealed, "request-1")
+	require.NoError(t, err)
+	assert.Equal(t, "+989121234567", phone)
+	_, err = hasher.OpenPhone(sealed, "request-2")
+	assert.Error(t, err)
+	_, err = other.OpenPhone(sealed, "request-1")
+	assert.Error(t, err)
+}
+
+func TestNilPhoneKeyHasherKeepsPlaintextPhone(t *testing.T) {
+	var hasher *PhoneKeyHasher
+
+	sealed, err := hasher.SealPhone("+989121234567", "request-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, "+989121234567", sealed)
+	_, err = hasher.OpenPhone(sealed, "request-1")
+	assert.Error(t, err)
+}
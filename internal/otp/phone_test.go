@@ -0,0 +1,94 @@
+package otp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPhoneNormalizerNormalize(t *testing.T) {
+	normalizer, err := NewPhoneNormalizer("")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		phone  string
+		region string
+		want   string
+	}{
+		{name: "e164", phone: "+989121234567", want: "+989121234567"},
+		{name: "international 00 prefix", phone: "00989121234567", want: "+989121234567"},
+		{name: "national with trunk prefix", phone: "09121234567", region: "IR", want: "+989121234567"},
+		{name: "country code without plus", phone: "989121234567", region: "IR", want: "+989121234567"},
+		{name: "national without trunk prefix", phone: "9121234567", region: "IR", want: "+989121234567"},
+		{name: "separators", phone: " +98 (912) 123-45.67 ", want: "+989121234567"},
+		{name: "persian digits", phone: "۰۹۱۲۱۲۳۴۵۶۷", region: "IR", want: "+989121234567"},
+		{name: "arabic-indic digits", phone: "٠٩١٢١٢٣٤٥٦٧", region: "IR", want: "+989121234567"},
+		{name: "us national", phone: "(212) 555-0123", region: "US", want: "+12125550123"},
+		{name: "us with trunk prefix", phone: "1 212 555 0123", region: "US", want: "+12125550123"},
+		{name: "german ten digit mobile", phone: "0151 2345678", region: "DE", want: "+491512345678"},
+		{name: "international ignores region", phone: "+447911123456", region: "IR", want: "+447911123456"},
+		{name: "unlisted country code", phone: "+33612345678", want: "+33612345678"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizer.Normalize(tt.phone, tt.region)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPhoneNormalizerNormalizeInvalid(t *testing.T) {
+	normalizer, err := NewPhoneNormalizer("")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		phone  string
+		region string
+	}{
+		{name: "letters", phone: "+98912abc4567"},
+		{name: "plus not leading", phone: "98+9121234567", region: "IR"},
+		{name: "no digits", phone: "+ ( )"},
+		{name: "national without region", phone: "09121234567"},
+		{name: "national too short", phone: "0912123456", region: "IR"},
+		{name: "national too long", phone: "091212345678", region: "IR"},
+		{name: "known country code wrong length", phone: "+98912123456"},
+		{name: "nanp wrong length", phone: "+1212555012"},
+		{name: "e164 too short", phone: "+3361234"},
+		{name: "e164 too long", phone: "+3361234567890123"},
+		{name: "leading zero country code", phone: "+0989121234567"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := normalizer.Normalize(tt.phone, tt.region)
+
+			assert.ErrorIs(t, err, ErrInvalidPhone)
+		})
+	}
+}
+
+func TestPhoneNormalizerRegion(t *testing.T) {
+	normalizer, err := NewPhoneNormalizer(" ir ")
+	require.NoError(t, err)
+
+	assert.Equal(t, "GB", normalizer.Region(&TenantSettings{Timezone: "Asia/Tehran", Metadata: map[string]interface{}{PhoneRegionMetadataKey: "gb"}}))
+	assert.Equal(t, "TR", normalizer.Region(&TenantSettings{Timezone: "Europe/Istanbul"}))
+	assert.Equal(t, "US", normalizer.Region(&TenantSettings{Timezone: "America/New_York", Metadata: map[string]interface{}{PhoneRegionMetadataKey: "XX"}}))
+	assert.Equal(t, "IR", normalizer.Region(&TenantSettings{Timezone: "UTC"}))
+	assert.Equal(t, "IR", normalizer.Region(nil))
+
+	var none *PhoneNormalizer
+	assert.Equal(t, "", none.Region(&TenantSettings{Timezone: "UTC"}))
+}
+
+func TestNewPhoneNormalizerRejectsUnknownRegion(t *testing.T) {
+	_, err := NewPhoneNormalizer("XX")
+
+	require.Error(t, err)
+}
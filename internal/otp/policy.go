@@ -0,0 +1,279 @@
+package otp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PolicyMetadataKey is the tenant_settings.metadata key holding per-tenant OTP overrides.
+const PolicyMetadataKey = "otp_policy"
+
+// minPolicyCodeLength is the shortest code a tenant may configure; shorter codes are
+// guessable within a handful of attempts.
+const minPolicyCodeLength = 4
+
+// Policy contains the OTP parameters a tenant may override. Zero values fall back
+// to the global Config.
+type Policy struct {
+	CodeLength     int           `json:"code_length,omitempty"`
+	TTL            time.Duration `json:"ttl,omitempty"`
+	MaxAttempts    int           `json:"max_attempts,omitempty"`
+	ResendCooldown time.Duration `json:"resend_cooldown,omitempty"`
+	MaxResends     int           `json:"max_resends,omitempty"`
+	ResendMode     string        `json:"resend_mode,omitempty"`
+	// Purposes lists the purposes the tenant allows, with their overrides. A nil map
+	// allows every purpose.
+	Purposes map[string]PurposePolicy `json:"purposes,omitempty"`
+	// Invalid marks a policy that could not be fully parsed. Only its valid fields
+	// apply, and sends are restricted to the default purpose or the valid listed ones.
+	Invalid bool `json:"invalid,omitempty"`
+}
+
+// PurposePolicy contains the OTP parameters a tenant may override per purpose. Zero
+// values fall back to the tenant policy.
+type PurposePolicy struct {
+	TTL time.Duration `json:"ttl,omitempty"`
+}
+
+// ParsePolicy reads and validates the otp_policy block from tenant metadata, for example
+// {"otp_policy": {"code_length": 8, "ttl": "60s", "max_attempts": 3, "resend_mode": "same"}}.
+// A purposes block restricts the allowed purposes and may set their TTLs, for example
+// {"purposes": {"login": {}, "password_reset": {"ttl": "10m"}}}.
+// It returns nil when the tenant has no overrides. An invalid block returns the error
+// together with the policy built from its valid fields, marked Invalid.
+func ParsePolicy(metadata map[string]interface{}) (*Policy, error) {
+	raw, ok := metadata[PolicyMetadataKey]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	policy := &Policy{}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		policy.Invalid = true
+		return policy, fmt.Errorf("otp_policy: %w", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+		policy.Invalid = true
+		return policy, fmt.Errorf("otp_policy: must be an object")
+	}
+
+	var errs []error
+	for name, value := range fields {
+		if string(value) == "null" {
+			continue
+		}
+		if err := policy.setField(name, value); err != nil {
+			errs = append(errs, fmt.Errorf("otp_policy: %w", err))
+		}
+	}
+	if len(errs) > 0 {
+		policy.Invalid = true
+		return policy, errors.Join(errs...)
+	}
+	return policy, nil
+}
+
+// setField validates one otp_policy field and applies it to the policy.
+func (p *Policy) setField(name string, value json.RawMessage) error {
+	switch name {
+	case "code_length":
+		codeLength, err := decodePolicyInt(name, value)
+		if err != nil {
+			return err
+		}
+		if codeLength < minPolicyCodeLength || codeLength > maxCodeLength {
+			return fmt.Errorf("code_length must be between %d and %d", minPolicyCodeLength, maxCodeLength)
+		}
+		p.CodeLength = codeLength
+	case "ttl":
+		ttl, err := decodePolicyDuration(name, value)
+		if err != nil {
+			return err
+		}
+		p.TTL = ttl
+	case "max_attempts":
+		maxAttempts, err := decodePolicyInt(name, value)
+		if err != nil {
+			return err
+		}
+		if maxAttempts <= 0 {
+			return fmt.Errorf("max_attempts must be > 0")
+		}
+		p.MaxAttempts = maxAttempts
+	case "resend_cooldown":
+		cooldown, err := decodePolicyDuration(name, value)
+		if err != nil {
+			return err
+		}
+		p.ResendCooldown = cooldown
+	case "max_resends":
+		maxResends, err := decodePolicyInt(name, value)
+		if err != nil {
+			return err
+		}
+		if maxResends <= 0 {
+			return fmt.Errorf("max_resends must be > 0")
+		}
+		p.MaxResends = maxResends
+	case "resend_mode":
+		var mode string
+		if err := json.Unmarshal(value, &mode); err != nil {
+			return fmt.Errorf("resend_mode: %w", err)
+		}
+		if mode != ResendModeRotate && mode != ResendModeSame {
+			return fmt.Errorf("resend_mode must be %q or %q", ResendModeRotate, ResendModeSame)
+		}
+		p.ResendMode = mode
+	case "purposes":
+		purposes, err := parsePurposePolicies(value)
+		// Valid purposes are kept even when others are not, so the tenant's
+		// allow-list still applies.
+		p.Purposes = purposes
+		return err
+	default:
+		return fmt.Errorf("unknown field %q", name)
+	}
+	return nil
+}
+
+// parsePurposePolicies returns the valid entries of a purposes block. Invalid entries
+// are left out, so they are not allowed, and reported in the error.
+func parsePurposePolicies(value json.RawMessage) (map[string]PurposePolicy, error) {
+	var parsed map[string]json.RawMessage
+	if err := json.Unmarshal(value, &parsed); err != nil {
+		return nil, fmt.Errorf("purposes: %w", err)
+	}
+	if len(parsed) == 0 {
+		return nil, fmt.Errorf("purposes must not be empty")
+	}
+
+	purposes := make(map[string]PurposePolicy, len(parsed))
+	var errs []error
+	for purpose, override := range parsed {
+		purposePolicy, err := parsePurposePolicy(purpose, override)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		purposes[purpose] = purposePolicy
+	}
+	if len(purposes) == 0 {
+		purposes = nil
+	}
+	return purposes, errors.Join(errs...)
+}
+
+func parsePurposePolicy(purpose string, value json.RawMessage) (PurposePolicy, error) {
+	if !purposePattern.MatchString(purpose) {
+		return PurposePolicy{}, fmt.Errorf("invalid purpose %q", purpose)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.DisallowUnknownFields()
+	var parsed struct {
+		TTL *string `json:"ttl"`
+	}
+	if err := decoder.Decode(&parsed); err != nil {
+		return PurposePolicy{}, fmt.Errorf("purpose %q: %w", purpose, err)
+	}
+
+	var purposePolicy PurposePolicy
+	if parsed.TTL != nil {
+		ttl, err := time.ParseDuration(*parsed.TTL)
+		if err != nil {
+			return PurposePolicy{}, fmt.Errorf("invalid ttl for purpose %q: %w", purpose, err)
+		}
+		if ttl <= 0 {
+			return PurposePolicy{}, fmt.Errorf("ttl for purpose %q must be > 0", purpose)
+		}
+		purposePolicy.TTL = ttl
+	}
+	return purposePolicy, nil
+}
+
+func decodePolicyInt(name string, value json.RawMessage) (int, error) {
+	var n int
+	if err := json.Unmarshal(value, &n); err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	return n, nil
+}
+
+func decodePolicyDuration(name string, value json.RawMessage) (time.Duration, error) {
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%s must be > 0", name)
+	}
+	return d, nil
+}
+
+// effectivePolicy overlays the tenant's overrides on the global config.
+func (s *Service) effectivePolicy(tenant *TenantSettings) Policy {
+	policy := Policy{
+		CodeLength:     s.config.CodeLength,
+		TTL:            s.config.TTL,
+		MaxAttempts:    s.config.MaxAttempts,
+		ResendCooldown: s.config.ResendCooldown,
+		MaxResends:     s.config.MaxResends,
+		ResendMode:     s.config.ResendMode,
+	}
+	if tenant == nil || tenant.OTPPolicy == nil {
+		return policy
+	}
+
+	if tenant.OTPPolicy.CodeLength > 0 {
+		policy.CodeLength = tenant.OTPPolicy.CodeLength
+	}
+	if tenant.OTPPolicy.TTL > 0 {
+		policy.TTL = tenant.OTPPolicy.TTL
+	}
+	if tenant.OTPPolicy.MaxAttempts > 0 {
+		policy.MaxAttempts = tenant.OTPPolicy.MaxAttempts
+	}
+	if tenant.OTPPolicy.ResendCooldown > 0 {
+		policy.ResendCooldown = tenant.OTPPolicy.ResendCooldown
+	}
+	if tenant.OTPPolicy.MaxResends > 0 {
+		policy.MaxResends = tenant.OTPPolicy.MaxResends
+	}
+	if tenant.OTPPolicy.ResendMode != "" {
+		policy.ResendMode = tenant.OTPPolicy.ResendMode
+	}
+	return policy
+}
+
+// purposePolicy returns the effective policy for an OTP purpose, with the tenant's TTL
+// for the purpose, or ErrPurposeNotAllowed when the tenant lists purposes without it.
+// An invalid policy without valid purposes allows only the default purpose.
+func (s *Service) purposePolicy(tenant *TenantSettings, purpose string) (Policy, error) {
+	policy := s.effectivePolicy(tenant)
+	if tenant == nil || tenant.OTPPolicy == nil {
+		return policy, nil
+	}
+	if tenant.OTPPolicy.Purposes == nil {
+		if tenant.OTPPolicy.Invalid && purpose != PurposeDefault {
+			return Policy{}, ErrPurposeNotAllowed
+		}
+		return policy, nil
+	}
+	purposePolicy, ok := tenant.OTPPolicy.Purposes[purpose]
+	if !ok {
+		return Policy{}, ErrPurposeNotAllowed
+	}
+	if purposePolicy.TTL > 0 {
+		policy.TTL = purposePolicy.TTL
+	}
+	return policy, nil
+}
@@ -0,0 +1,195 @@
+package otp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]interface{}
+		want     *Policy
+	}{
+		{name: "nil metadata", metadata: nil, want: nil},
+		{name: "no policy", metadata: map[string]interface{}{"source": "test"}, want: nil},
+		{name: "null policy", metadata: map[string]interface{}{PolicyMetadataKey: nil}, want: nil},
+		{
+			name: "full policy",
+			metadata: map[string]interface{}{PolicyMetadataKey: map[string]interface{}{
+				"code_length":  8,
+				"ttl":          "60s",
+				"max_attempts": 3,
+			}},
+			want: &Policy{CodeLength: 8, TTL: 60 * time.Second, MaxAttempts: 3},
+		},
+		{
+			name: "resend policy",
+			metadata: map[string]interface{}{PolicyMetadataKey: map[string]interface{}{
+				"resend_cooldown": "45s",
+				"max_resends":     2,
+				"resend_mode":     "same",
+			}},
+			want: &Policy{ResendCooldown: 45 * time.Second, MaxResends: 2, ResendMode: ResendModeSame},
+		},
+		{
+			name: "json decoded numbers",
+			metadata: map[string]interface{}{PolicyMetadataKey: map[string]interface{}{
+				"code_length": float64(5),
+			}},
+			want: &Policy{CodeLength: 5},
+		},
+		{
+			name:     "partial policy",
+			metadata: map[string]interface{}{PolicyMetadataKey: map[string]interface{}{"ttl": "5m"}},
+			want:     &Policy{TTL: 5 * time.Minute},
+		},
+		{
+			name: "purposes",
+			metadata: map[string]interface{}{PolicyMetadataKey: map[string]interface{}{
+				"purposes": map[string]interface{}{
+					"login":          map[string]interface{}{},
+					"password_reset": map[string]interface{}{"ttl": "10m"},
+				},
+			}},
+			want: &Policy{Purposes: map[string]PurposePolicy{
+				"login":          {},
+				"password_reset": {TTL: 10 * time.Minute},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePolicy(tt.metadata)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParsePolicyInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy interface{}
+		errMsg string
+	}{
+		{name: "not an object", policy: "8 digits", errMsg: "otp_policy"},
+		{name: "unknown field", policy: map[string]interface{}{"length": 8}, errMsg: "unknown field"},
+		{name: "code length too short", policy: map[string]interface{}{"code_length": 0}, errMsg: "code_length"},
+		{name: "code length below security floor", policy: map[string]interface{}{"code_length": 3}, errMsg: "code_length"},
+		{name: "code length too long", policy: map[string]interface{}{"code_length": 19}, errMsg: "code_length"},
+		{name: "fractional code length", policy: map[string]interface{}{"code_length": 6.5}, errMsg: "otp_policy"},
+		{name: "numeric ttl", policy: map[string]interface{}{"ttl": 60}, errMsg: "otp_policy"},
+		{name: "malformed ttl", policy: map[string]interface{}{"ttl": "soon"}, errMsg: "invalid ttl"},
+		{name: "non-positive ttl", policy: map[string]interface{}{"ttl": "0s"}, errMsg: "ttl must be > 0"},
+		{name: "non-positive max attempts", policy: map[string]interface{}{"max_attempts": -1}, errMsg: "max_attempts"},
+		{name: "malformed resend cooldown", policy: map[string]interface{}{"resend_cooldown": "soon"}, errMsg: "invalid resend_cooldown"},
+		{name: "non-positive max resends", policy: map[string]interface{}{"max_resends": 0}, errMsg: "max_resends"},
+		{name: "unknown resend mode", policy: map[string]interface{}{"resend_mode": "reuse"}, errMsg: "resend_mode"},
+		{name: "empty purposes", policy: map[string]interface{}{"purposes": map[string]interface{}{}}, errMsg: "purposes must not be empty"},
+		{name: "invalid purpose", policy: map[string]interface{}{"purposes": map[string]interface{}{"Login!": map[string]interface{}{}}}, errMsg: "invalid purpose"},
+		{name: "unknown purpose field", policy: map[string]interface{}{"purposes": map[string]interface{}{"login": map[string]interface{}{"max_attempts": 3}}}, errMsg: "unknown field"},
+		{name: "malformed purpose ttl", policy: map[string]interface{}{"purposes": map[string]interface{}{"login": map[string]interface{}{"ttl": "soon"}}}, errMsg: "invalid ttl for purpose"},
+		{name: "non-positive purpose ttl", policy: map[string]interface{}{"purposes": map[string]interface{}{"login": map[string]interface{}{"ttl": "0s"}}}, errMsg: "ttl for purpose"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePolicy(map[string]interface{}{PolicyMetadataKey: tt.policy})
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+			require.NotNil(t, got)
+			assert.True(t, got.Invalid)
+		})
+	}
+}
+
+func TestParsePolicyInvalidKeepsValidFields(t *testing.T) {
+	got, err := ParsePolicy(map[string]interface{}{PolicyMetadataKey: map[string]interface{}{
+		"code_length":  8,
+		"ttl":          "2 minutes",
+		"max_attempts": 3,
+		"length":       6,
+		"purposes": map[string]interface{}{
+			"login":          map[string]interface{}{},
+			"password_reset": map[string]interface{}{"ttl": "soon"},
+		},
+	}})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid ttl")
+	assert.Contains(t, err.Error(), "unknown field")
+	assert.Contains(t, err.Error(), "invalid ttl for purpose")
+	assert.Equal(t, &Policy{
+		CodeLength:  8,
+		MaxAttempts: 3,
+		Purposes:    map[string]PurposePolicy{"login": {}},
+		Invalid:     true,
+	}, got)
+}
+
+func TestServiceEffectivePolicy(t *testing.T) {
+	service := NewService(nil, nil, nil, nil, nil, Config{
+		CodeLength:  6,
+		TTL:         2 * time.Minute,
+		MaxAttempts: 5,
+	})
+	global := Policy{CodeLength: 6, TTL: 2 * time.Minute, MaxAttempts: 5, ResendCooldown: 30 * time.Second, MaxResends: 3, ResendMode: ResendModeRotate}
+
+	assert.Equal(t, global, service.effectivePolicy(nil))
+	assert.Equal(t, global, service.effectivePolicy(&TenantSettings{}))
+	assert.Equal(t,
+		Policy{CodeLength: 8, TTL: 2 * time.Minute, MaxAttempts: 3, ResendCooldown: 30 * time.Second, MaxResends: 3, ResendMode: ResendModeSame},
+		service.effectivePolicy(&TenantSettings{OTPPolicy: &Policy{CodeLength: 8, MaxAttempts: 3, ResendMode: ResendModeSame}}),
+	)
+}
+
+func TestServicePurposePolicy(t *testing.T) {
+	service := NewService(nil, nil, nil, nil, nil, Config{TTL: 2 * time.Minute})
+	tenant := &TenantSettings{OTPPolicy: &Policy{
+		TTL: 5 * time.Minute,
+		Purposes: map[string]PurposePolicy{
+			"login":          {},
+			"password_reset": {TTL: 10 * time.Minute},
+		},
+	}}
+
+	policy, err := service.purposePolicy(nil, "payment")
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Minute, policy.TTL)
+
+	policy, err = service.purposePolicy(tenant, "login")
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, policy.TTL)
+
+	policy, err = service.purposePolicy(tenant, "password_reset")
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, policy.TTL)
+
+	_, err = service.purposePolicy(tenant, PurposeDefault)
+	assert.ErrorIs(t, err, ErrPurposeNotAllowed)
+}
+
+func TestServicePurposePolicyInvalidPolicy(t *testing.T) {
+	service := NewService(nil, nil, nil, nil, nil, Config{TTL: 2 * time.Minute})
+	invalid := &TenantSettings{OTPPolicy: &Policy{TTL: 5 * time.Minute, Invalid: true}}
+
+	policy, err := service.purposePolicy(invalid, PurposeDefault)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, policy.TTL)
+
+	_, err = service.purposePolicy(invalid, "payment")
+	assert.ErrorIs(t, err, ErrPurposeNotAllowed)
+
+	invalid.OTPPolicy.Purposes = map[string]PurposePolicy{"login": {}}
+	_, err = service.purposePolicy(invalid, "login")
+	require.NoError(t, err)
+	_, err = service.purposePolicy(invalid, PurposeDefault)
+	assert.ErrorIs(t, err, ErrPurposeNotAllowed)
+}
@@ -0,0 +1,23 @@
+package otp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// purposePattern keeps purposes short and safe to embed in Redis key names.
+var purposePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// normalizePurpose returns the purpose an OTP request is bound to, PurposeDefault when
+// it names none, or ErrInvalidPurpose.
+func normalizePurpose(purpose string) (string, error) {
+	purpose = strings.TrimSpace(purpose)
+	if purpose == "" {
+		return PurposeDefault, nil
+	}
+	if !purposePattern.MatchString(purpose) {
+		return "", fmt.Errorf("%w: must match %s", ErrInvalidPurpose, purposePattern)
+	}
+	return purpose, nil
+}
@@ -0,0 +1,215 @@
+package otp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePurpose(t *testing.T) {
+	tests := []struct {
+		purpose string
+		want    string
+		wantErr bool
+	}{
+		{purpose: "", want: PurposeDefault},
+		{purpose: "  ", want: PurposeDefault},
+		{purpose: "login", want: "login"},
+		{purpose: " password_reset ", want: "password_reset"},
+		{purpose: "payment2", want: "payment2"},
+		{purpose: "Login", wantErr: true},
+		{purpose: "2fa", wantErr: true},
+		{purpose: "pass:reset", wantErr: true},
+		{purpose: "a_purpose_name_longer_than_32_chars", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.purpose, func(t *testing.T) {
+			got, err := normalizePurpose(tt.purpose)
+
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidPurpose)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func purposeTenantSettings() *TenantSettings {
+	tenant := activeTenantSettings()
+	tenant.OTPPolicy = &Policy{Purposes: map[string]PurposePolicy{
+		"login":          {},
+		"password_reset": {TTL: 10 * time.Minute},
+	}}
+	return tenant
+}
+
+func TestServiceSendOTPWithPurpose(t *testing.T) {
+	store := &fakeOTPStore{}
+	limiter := &fakeSendRateLimiter{}
+	service := NewService(&fakeTenantProvider{settings: purposeTenantSettings()}, store, &fakeSMSProvider{}, nil, nil, Config{TTL: 2 * time.Minute})
+	service.SetSendRateLimiter(limiter)
+
+	resp, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567", Purpose: "password_reset"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "password_reset", store.reserved.Purpose)
+	assert.Equal(t, "password_reset", store.getPurpose)
+	assert.Equal(t, "password_reset", limiter.purpose)
+	assert.Equal(t, 10*time.Minute, store.ttl)
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), resp.ExpiredAt, time.Second)
+}
+
+func TestServiceSendOTPDefaultPurpose(t *testing.T) {
+	store := &fakeOTPStore{}
+	limiter := &fakeSendRateLimiter{}
+	service := NewService(&fakeTenantProvider{settings: activeTenantSettings()}, store, &fakeSMSProvider{}, nil, nil, Config{})
+	service.SetSendRateLimiter(limiter)
+
+	_, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	require.NoError(t, err)
+	assert.Equal(t, PurposeDefault, store.reserved.Purpose)
+	assert.Equal(t, PurposeDefault, limiter.purpose)
+}
+
+func TestServiceSendOTPPurposeNotAllowed(t *testing.T) {
+	store := &fakeOTPStore{}
+	smsProvider := &fakeSMSProvider{}
+	requestLogger := &fakeRequestLogger{}
+	limiter := &fakeSendRateLimiter{}
+	service := NewService(&fakeTenantProvider{settings: purposeTenantSettings()}, store, smsProvider, requestLogger, nil, Config{})
+	service.SetSendRateLimiter(limiter)
+
+	for _, purpose := range []string{"payment", ""} {
+		_, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567", Purpose: purpose})
+
+		assert.ErrorIs(t, err, ErrPurposeNotAllowed)
+	}
+	assert.Equal(t, 0, limiter.calls)
+	assert.Equal(t, 0, store.reserveCalls)
+	assert.Equal(t, 0, smsProvider.calls)
+	assert.Equal(t, 0, requestLogger.createCalls)
+}
+
+func TestServiceSendOTPInvalidPolicyAllowsOnlyDefaultPurpose(t *testing.T) {
+	settings := activeTenantSettings()
+	settings.OTPPolicy = &Policy{Invalid: true}
+	store := &fakeOTPStore{}
+	smsProvider := &fakeSMSProvider{}
+	service := NewService(&fakeTenantProvider{settings: settings}, store, smsProvider, nil, nil, Config{})
+
+	_, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567", Purpose: "payment"})
+
+	assert.ErrorIs(t, err, ErrPurposeNotAllowed)
+	assert.Equal(t, 0, store.reserveCalls)
+	assert.Equal(t, 0, smsProvider.calls)
+
+	_, err = service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	require.NoError(t, err)
+	assert.Equal(t, PurposeDefault, store.reserved.Purpose)
+}
+
+func TestServiceSendOTPPurposesAreIndependent(t *testing.T) {
+	state := activeOTPState("123456")
+	state.Purpose = "login"
+	store := &fakeOTPStore{state: state}
+	service := NewService(&fakeTenantProvider{settings: purposeTenantSettings()}, store, &fakeSMSProvider{}, nil, nil, Config{})
+
+	_, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567", Purpose: "password_reset"})
+	require.NoError(t, err)
+
+	_, err = service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567", Purpose: "password_reset"})
+	assert.ErrorIs(t, err, ErrOTPAlreadyActive)
+}
+
+func TestServiceVerifyOTPOtherPurpose(t *testing.T) {
+	state := activeOTPState("123456")
+	state.Purpose = "password_reset"
+	store := &fakeOTPStore{state: state}
+	verifyLogger := &fakeVerificationLogger{}
+	service := NewService(nil, store, nil, nil, verifyLogger, Config{})
+
+	resp, err := service.VerifyOTP(context.Background(), VerifyRequest{TenantID: 42, Phone: "+989121234567", Purpose: "login", Code: "123456"})
+
+	require.NoError(t, err)
+	assert.False(t, resp.Verified)
+	assert.Equal(t, ReasonNotFound, resp.Reason)
+	assert.Equal(t, "login", store.getPurpose)
+	assert.NotNil(t, store.state)
+	assert.Equal(t, 0, store.deleteCalls)
+
+	resp, err = service.VerifyOTP(context.Background(), VerifyRequest{TenantID: 42, Phone: "+989121234567", Purpose: "password_reset", Code: "123456"})
+
+	require.NoError(t, err)
+	assert.True(t, resp.Verified)
+	assert.Nil(t, store.state)
+}
+
+func TestServiceVerifyOTPInvalidPurpose(t *testing.T) {
+	store := &fakeOTPStore{state: activeOTPState("123456")}
+	service := NewService(nil, store, nil, nil, nil, Config{})
+
+	resp, err := service.VerifyOTP(context.Background(), VerifyRequest{TenantID: 42, Phone: "+989121234567", Purpose: "Login!", Code: "123456"})
+
+	require.Nil(t, resp)
+	assert.ErrorIs(t, err, ErrInvalidPurpose)
+	assert.Equal(t, 0, store.getCalls)
+}
+
+func TestServiceVerifyOTPByRequestIDOtherPurpose(t *testing.T) {
+	state := activeOTPState("123456")
+	state.Purpose = "payment"
+	store := &fakeOTPStore{state: state}
+	verifyLogger := &fakeVerificationLogger{}
+	service := NewService(nil, store, nil, nil, verifyLogger, Config{})
+
+	resp, err := service.VerifyOTPByRequestID(context.Background(), VerifyByRequestIDRequest{
+		TenantID:  42,
+		RequestID: "request-verify",
+		Code:      "123456",
+	})
+
+	require.NoError(t, err)
+	assert.False(t, resp.Verified)
+	assert.Equal(t, ReasonNotFound, resp.Reason)
+	assert.NotNil(t, store.state)
+	assert.Equal(t, 0, store.incrementCalls)
+	assert.Equal(t, 0, store.deleteCalls)
+	require.Len(t, verifyLogger.logs, 1)
+	assert.Equal(t, ReasonNotFound, verifyLogger.logs[0].Reason)
+
+	resp, err = service.VerifyOTPByRequestID(context.Background(), VerifyByRequestIDRequest{
+		TenantID:  42,
+		RequestID: "request-verify",
+		Purpose:   "payment",
+		Code:      "123456",
+	})
+
+	require.NoError(t, err)
+	assert.True(t, resp.Verified)
+}
+
+func TestServiceResendOTPUsesPurposeTTL(t *testing.T) {
+	state := sentOTPState("111111", time.Minute)
+	state.Purpose = "password_reset"
+	store := &fakeOTPStore{state: state}
+	service := newResendTestService(purposeTenantSettings(), store, &fakeSMSProvider{}, nil)
+
+	resp, err := service.ResendOTP(context.Background(), ResendRequest{TenantID: 42, Phone: "+989121234567", Purpose: "password_reset"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "password_reset", store.getPurpose)
+	assert.Equal(t, 10*time.Minute, store.ttl)
+	assert.Equal(t, "password_reset", store.resent.Purpose)
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), resp.ExpiredAt, time.Second)
+
+	_, err = service.ResendOTP(context.Background(), ResendRequest{TenantID: 42, Phone: "+989121234567", Purpose: "login"})
+	assert.ErrorIs(t, err, ErrOTPNotFound)
+}
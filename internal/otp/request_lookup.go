@@ -0,0 +1,120 @@
+package otp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// VerifyOTPByRequestID verifies the OTP issued under a send's request ID, so clients do
+// not have to send the phone again. A request ID that no longer names the phone's active
+// OTP, because it expired, was consumed or was replaced, or that was sent for another
+// purpose fails with not_found, and one whose OTP was cancelled fails with cancelled.
+func (s *Service) VerifyOTPByRequestID(ctx context.Context, req VerifyByRequestIDRequest) (*VerifyResponse, error) {
+	if err := validateVerifyByRequestIDRequest(req); err != nil {
+		return nil, err
+	}
+	purpose, err := normalizePurpose(req.Purpose)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := s.store.GetByRequestID(ctx, req.TenantID, req.RequestID)
+	if err != nil {
+		if errors.Is(err, ErrOTPNotFound) {
+			return s.verifyMissingRequest(ctx, req)
+		}
+		return nil, fmt.Errorf("get otp state by request id: %w", err)
+	}
+
+	verifyReq := VerifyRequest{TenantID: req.TenantID, Phone: state.Phone, Purpose: state.Purpose, Code: req.Code}
+	if state.Purpose != purpose {
+		// The request ID belongs to another flow, whose OTP must not be touched.
+		s.logVerification(ctx, verificationLog(verifyReq, state.RequestID, VerificationResultFailed, ReasonNotFound, 0))
+		return failedVerifyResponse("", ReasonNotFound), nil
+	}
+
+	if err := s.checkLockout(ctx, req.TenantID, state.Phone); err != nil {
+		return nil, err
+	}
+
+	return s.verifyState(ctx, verifyReq, state)
+}
+
+// verifyMissingRequest answers a verification of a request ID without an active OTP,
+// telling a cancelled OTP apart from one that expired, was consumed or was replaced.
+func (s *Service) verifyMissingRequest(ctx context.Context, req VerifyByRequestIDRequest) (*VerifyResponse, error) {
+	cancelled, err := s.store.IsCancelled(ctx, req.TenantID, req.RequestID)
+	if err != nil {
+		return nil, fmt.Errorf("get cancelled otp: %w", err)
+	}
+	reason, requestID := ReasonNotFound, ""
+	if cancelled {
+		reason, requestID = ReasonCancelled, req.RequestID
+	}
+	s.logVerification(ctx, OTPVerificationLog{
+		RequestID: req.RequestID,
+		TenantID:  req.TenantID,
+		Result:    VerificationResultFailed,
+		Reason:    reason,
+		CreatedAt: time.Now().UTC(),
+	})
+	return failedVerifyResponse(requestID, reason), nil
+}
+
+// GetOTP returns the status of the active OTP issued under requestID, or ErrOTPNotFound
+// once it has expired, been consumed or been replaced.
+func (s *Service) GetOTP(ctx context.Context, tenantID int64, requestID string) (*OTPStatus, error) {
+	if tenantID <= 0 {
+		return nil, fmt.Errorf("tenant_id must be greater than 0")
+	}
+	if strings.TrimSpace(requestID) == "" {
+		return nil, fmt.Errorf("request_id must not be empty")
+	}
+
+	state, err := s.store.GetByRequestID(ctx, tenantID, requestID)
+	if err != nil {
+		if errors.Is(err, ErrOTPNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("get otp state by request id: %w", err)
+	}
+	if !time.Now().UTC().Before(state.ExpiresAt) {
+		return nil, ErrOTPNotFound
+	}
+
+	maxAttempts := state.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = s.fallbackMaxAttempts(ctx, tenantID)
+	}
+	lastSentAt := state.LastSentAt
+	if lastSentAt.IsZero() {
+		lastSentAt = state.CreatedAt
+	}
+	return &OTPStatus{
+		RequestID:         state.RequestID,
+		Purpose:           state.Purpose,
+		AttemptCount:      state.AttemptCount,
+		MaxAttempts:       maxAttempts,
+		RemainingAttempts: max(maxAttempts-state.AttemptCount, 0),
+		ResendCount:       state.ResendCount,
+		CreatedAt:         state.CreatedAt,
+		LastSentAt:        lastSentAt,
+		ExpiredAt:         state.ExpiresAt,
+	}, nil
+}
+
+func validateVerifyByRequestIDRequest(req VerifyByRequestIDRequest) error {
+	if req.TenantID <= 0 {
+		return fmt.Errorf("tenant_id must be greater than 0")
+	}
+	if strings.TrimSpace(req.RequestID) == "" {
+		return fmt.Errorf("request_id must not be empty")
+	}
+	if strings.TrimSpace(req.Code) == "" {
+		return fmt.Errorf("code must not be empty")
+	}
+	return nil
+}
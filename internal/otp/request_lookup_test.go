@@ -0,0 +1,182 @@
+package otp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceVerifyOTPByRequestIDSuccess(t *testing.T) {
+	store := &fakeOTPStore{state: activeOTPState("123456")}
+	verifyLogger := &fakeVerificationLogger{}
+	lockout := &fakePhoneLockout{}
+	service := NewService(nil, store, nil, nil, verifyLogger, Config{})
+	service.SetPhoneLockout(lockout)
+
+	resp, err := service.VerifyOTPByRequestID(context.Background(), VerifyByRequestIDRequest{
+		TenantID:  42,
+		RequestID: "request-verify",
+		Code:      "123456",
+	})
+
+	require.NoError(t, err)
+	assert.True(t, resp.Verified)
+	assert.Equal(t, "request-verify", resp.RequestID)
+	assert.Equal(t, "request-verify", store.getRequestID)
+	assert.Equal(t, 1, store.deleteCalls)
+	assert.Nil(t, store.state)
+	assert.Equal(t, 1, lockout.checkCalls)
+	assert.Equal(t, 1, lockout.resetCalls)
+	assertVerificationLog(t, verifyLogger, VerificationResultSuccess, ReasonVerified, "request-verify", 0)
+	assert.Equal(t, "+989121234567", verifyLogger.logs[0].Phone)
+}
+
+func TestServiceVerifyOTPByRequestIDStaleRequestID(t *testing.T) {
+	// The phone's OTP was replaced by a newer send with the same code.
+	store := &fakeOTPStore{state: activeOTPState("123456")}
+	verifyLogger := &fakeVerificationLogger{}
+	service := NewService(nil, store, nil, nil, verifyLogger, Config{})
+
+	resp, err := service.VerifyOTPByRequestID(context.Background(), VerifyByRequestIDRequest{
+		TenantID:  42,
+		RequestID: "request-old",
+		Code:      "123456",
+	})
+
+	require.NoError(t, err)
+	assert.False(t, resp.Verified)
+	assert.Equal(t, ReasonNotFound, resp.Reason)
+	assert.Empty(t, resp.RequestID)
+	assert.NotNil(t, store.state)
+	assert.Equal(t, 0, store.deleteCalls)
+	assert.Equal(t, 0, store.incrementCalls)
+	require.Len(t, verifyLogger.logs, 1)
+	assert.Equal(t, "request-old", verifyLogger.logs[0].RequestID)
+	assert.Equal(t, ReasonNotFound, verifyLogger.logs[0].Reason)
+	assert.Empty(t, verifyLogger.logs[0].Phone)
+}
+
+func TestServiceVerifyOTPByRequestIDInvalidCode(t *testing.T) {
+	store := &fakeOTPStore{state: activeOTPState("123456")}
+	verifyLogger := &fakeVerificationLogger{}
+	lockout := &fakePhoneLockout{}
+	service := NewService(nil, store, nil, nil, verifyLogger, Config{})
+	service.SetPhoneLockout(lockout)
+
+	resp, err := service.VerifyOTPByRequestID(context.Background(), VerifyByRequestIDRequest{
+		TenantID:  42,
+		RequestID: "request-verify",
+		Code:      "000000",
+	})
+
+	require.NoError(t, err)
+	assert.False(t, resp.Verified)
+	assert.Equal(t, ReasonInvalidCode, resp.Reason)
+	assert.Equal(t, 1, store.state.AttemptCount)
+	assert.Equal(t, 1, lockout.failureCalls)
+	assertVerificationLog(t, verifyLogger, VerificationResultFailed, ReasonInvalidCode, "request-verify", 1)
+}
+
+func TestServiceVerifyOTPByRequestIDPhoneLocked(t *testing.T) {
+	store := &fakeOTPStore{state: activeOTPState("123456")}
+	service := NewService(nil, store, nil, nil, nil, Config{})
+	service.SetPhoneLockout(&fakePhoneLockout{checkErr: &LimitError{Err: ErrPhoneLocked, Limit: 10, ResetAfter: time.Hour}})
+
+	resp, err := service.VerifyOTPByRequestID(context.Background(), VerifyByRequestIDRequest{
+		TenantID:  42,
+		RequestID: "request-verify",
+		Code:      "123456",
+	})
+
+	require.Nil(t, resp)
+	require.ErrorIs(t, err, ErrPhoneLocked)
+	assert.Equal(t, 0, store.deleteCalls)
+}
+
+func TestServiceVerifyOTPByRequestIDInvalidRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		req  VerifyByRequestIDRequest
+	}{
+		{name: "missing tenant", req: VerifyByRequestIDRequest{RequestID: "request-verify", Code: "123456"}},
+		{name: "missing request id", req: VerifyByRequestIDRequest{TenantID: 42, RequestID: " ", Code: "123456"}},
+		{name: "missing code", req: VerifyByRequestIDRequest{TenantID: 42, RequestID: "request-verify"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeOTPStore{state: activeOTPState("123456")}
+			service := NewService(nil, store, nil, nil, nil, Config{})
+
+			resp, err := service.VerifyOTPByRequestID(context.Background(), tt.req)
+
+			require.Nil(t, resp)
+			require.Error(t, err)
+			assert.Equal(t, 0, store.getCalls)
+		})
+	}
+}
+
+func TestServiceVerifyOTPConsumedConcurrently(t *testing.T) {
+	store := &fakeOTPStore{state: activeOTPState("123456"), deleteErr: ErrOTPNotFound}
+	verifyLogger := &fakeVerificationLogger{}
+	service := NewService(nil, store, nil, nil, verifyLogger, Config{})
+
+	resp, err := service.VerifyOTP(context.Background(), VerifyRequest{TenantID: 42, Phone: "+989121234567", Code: "123456"})
+
+	require.NoError(t, err)
+	assert.False(t, resp.Verified)
+	assert.Equal(t, ReasonNotFound, resp.Reason)
+	assertVerificationLog(t, verifyLogger, VerificationResultFailed, ReasonNotFound, "request-verify", 0)
+}
+
+func TestServiceGetOTP(t *testing.T) {
+	state := activeOTPState("123456")
+	state.AttemptCount = 1
+	state.ResendCount = 2
+	state.LastSentAt = state.CreatedAt.Add(30 * time.Second)
+	service := NewService(nil, &fakeOTPStore{state: state}, nil, nil, nil, Config{})
+
+	status, err := service.GetOTP(context.Background(), 42, "request-verify")
+
+	require.NoError(t, err)
+	assert.Equal(t, &OTPStatus{
+		RequestID:         "request-verify",
+		Purpose:           PurposeDefault,
+		AttemptCount:      1,
+		MaxAttempts:       3,
+		RemainingAttempts: 2,
+		ResendCount:       2,
+		CreatedAt:         state.CreatedAt,
+		LastSentAt:        state.LastSentAt,
+		ExpiredAt:         state.ExpiresAt,
+	}, status)
+}
+
+func TestServiceGetOTPNotFound(t *testing.T) {
+	expired := activeOTPState("123456")
+	expired.ExpiresAt = time.Now().UTC().Add(-time.Second)
+	tests := []struct {
+		name      string
+		state     *OTPState
+		requestID string
+	}{
+		{name: "missing", requestID: "request-verify"},
+		{name: "stale request id", state: activeOTPState("123456"), requestID: "request-old"},
+		{name: "expired", state: expired, requestID: "request-verify"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewService(nil, &fakeOTPStore{state: tt.state}, nil, nil, nil, Config{})
+
+			status, err := service.GetOTP(context.Background(), 42, tt.requestID)
+
+			assert.Nil(t, status)
+			assert.ErrorIs(t, err, ErrOTPNotFound)
+		})
+	}
+}
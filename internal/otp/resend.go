@@ -0,0 +1,162 @@
+package otp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ResendOTP re-delivers the active OTP of a phone for a purpose, for users whose SMS never
+// arrived.
+// It keeps the request ID and attempt count, and either rotates the code or re-sends the
+// same one as the tenant policy says. Resends are spaced by the policy cooldown, capped
+// per OTP, restart the OTP TTL and count against the send rate limits. A resend that
+// cannot be delivered is reverted, so the code the user already holds stays valid.
+func (s *Service) ResendOTP(ctx context.Context, req ResendRequest) (*SendResponse, error) {
+	if req.TenantID <= 0 {
+		return nil, fmt.Errorf("tenant_id must be greater than 0")
+	}
+	if strings.TrimSpace(req.Phone) == "" {
+		return nil, fmt.Errorf("phone must not be empty")
+	}
+	purpose, err := normalizePurpose(req.Purpose)
+	if err != nil {
+		return nil, err
+	}
+	req.Purpose = purpose
+
+	tenant, err := s.tenantSettings.GetTenantSettings(ctx, req.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateTenant(tenant, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	policy, err := s.purposePolicy(tenant, req.Purpose)
+	if err != nil {
+		return nil, err
+	}
+	req.Phone, err = s.phones.Normalize(req.Phone, s.phones.Region(tenant))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkLockout(ctx, req.TenantID, req.Phone); err != nil {
+		return nil, err
+	}
+
+	state, err := s.store.Get(ctx, req.TenantID, req.Phone, req.Purpose)
+	if err != nil {
+		if errors.Is(err, ErrOTPNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("get otp state: %w", err)
+	}
+	now := time.Now().UTC()
+	if state == nil || !now.Before(state.ExpiresAt) {
+		return nil, ErrOTPNotFound
+	}
+
+	if err := checkResendAllowed(state, policy, now); err != nil {
+		return nil, err
+	}
+
+	sendReq := SendRequest{TenantID: req.TenantID, Phone: req.Phone, Purpose: req.Purpose, Metadata: req.Metadata}
+	if err := s.allowTenantSend(ctx, sendReq, tenant); err != nil {
+		return nil, err
+	}
+	if err := s.allowSend(ctx, sendReq, tenant.SMSProvider); err != nil {
+		return nil, err
+	}
+
+	code, resent, err := s.resendCode(state, policy)
+	if err != nil {
+		return nil, err
+	}
+	resent.ResendCount = state.ResendCount + 1
+	resent.LastSentAt = now
+	resent.ExpiresAt = now.Add(policy.TTL)
+	if err := s.store.Resend(ctx, resent, policy.TTL); err != nil {
+		if errors.Is(err, ErrOTPResendCooldown) {
+			// A concurrent resend just went out, so the full cooldown applies.
+			return nil, &LimitError{Err: ErrOTPResendCooldown, Limit: 1, ResetAfter: policy.ResendCooldown}
+		}
+		if errors.Is(err, ErrOTPNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("store otp resend: %w", err)
+	}
+
+	if err := s.deliverAndRecord(ctx, tenant, SMSRequest{
+		RequestID:      state.RequestID,
+		TenantID:       req.TenantID,
+		Phone:          req.Phone,
+		Code:           code,
+		Template:       tenant.SMSTemplate,
+		TemplateParams: tenant.SMSTemplateParams,
+		Sender:         tenant.SMSSender,
+		Metadata:       req.Metadata,
+	}, resent.ResendCount); err != nil {
+		// The caller may be gone, but the previous code must still be put back.
+		revertErr := s.store.RevertResend(context.WithoutCancel(ctx), *state)
+		if revertErr != nil && !errors.Is(revertErr, ErrOTPNotFound) && !errors.Is(revertErr, ErrOTPResendCooldown) {
+			return nil, fmt.Errorf("%w; revert otp resend: %w", err, revertErr)
+		}
+		return nil, err
+	}
+
+	return &SendResponse{
+		RequestID:   state.RequestID,
+		ExpiredAt:   resent.ExpiresAt,
+		ResendCount: resent.ResendCount,
+	}, nil
+}
+
+// checkResendAllowed enforces the resend cap and the cooldown since the last delivery.
+// States written before resends existed count from their creation.
+func checkResendAllowed(state *OTPState, policy Policy, now time.Time) error {
+	if state.ResendCount >= policy.MaxResends {
+		return &LimitError{Err: ErrOTPResendLimit, Limit: policy.MaxResends, ResetAfter: state.ExpiresAt.Sub(now)}
+	}
+	lastSentAt := state.LastSentAt
+	if lastSentAt.IsZero() {
+		lastSentAt = state.CreatedAt
+	}
+	if wait := lastSentAt.Add(policy.ResendCooldown).Sub(now); wait > 0 {
+		return &LimitError{Err: ErrOTPResendCooldown, Limit: 1, ResetAfter: wait}
+	}
+	return nil
+}
+
+// resendCode returns the code to deliver and a copy of state carrying it. The sealed
+// code is re-sent when the policy asks for the same code; otherwise, or when the OTP
+// has no readable sealed code, a new code replaces the old one.
+func (s *Service) resendCode(state *OTPState, policy Policy) (string, OTPState, error) {
+	resent := *state
+	if policy.ResendMode == ResendModeSame && state.SealedCode != "" && s.codeSealer != nil {
+		if code, err := s.codeSealer.Open(state.SealedCode, state.RequestID); err == nil {
+			return code, resent, nil
+		}
+	}
+
+	code, err := GenerateCode(policy.CodeLength)
+	if err != nil {
+		return "", OTPState{}, err
+	}
+	resent.CodeHash, resent.CodeHashKeyID = s.codeHasher.Hash(code)
+	resent.SealedCode, err = s.sealCode(policy, code, state.RequestID)
+	if err != nil {
+		return "", OTPState{}, err
+	}
+	return code, resent, nil
+}
+
+// sealCode encrypts code for tenants that resend the same code.
+func (s *Service) sealCode(policy Policy, code string, requestID string) (string, error) {
+	if policy.ResendMode != ResendModeSame {
+		return "", nil
+	}
+	return s.codeSealer.Seal(code, requestID)
+}
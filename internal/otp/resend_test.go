@@ -0,0 +1,236 @@
+package otp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sentOTPState returns an active OTP whose code was last delivered sentAgo ago.
+func sentOTPState(code string, sentAgo time.Duration) *OTPState {
+	state := activeOTPState(code)
+	state.AttemptCount = 1
+	state.LastSentAt = time.Now().UTC().Add(-sentAgo)
+	return state
+}
+
+func newResendTestService(tenant *TenantSettings, store *fakeOTPStore, smsProvider *fakeSMSProvider, requestLogger OTPRequestLogger) *Service {
+	return NewService(&fakeTenantProvider{settings: tenant}, store, smsProvider, requestLogger, nil, Config{
+		CodeLength:     6,
+		TTL:            2 * time.Minute,
+		MaxAttempts:    3,
+		ResendCooldown: 30 * time.Second,
+		MaxResends:     2,
+	})
+}
+
+func TestServiceResendOTPRotatesCode(t *testing.T) {
+	store := &fakeOTPStore{state: sentOTPState("111111", time.Minute)}
+	smsProvider := &fakeSMSProvider{}
+	requestLogger := &fakeRequestLogger{}
+	service := newResendTestService(activeTenantSettings(), store, smsProvider, requestLogger)
+
+	resp, err := service.ResendOTP(context.Background(), ResendRequest{TenantID: 42, Phone: "+98 912 123 4567"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "request-verify", resp.RequestID)
+	assert.Equal(t, 1, resp.ResendCount)
+	assert.Equal(t, 1, store.resendCalls)
+	assert.Equal(t, 1, store.resent.ResendCount)
+	assert.Equal(t, 1, store.state.AttemptCount)
+	assert.Equal(t, 2*time.Minute, store.ttl)
+	assert.True(t, resp.ExpiredAt.Equal(store.resent.ExpiresAt))
+	assert.Equal(t, "request-verify", smsProvider.req.RequestID)
+	assert.Equal(t, "+989121234567", smsProvider.req.Phone)
+	assert.NotEqual(t, "111111", smsProvider.req.Code)
+	assert.True(t, VerifyCode(smsProvider.req.Code, store.resent.CodeHash))
+	assert.Empty(t, store.resent.SealedCode)
+	assert.Equal(t, 0, requestLogger.createCalls)
+	require.Len(t, requestLogger.updateLogs, 1)
+	assert.Equal(t, RequestStatusSent, requestLogger.updateLogs[0].Status)
+	assert.Equal(t, 1, requestLogger.updateLogs[0].ResendCount)
+}
+
+func TestServiceResendOTPSameCode(t *testing.T) {
+	sealer, err := NewCodeSealer([]byte("0123456789abcdef"))
+	require.NoError(t, err)
+	tenant := activeTenantSettings()
+	tenant.OTPPolicy = &Policy{ResendMode: ResendModeSame}
+	store := &fakeOTPStore{}
+	smsProvider := &fakeSMSProvider{}
+	service := newResendTestService(tenant, store, smsProvider, nil)
+	service.SetCodeSealer(sealer)
+
+	_, err = service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+	require.NoError(t, err)
+	code := smsProvider.req.Code
+	require.NotEmpty(t, store.reserved.SealedCode)
+	store.state.LastSentAt = store.state.LastSentAt.Add(-time.Minute)
+
+	_, err = service.ResendOTP(context.Background(), ResendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	require.NoError(t, err)
+	assert.Equal(t, code, smsProvider.req.Code)
+	assert.Equal(t, store.reserved.CodeHash, store.resent.CodeHash)
+	assert.Equal(t, 2, smsProvider.calls)
+}
+
+func TestServiceResendOTPSameCodeWithoutSealerRotates(t *testing.T) {
+	tenant := activeTenantSettings()
+	tenant.OTPPolicy = &Policy{ResendMode: ResendModeSame}
+	store := &fakeOTPStore{state: sentOTPState("111111", time.Minute)}
+	smsProvider := &fakeSMSProvider{}
+	service := newResendTestService(tenant, store, smsProvider, nil)
+
+	_, err := service.ResendOTP(context.Background(), ResendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	require.NoError(t, err)
+	assert.NotEqual(t, "111111", smsProvider.req.Code)
+	assert.True(t, VerifyCode(smsProvider.req.Code, store.resent.CodeHash))
+}
+
+func TestServiceResendOTPCooldown(t *testing.T) {
+	store := &fakeOTPStore{state: sentOTPState("111111", 10*time.Second)}
+	smsProvider := &fakeSMSProvider{}
+	service := newResendTestService(activeTenantSettings(), store, smsProvider, nil)
+
+	_, err := service.ResendOTP(context.Background(), ResendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	require.ErrorIs(t, err, ErrOTPResendCooldown)
+	var limitErr *LimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.InDelta(t, 20*time.Second, limitErr.ResetAfter, float64(time.Second))
+	assert.Equal(t, 0, store.resendCalls)
+	assert.Equal(t, 0, smsProvider.calls)
+}
+
+func TestServiceResendOTPLimit(t *testing.T) {
+	state := sentOTPState("111111", time.Minute)
+	state.ResendCount = 2
+	store := &fakeOTPStore{state: state}
+	smsProvider := &fakeSMSProvider{}
+	service := newResendTestService(activeTenantSettings(), store, smsProvider, nil)
+
+	_, err := service.ResendOTP(context.Background(), ResendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	require.ErrorIs(t, err, ErrOTPResendLimit)
+	var limitErr *LimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, 2, limitErr.Limit)
+	assert.Equal(t, 0, smsProvider.calls)
+}
+
+func TestServiceResendOTPNoActiveOTP(t *testing.T) {
+	expired := sentOTPState("111111", time.Minute)
+	expired.ExpiresAt = time.Now().UTC().Add(-time.Second)
+	tests := []struct {
+		name  string
+		store *fakeOTPStore
+	}{
+		{name: "missing", store: &fakeOTPStore{getErr: ErrOTPNotFound}},
+		{name: "expired", store: &fakeOTPStore{state: expired}},
+		{name: "replaced concurrently", store: &fakeOTPStore{state: sentOTPState("111111", time.Minute), resendErr: ErrOTPNotFound}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			smsProvider := &fakeSMSProvider{}
+			service := newResendTestService(activeTenantSettings(), tt.store, smsProvider, nil)
+
+			_, err := service.ResendOTP(context.Background(), ResendRequest{TenantID: 42, Phone: "+989121234567"})
+
+			assert.ErrorIs(t, err, ErrOTPNotFound)
+			assert.Equal(t, 0, smsProvider.calls)
+		})
+	}
+}
+
+func TestServiceResendOTPConcurrentResend(t *testing.T) {
+	store := &fakeOTPStore{state: sentOTPState("111111", time.Minute), resendErr: ErrOTPResendCooldown}
+	smsProvider := &fakeSMSProvider{}
+	service := newResendTestService(activeTenantSettings(), store, smsProvider, nil)
+
+	_, err := service.ResendOTP(context.Background(), ResendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	require.ErrorIs(t, err, ErrOTPResendCooldown)
+	var limitErr *LimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, 30*time.Second, limitErr.ResetAfter)
+	assert.Equal(t, 0, smsProvider.calls)
+}
+
+func TestServiceResendOTPRateLimited(t *testing.T) {
+	store := &fakeOTPStore{state: sentOTPState("111111", time.Minute)}
+	smsProvider := &fakeSMSProvider{}
+	service := newResendTestService(activeTenantSettings(), store, smsProvider, &fakeRequestLogger{})
+	service.SetSendRateLimiter(&fakeSendRateLimiter{err: &LimitError{Err: ErrOTPRateLimited, Limit: 3}})
+
+	_, err := service.ResendOTP(context.Background(), ResendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	assert.ErrorIs(t, err, ErrOTPRateLimited)
+	assert.Equal(t, 0, store.resendCalls)
+	assert.Equal(t, 0, smsProvider.calls)
+}
+
+func TestServiceResendOTPDeliveryFailure(t *testing.T) {
+	store := &fakeOTPStore{state: sentOTPState("111111", time.Minute)}
+	smsProvider := &fakeSMSProvider{err: errors.New("provider failed")}
+	requestLogger := &fakeRequestLogger{}
+	service := newResendTestService(activeTenantSettings(), store, smsProvider, requestLogger)
+
+	_, err := service.ResendOTP(context.Background(), ResendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	require.ErrorIs(t, err, ErrSMSProviderFailed)
+	assert.Equal(t, 1, store.resendCalls)
+	assert.Equal(t, 1, store.revertCalls)
+	// The code the user already holds is valid again, with the resend not counted.
+	assert.True(t, VerifyCode("111111", store.state.CodeHash))
+	assert.Equal(t, 0, store.state.ResendCount)
+	assert.Equal(t, 1, store.state.AttemptCount)
+	// The original delivery still stands, so the request log is not marked failed.
+	assert.Empty(t, requestLogger.updateLogs)
+
+	// The user can resend again once the original cooldown is over.
+	smsProvider.err = nil
+	resp, err := service.ResendOTP(context.Background(), ResendRequest{TenantID: 42, Phone: "+989121234567"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, resp.ResendCount)
+}
+
+func TestServiceResendOTPDeliveryFailureRevertError(t *testing.T) {
+	revertErr := errors.New("redis down")
+	store := &fakeOTPStore{state: sentOTPState("111111", time.Minute), revertErr: revertErr}
+	service := newResendTestService(activeTenantSettings(), store, &fakeSMSProvider{err: errors.New("provider failed")}, nil)
+
+	_, err := service.ResendOTP(context.Background(), ResendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	assert.ErrorIs(t, err, ErrSMSProviderFailed)
+	assert.ErrorIs(t, err, revertErr)
+}
+
+func TestServiceResendOTPPhoneLocked(t *testing.T) {
+	store := &fakeOTPStore{state: sentOTPState("111111", time.Minute)}
+	service := newResendTestService(activeTenantSettings(), store, &fakeSMSProvider{}, nil)
+	service.SetPhoneLockout(&fakePhoneLockout{checkErr: &LimitError{Err: ErrPhoneLocked}})
+
+	_, err := service.ResendOTP(context.Background(), ResendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	assert.ErrorIs(t, err, ErrPhoneLocked)
+	assert.Equal(t, 0, store.getCalls)
+}
+
+func TestServiceResendOTPLegacyStateCountsFromCreation(t *testing.T) {
+	state := sentOTPState("111111", 10*time.Second)
+	state.CreatedAt = state.LastSentAt
+	state.LastSentAt = time.Time{}
+	store := &fakeOTPStore{state: state}
+	service := newResendTestService(activeTenantSettings(), store, &fakeSMSProvider{}, nil)
+
+	_, err := service.ResendOTP(context.Background(), ResendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	assert.ErrorIs(t, err, ErrOTPResendCooldown)
+}
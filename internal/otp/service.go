@@ -16,6 +16,12 @@ type Service struct {
 	store          OTPStore
 	smsProvider    SMSProvider
 	sendLimiter    SendRateLimiter
+	phoneLimiter   GlobalPhoneRateLimiter
+	tenantLimiter  TenantSendRateLimiter
+	lockout        PhoneLockout
+	codeHasher     *CodeHasher
+	codeSealer     *CodeSealer
+	phones         *PhoneNormalizer
 	requestLogger  OTPRequestLogger
 	verifyLogger   OTPVerificationLogger
 	config         Config
@@ -38,6 +44,7 @@ func NewService(
 		smsProvider:    smsProvider,
 		requestLogger:  requestLogger,
 		verifyLogger:   verifyLogger,
+		phones:         &PhoneNormalizer{},
 		config:         config,
 	}
 }
@@ -47,11 +54,51 @@ func (s *Service) SetSendRateLimiter(limiter SendRateLimiter) {
 	s.sendLimiter = limiter
 }
 
+// SetGlobalPhoneRateLimiter configures an optional per-phone limiter shared by all
+// tenants, checked after the tenant-scoped send rate limiter.
+func (s *Service) SetGlobalPhoneRateLimiter(limiter GlobalPhoneRateLimiter) {
+	s.phoneLimiter = limiter
+}
+
+// SetTenantSendRateLimiter configures an optional limiter enforcing each tenant's
+// rate_limit_per_min across all phones.
+func (s *Service) SetTenantSendRateLimiter(limiter TenantSendRateLimiter) {
+	s.tenantLimiter = limiter
+}
+
+// SetPhoneLockout configures optional brute-force protection spanning OTP lifetimes.
+func (s *Service) SetPhoneLockout(lockout PhoneLockout) {
+	s.lockout = lockout
+}
+
+// SetPhoneNormalizer configures the default phone region for tenants without one.
+// Without it, such tenants must send numbers in international format.
+func (s *Service) SetPhoneNormalizer(normalizer *PhoneNormalizer) {
+	s.phones = normalizer
+}
+
+// SetCodeHasher configures the keyed hasher for OTP codes. Without it, codes are
+// hashed with the legacy unkeyed SHA-256 format.
+func (s *Service) SetCodeHasher(hasher *CodeHasher) {
+	s.codeHasher = hasher
+}
+
+// SetCodeSealer configures encryption of codes for tenants whose resends re-deliver the
+// same code. Without it, resends always rotate the code.
+func (s *Service) SetCodeSealer(sealer *CodeSealer) {
+	s.codeSealer = sealer
+}
+
 // SendOTP will orchestrate tenant lookup, OTP storage, provider send, and logging.
 func (s *Service) SendOTP(ctx context.Context, req SendRequest) (*SendResponse, error) {
 	if err := validateSendRequest(req); err != nil {
 		return nil, err
 	}
+	purpose, err := normalizePurpose(req.Purpose)
+	if err != nil {
+		return nil, err
+	}
+	req.Purpose = purpose
 
 	tenant, err := s.tenantSettings.GetTenantSettings(ctx, req.TenantID)
 	if err != nil {
@@ -60,32 +107,58 @@ func (s *Service) SendOTP(ctx context.Context, req SendRequest) (*SendResponse,
 	if err := validateTenant(tenant, time.Now().UTC()); err != nil {
 		return nil, err
 	}
+	policy, err := s.purposePolicy(tenant, req.Purpose)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every key, limiter and log below uses the canonical E.164 phone.
+	req.Phone, err = s.phones.Normalize(req.Phone, s.phones.Region(tenant))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkLockout(ctx, req.TenantID, req.Phone); err != nil {
+		return nil, err
+	}
 
-	if err := s.preventActiveResend(ctx, req.TenantID, req.Phone, time.Now().UTC()); err != nil {
+	if err := s.preventActiveResend(ctx, req.TenantID, req.Phone, req.Purpose, time.Now().UTC()); err != nil {
 		return nil, err
 	}
 
-	if err := s.allowSend(ctx, req.TenantID, req.Phone); err != nil {
+	if err := s.allowTenantSend(ctx, req, tenant); err != nil {
+		return nil, err
+	}
+	if err := s.allowSend(ctx, req, tenant.SMSProvider); err != nil {
 		return nil, err
 	}
 
 	requestID := uuid.NewString()
-	code, err := GenerateCode(s.config.CodeLength)
+	code, err := GenerateCode(policy.CodeLength)
 	if err != nil {
 		return nil, err
 	}
 
+	codeHash, codeHashKeyID := s.codeHasher.Hash(code)
+	sealedCode, err := s.sealCode(policy, code, requestID)
+	if err != nil {
+		return nil, err
+	}
 	now := time.Now().UTC()
-	expiredAt := now.Add(s.config.TTL)
+	expiredAt := now.Add(policy.TTL)
 	state := OTPState{
-		RequestID:    requestID,
-		TenantID:     req.TenantID,
-		Phone:        req.Phone,
-		CodeHash:     HashCode(code),
-		AttemptCount: 0,
-		MaxAttempts:  s.config.MaxAttempts,
-		CreatedAt:    now,
-		ExpiresAt:    expiredAt,
+		RequestID:     requestID,
+		TenantID:      req.TenantID,
+		Phone:         req.Phone,
+		Purpose:       req.Purpose,
+		CodeHash:      codeHash,
+		CodeHashKeyID: codeHashKeyID,
+		SealedCode:    sealedCode,
+		AttemptCount:  0,
+		MaxAttempts:   policy.MaxAttempts,
+		CreatedAt:     now,
+		LastSentAt:    now,
+		ExpiresAt:     expiredAt,
 	}
 
 	if s.requestLogger != nil {
@@ -104,54 +177,77 @@ func (s *Service) SendOTP(ctx context.Context, req SendRequest) (*SendResponse,
 		}
 	}
 
-	if err := s.store.Save(ctx, state, s.config.TTL); err != nil {
-		saveErr := fmt.Errorf("save otp state: %w", err)
+	if err := s.store.Reserve(ctx, state, policy.TTL); err != nil {
+		reserveErr := fmt.Errorf("reserve otp state: %w", err)
 		s.updateProviderResult(ctx, OTPProviderResultLog{
 			RequestID:    requestID,
 			Status:       RequestStatusFailed,
 			ProviderName: tenant.SMSProvider,
-			ErrorMessage: saveErr.Error(),
+			ErrorMessage: reserveErr.Error(),
 			UpdatedAt:    time.Now().UTC(),
 		})
-		return nil, fmt.Errorf("save otp state: %w", err)
+		if errors.Is(err, ErrOTPAlreadyActive) {
+			// Another send reserved the phone just now, so its OTP lives about one TTL.
+			return nil, &LimitError{Err: ErrOTPAlreadyActive, Limit: 1, ResetAfter: policy.TTL}
+		}
+		return nil, reserveErr
+	}
+
+	if err := s.deliverAndRecord(ctx, tenant, SMSRequest{
+		RequestID:      requestID,
+		TenantID:       req.TenantID,
+		Phone:          req.Phone,
+		Code:           code,
+		Template:       tenant.SMSTemplate,
+		TemplateParams: tenant.SMSTemplateParams,
+		Sender:         tenant.SMSSender,
+		Metadata:       req.Metadata,
+	}, 0); err != nil {
+		return nil, err
 	}
 
-	providerCtx, cancel := context.WithTimeout(ctx, s.config.ProviderTimeout)
-	defer cancel()
-
-	result, err := s.smsProvider.SendOTP(providerCtx, SMSRequest{
+	return &SendResponse{
 		RequestID: requestID,
-		TenantID:  req.TenantID,
-		Phone:     req.Phone,
-		Code:      code,
-		Provider:  tenant.SMSProvider,
-		Metadata:  req.Metadata,
-	})
+		ExpiredAt: expiredAt,
+	}, nil
+}
+
+// deliverAndRecord sends req through the tenant's providers and records the outcome on
+// the request log. resendCount is 0 for the first delivery of an OTP. A failed resend
+// leaves the log alone, since the OTP's earlier delivery still stands.
+func (s *Service) deliverAndRecord(ctx context.Context, tenant *TenantSettings, req SMSRequest, resendCount int) error {
+	delivery, err := s.deliver(ctx, tenant, req)
+	if err != nil && resendCount > 0 {
+		return fmt.Errorf("%w: %w", ErrSMSProviderFailed, err)
+	}
 	if err != nil {
+		providerResponse := smsProviderErrorResponse(err)
+		if providerResponse == nil {
+			providerResponse = map[string]interface{}{}
+		}
+		providerResponse["attempts"] = delivery.attempts
 		s.updateProviderResult(ctx, OTPProviderResultLog{
-			RequestID:    requestID,
-			Status:       RequestStatusFailed,
-			ProviderName: tenant.SMSProvider,
-			ErrorMessage: err.Error(),
-			UpdatedAt:    time.Now().UTC(),
+			RequestID:        req.RequestID,
+			Status:           RequestStatusFailed,
+			ProviderName:     delivery.provider,
+			ProviderResponse: providerResponse,
+			ErrorMessage:     err.Error(),
+			ResendCount:      resendCount,
+			UpdatedAt:        time.Now().UTC(),
 		})
-		return nil, fmt.Errorf("%w: %w", ErrSMSProviderFailed, err)
+		return fmt.Errorf("%w: %w", ErrSMSProviderFailed, err)
 	}
 
-	if err := s.updateProviderResult(ctx, OTPProviderResultLog{
-		RequestID:        requestID,
+	providerResponse := smsProviderResponse(delivery.result)
+	providerResponse["attempts"] = delivery.attempts
+	return s.updateProviderResult(ctx, OTPProviderResultLog{
+		RequestID:        req.RequestID,
 		Status:           RequestStatusSent,
-		ProviderName:     tenant.SMSProvider,
-		ProviderResponse: smsProviderResponse(result),
+		ProviderName:     delivery.provider,
+		ProviderResponse: providerResponse,
+		ResendCount:      resendCount,
 		UpdatedAt:        time.Now().UTC(),
-	}); err != nil {
-		return nil, err
-	}
-
-	return &SendResponse{
-		RequestID: requestID,
-		ExpiredAt: expiredAt,
-	}, nil
+	})
 }
 
 // VerifyOTP will orchestrate Redis state lookup, attempt tracking, and verification logging.
@@ -159,35 +255,57 @@ func (s *Service) VerifyOTP(ctx context.Context, req VerifyRequest) (*VerifyResp
 	if err := validateVerifyRequest(req); err != nil {
 		return nil, err
 	}
+	purpose, err := normalizePurpose(req.Purpose)
+	if err != nil {
+		return nil, err
+	}
+	req.Purpose = purpose
 
-	state, err := s.store.Get(ctx, req.TenantID, req.Phone)
+	phone, err := s.normalizeVerifyPhone(ctx, req.TenantID, req.Phone)
+	if err != nil {
+		return nil, err
+	}
+	req.Phone = phone
+
+	if err := s.checkLockout(ctx, req.TenantID, req.Phone); err != nil {
+		return nil, err
+	}
+
+	state, err := s.store.Get(ctx, req.TenantID, req.Phone, req.Purpose)
 	if err != nil {
 		if errors.Is(err, ErrOTPNotFound) {
-			s.logVerification(ctx, verificationLog(req, "", VerificationResultFailed, ReasonNotFound, 0))
-			return failedVerifyResponse("", ReasonNotFound), nil
+			return s.verifyMissing(ctx, req)
 		}
 		return nil, fmt.Errorf("get otp state: %w", err)
 	}
 
+	return s.verifyState(ctx, req, state)
+}
+
+// verifyState checks req.Code against state, the active OTP of req.Phone for req.Purpose.
+// Attempts and deletes apply only while the stored OTP still has state.RequestID.
+func (s *Service) verifyState(ctx context.Context, req VerifyRequest, state *OTPState) (*VerifyResponse, error) {
 	now := time.Now().UTC()
 	if !now.Before(state.ExpiresAt) {
-		_ = s.store.Delete(ctx, req.TenantID, req.Phone)
+		_ = s.store.DeleteRequest(ctx, req.TenantID, req.Phone, req.Purpose, state.RequestID)
 		s.logVerification(ctx, verificationLog(req, state.RequestID, VerificationResultFailed, ReasonExpired, state.AttemptCount))
+		s.recordVerificationResult(ctx, state.RequestID, ReasonExpired, state.AttemptCount)
 		return failedVerifyResponse(state.RequestID, ReasonExpired), nil
 	}
 
 	maxAttempts := state.MaxAttempts
 	if maxAttempts <= 0 {
-		maxAttempts = s.config.MaxAttempts
+		maxAttempts = s.fallbackMaxAttempts(ctx, req.TenantID)
 	}
 	if state.AttemptCount >= maxAttempts {
-		_ = s.store.Delete(ctx, req.TenantID, req.Phone)
+		_ = s.store.DeleteRequest(ctx, req.TenantID, req.Phone, req.Purpose, state.RequestID)
 		s.logVerification(ctx, verificationLog(req, state.RequestID, VerificationResultFailed, ReasonMaxAttemptsExceeded, state.AttemptCount))
+		s.recordVerificationResult(ctx, state.RequestID, ReasonMaxAttemptsExceeded, state.AttemptCount)
 		return failedVerifyResponse(state.RequestID, ReasonMaxAttemptsExceeded), nil
 	}
 
-	if !VerifyCode(req.Code, state.CodeHash) {
-		attempts, err := s.store.IncrementAttempts(ctx, req.TenantID, req.Phone)
+	if !s.codeHasher.Verify(req.Code, state.CodeHash, state.CodeHashKeyID) {
+		attempts, err := s.store.IncrementAttempts(ctx, req.TenantID, req.Phone, req.Purpose, state.RequestID)
 		if err != nil {
 			if errors.Is(err, ErrOTPNotFound) {
 				s.logVerification(ctx, verificationLog(req, state.RequestID, VerificationResultFailed, ReasonNotFound, 0))
@@ -195,28 +313,50 @@ func (s *Service) VerifyOTP(ctx context.Context, req VerifyRequest) (*VerifyResp
 			}
 			return nil, fmt.Errorf("increment otp attempts: %w", err)
 		}
+		if err := s.recordVerifyFailure(ctx, req.TenantID, req.Phone); err != nil {
+			if !errors.Is(err, ErrPhoneLocked) {
+				return nil, err
+			}
+			_ = s.store.DeleteRequest(ctx, req.TenantID, req.Phone, req.Purpose, state.RequestID)
+			s.logVerification(ctx, verificationLog(req, state.RequestID, VerificationResultFailed, ReasonPhoneLocked, attempts))
+			s.recordVerificationResult(ctx, state.RequestID, ReasonPhoneLocked, attempts)
+			return failedVerifyResponse(state.RequestID, ReasonPhoneLocked), nil
+		}
 		if attempts >= maxAttempts {
-			_ = s.store.Delete(ctx, req.TenantID, req.Phone)
+			_ = s.store.DeleteRequest(ctx, req.TenantID, req.Phone, req.Purpose, state.RequestID)
 			s.logVerification(ctx, verificationLog(req, state.RequestID, VerificationResultFailed, ReasonMaxAttemptsExceeded, attempts))
+			s.recordVerificationResult(ctx, state.RequestID, ReasonMaxAttemptsExceeded, attempts)
 			return failedVerifyResponse(state.RequestID, ReasonMaxAttemptsExceeded), nil
 		}
 		s.logVerification(ctx, verificationLog(req, state.RequestID, VerificationResultFailed, ReasonInvalidCode, attempts))
 		return failedVerifyResponse(state.RequestID, ReasonInvalidCode), nil
 	}
 
-	if err := s.store.Delete(ctx, req.TenantID, req.Phone); err != nil {
+	if err := s.store.DeleteRequest(ctx, req.TenantID, req.Phone, req.Purpose, state.RequestID); err != nil {
+		if errors.Is(err, ErrOTPNotFound) {
+			// A concurrent verification consumed the OTP first.
+			s.logVerification(ctx, verificationLog(req, state.RequestID, VerificationResultFailed, ReasonNotFound, state.AttemptCount))
+			return failedVerifyResponse("", ReasonNotFound), nil
+		}
 		return nil, fmt.Errorf("delete verified otp state: %w", err)
 	}
+	if s.lockout != nil {
+		// Best effort: a stale failure count only brings the next lockout forward.
+		_ = s.lockout.Reset(ctx, req.TenantID, req.Phone)
+	}
 
 	s.logVerification(ctx, verificationLog(req, state.RequestID, VerificationResultSuccess, ReasonVerified, state.AttemptCount))
+	s.recordVerificationResult(ctx, state.RequestID, ReasonVerified, state.AttemptCount)
 	return &VerifyResponse{
 		Verified:  true,
 		RequestID: state.RequestID,
 	}, nil
 }
 
-func (s *Service) preventActiveResend(ctx context.Context, tenantID int64, phone string, now time.Time) error {
-	state, err := s.store.Get(ctx, tenantID, phone)
+// preventActiveResend is a cheap pre-check that rejects a send before the limiter and
+// request log are touched. The authoritative check is the atomic store.Reserve call.
+func (s *Service) preventActiveResend(ctx context.Context, tenantID int64, phone string, purpose string, now time.Time) error {
+	state, err := s.store.Get(ctx, tenantID, phone, purpose)
 	if err != nil {
 		if errors.Is(err, ErrOTPNotFound) {
 			return nil
@@ -227,22 +367,155 @@ func (s *Service) preventActiveResend(ctx context.Context, tenantID int64, phone
 		return nil
 	}
 	if now.Before(state.ExpiresAt) {
-		return ErrOTPAlreadyActive
+		return &LimitError{Err: ErrOTPAlreadyActive, Limit: 1, ResetAfter: state.ExpiresAt.Sub(now)}
 	}
 
-	_ = s.store.Delete(ctx, tenantID, phone)
+	_ = s.store.Delete(ctx, tenantID, phone, purpose)
 	return nil
 }
 
-func (s *Service) allowSend(ctx context.Context, tenantID int64, phone string) error {
-	if s.sendLimiter == nil {
+// normalizeVerifyPhone normalizes the phone of a verification. Only national numbers
+// need the tenant's region, so international ones skip the tenant lookup.
+func (s *Service) normalizeVerifyPhone(ctx context.Context, tenantID int64, phone string) (string, error) {
+	if _, international, err := phoneDigits(phone); err != nil || international || s.tenantSettings == nil {
+		return s.phones.Normalize(phone, s.phones.Region(nil))
+	}
+	tenant, err := s.tenantSettings.GetTenantSettings(ctx, tenantID)
+	if err != nil {
+		return "", err
+	}
+	return s.phones.Normalize(phone, s.phones.Region(tenant))
+}
+
+// fallbackMaxAttempts resolves the attempt limit for states written without one,
+// preferring the tenant policy and falling back to the global config.
+func (s *Service) fallbackMaxAttempts(ctx context.Context, tenantID int64) int {
+	if s.tenantSettings == nil {
+		return s.config.MaxAttempts
+	}
+	tenant, err := s.tenantSettings.GetTenantSettings(ctx, tenantID)
+	if err != nil {
+		return s.config.MaxAttempts
+	}
+	return s.effectivePolicy(tenant).MaxAttempts
+}
+
+// allowSend checks the tenant-scoped and the global per-phone limits. Both reject with
+// ErrOTPRateLimited; the request log records which one did.
+func (s *Service) allowSend(ctx context.Context, req SendRequest, providerName string) error {
+	if s.sendLimiter != nil {
+		if err := s.sendLimiter.AllowSend(ctx, req.TenantID, req.Phone, req.Purpose); err != nil {
+			if errors.Is(err, ErrOTPRateLimited) {
+				s.logRejectedSend(ctx, req, providerName, SendReasonPhoneRateLimited)
+				return err
+			}
+			return fmt.Errorf("check otp send rate limit: %w", err)
+		}
+	}
+	if s.phoneLimiter != nil {
+		if err := s.phoneLimiter.AllowPhoneSend(ctx, req.Phone); err != nil {
+			if errors.Is(err, ErrOTPRateLimited) {
+				s.logRejectedSend(ctx, req, providerName, SendReasonGlobalPhoneRateLimited)
+				return err
+			}
+			return fmt.Errorf("check global phone send rate limit: %w", err)
+		}
+	}
+	return nil
+}
+
+// logRejectedSend records a rate-limited send. It is best-effort so that a logging
+// failure does not turn a 429 into a 500.
+func (s *Service) logRejectedSend(ctx context.Context, req SendRequest, providerName string, reason string) {
+	if s.requestLogger == nil {
+		return
+	}
+	now := time.Now().UTC()
+	_ = s.requestLogger.CreateRequest(ctx, OTPRequestLog{
+		RequestID:    uuid.NewString(),
+		TenantID:     req.TenantID,
+		Phone:        req.Phone,
+		Status:       RequestStatusRejected,
+		ProviderName: providerName,
+		ErrorMessage: reason,
+		Metadata:     req.Metadata,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	})
+}
+
+// allowTenantSend checks the tenant-wide quota. It runs before the per-phone limits so
+// that a send the tenant quota rejects does not use up the phone's budget.
+func (s *Service) allowTenantSend(ctx context.Context, req SendRequest, tenant *TenantSettings) error {
+	if s.tenantLimiter == nil || tenant.RateLimitPerMin <= 0 {
+		return nil
+	}
+	if err := s.tenantLimiter.AllowTenantSend(ctx, req.TenantID, tenant.RateLimitPerMin); err != nil {
+		if errors.Is(err, ErrTenantRateLimited) {
+			s.logRejectedSend(ctx, req, tenant.SMSProvider, SendReasonTenantRateLimited)
+			return err
+		}
+		return fmt.Errorf("check tenant otp send rate limit: %w", err)
+	}
+	return nil
+}
+
+// UnlockPhone lifts a brute-force lockout for a tenant's phone, for tenant admins. The
+// request must carry that tenant's admin token, so one tenant's admin can never unlock
+// another tenant's phones.
+func (s *Service) UnlockPhone(ctx context.Context, req UnlockRequest) error {
+	if req.TenantID <= 0 {
+		return fmt.Errorf("tenant_id must be greater than 0")
+	}
+	if strings.TrimSpace(req.Phone) == "" {
+		return fmt.Errorf("phone must not be empty")
+	}
+
+	tenant, err := s.tenantSettings.GetTenantSettings(ctx, req.TenantID)
+	if err != nil {
+		return err
+	}
+	if tenant == nil {
+		return ErrTenantNotFound
+	}
+	if !checkAdminToken(tenant, req.AdminToken) {
+		return ErrAdminUnauthorized
+	}
+	phone, err := s.phones.Normalize(req.Phone, s.phones.Region(tenant))
+	if err != nil {
+		return err
+	}
+	if s.lockout == nil {
 		return nil
 	}
-	if err := s.sendLimiter.AllowSend(ctx, tenantID, phone); err != nil {
-		if errors.Is(err, ErrOTPRateLimited) {
-			return ErrOTPRateLimited
+	if err := s.lockout.Unlock(ctx, req.TenantID, phone); err != nil {
+		return fmt.Errorf("unlock phone: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) checkLockout(ctx context.Context, tenantID int64, phone string) error {
+	if s.lockout == nil {
+		return nil
+	}
+	if err := s.lockout.Check(ctx, tenantID, phone); err != nil {
+		if errors.Is(err, ErrPhoneLocked) {
+			return err
+		}
+		return fmt.Errorf("check phone lockout: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) recordVerifyFailure(ctx context.Context, tenantID int64, phone string) error {
+	if s.lockout == nil {
+		return nil
+	}
+	if err := s.lockout.RecordFailure(ctx, tenantID, phone); err != nil {
+		if errors.Is(err, ErrPhoneLocked) {
+			return err
 		}
-		return fmt.Errorf("check otp send rate limit: %w", err)
+		return fmt.Errorf("record verify failure: %w", err)
 	}
 	return nil
 }
@@ -254,6 +527,28 @@ func (s *Service) logVerification(ctx context.Context, log OTPVerificationLog) {
 	_ = s.verifyLogger.LogVerification(ctx, log)
 }
 
+// recordVerificationResult marks the request log with its terminal verification outcome.
+// Like verification logging it is best-effort.
+func (s *Service) recordVerificationResult(ctx context.Context, requestID string, reason string, attemptCount int) {
+	if s.requestLogger == nil {
+		return
+	}
+	now := time.Now().UTC()
+	log := OTPVerificationResultLog{
+		RequestID:    requestID,
+		Status:       RequestStatusVerifyFailed,
+		ErrorMessage: reason,
+		AttemptCount: attemptCount,
+		UpdatedAt:    now,
+	}
+	if reason == ReasonVerified {
+		log.Status = RequestStatusVerified
+		log.ErrorMessage = ""
+		log.VerifiedAt = now
+	}
+	_ = s.requestLogger.UpdateVerificationResult(ctx, log)
+}
+
 func verificationLog(req VerifyRequest, requestID string, result string, reason string, attemptCount int) OTPVerificationLog {
 	return OTPVerificationLog{
 		RequestID:     requestID,
@@ -287,6 +582,27 @@ func smsProviderResponse(result *SMSResult) map[string]interface{} {
 	}
 }
 
+// smsProviderErrorResponse records the classified provider failure, if the adapter reported one.
+func smsProviderErrorResponse(err error) map[string]interface{} {
+	var providerErr *SMSProviderError
+	if !errors.As(err, &providerErr) {
+		return nil
+	}
+
+	response := map[string]interface{}{
+		"provider":   providerErr.Provider,
+		"error_kind": fmt.Sprint(providerErr.Kind),
+		"retryable":  providerErr.Retryable,
+	}
+	if providerErr.Code != "" {
+		response["error_code"] = providerErr.Code
+	}
+	if providerErr.StatusCode != 0 {
+		response["http_status"] = providerErr.StatusCode
+	}
+	return response
+}
+
 func withDefaults(config Config) Config {
 	defaults := DefaultConfig()
 	if config.CodeLength == 0 {
@@ -304,6 +620,18 @@ func withDefaults(config Config) Config {
 	if config.ProviderTimeout == 0 {
 		config.ProviderTimeout = defaults.ProviderTimeout
 	}
+	if config.FailoverTimeout == 0 {
+		config.FailoverTimeout = defaults.FailoverTimeout
+	}
+	if config.ResendCooldown == 0 {
+		config.ResendCooldown = defaults.ResendCooldown
+	}
+	if config.MaxResends == 0 {
+		config.MaxResends = defaults.MaxResends
+	}
+	if config.ResendMode == "" {
+		config.ResendMode = defaults.ResendMode
+	}
 	return config
 }
 
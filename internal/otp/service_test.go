@@ -2,7 +2,10 @@ package otp
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -11,12 +14,15 @@ import (
 )
 
 type fakeTenantProvider struct {
+	mu       sync.Mutex
 	settings *TenantSettings
 	err      error
 	calls    int
 }
 
 func (p *fakeTenantProvider) GetTenantSettings(ctx context.Context, tenantID int64) (*TenantSettings, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	p.calls++
 	if p.err != nil {
 		return nil, p.err
@@ -25,40 +31,100 @@ func (p *fakeTenantProvider) GetTenantSettings(ctx context.Context, tenantID int
 }
 
 type fakeOTPStore struct {
+	mu              sync.Mutex
 	saveErr         error
+	reserveErr      error
 	getErr          error
 	incrementErr    error
+	resendErr       error
+	revertErr       error
 	deleteErr       error
+	cancelErr       error
 	state           *OTPState
+	cancelledID     string
 	incrementResult int
+	getPhone        string
+	getPurpose      string
+	getRequestID    string
 	saved           OTPState
+	reserved        OTPState
+	resent          OTPState
 	ttl             time.Duration
-	calls           int
+	saveCalls       int
+	reserveCalls    int
 	getCalls        int
 	incrementCalls  int
+	resendCalls     int
+	revertCalls     int
 	deleteCalls     int
+	cancelCalls     int
 }
 
 func (s *fakeOTPStore) Save(ctx context.Context, state OTPState, ttl time.Duration) error {
-	s.calls++
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saveCalls++
 	s.saved = state
 	s.ttl = ttl
 	return s.saveErr
 }
 
-func (s *fakeOTPStore) Get(ctx context.Context, tenantID int64, phone string) (*OTPState, error) {
+func (s *fakeOTPStore) Reserve(ctx context.Context, state OTPState, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reserveCalls++
+	if s.reserveErr != nil {
+		return s.reserveErr
+	}
+	if s.state != nil && s.state.Purpose == state.Purpose && time.Now().UTC().Before(s.state.ExpiresAt) {
+		return ErrOTPAlreadyActive
+	}
+	s.reserved = state
+	s.ttl = ttl
+	reserved := state
+	s.state = &reserved
+	return nil
+}
+
+func (s *fakeOTPStore) Get(ctx context.Context, tenantID int64, phone string, purpose string) (*OTPState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.getCalls++
+	s.getPhone = phone
+	s.getPurpose = purpose
+	if s.getErr != nil {
+		return nil, s.getErr
+	}
+	if s.state != nil && s.state.Purpose != purpose {
+		return nil, ErrOTPNotFound
+	}
+	return s.state, nil
+}
+
+func (s *fakeOTPStore) GetByRequestID(ctx context.Context, tenantID int64, requestID string) (*OTPState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.getCalls++
+	s.getRequestID = requestID
 	if s.getErr != nil {
 		return nil, s.getErr
 	}
+	if s.state == nil || s.state.RequestID != requestID {
+		return nil, ErrOTPNotFound
+	}
 	return s.state, nil
 }
 
-func (s *fakeOTPStore) IncrementAttempts(ctx context.Context, tenantID int64, phone string) (int, error) {
+func (s *fakeOTPStore) IncrementAttempts(ctx context.Context, tenantID int64, phone string, purpose string, requestID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.incrementCalls++
 	if s.incrementErr != nil {
 		return 0, s.incrementErr
 	}
+	if s.state != nil && s.state.RequestID != requestID {
+		return 0, ErrOTPNotFound
+	}
 	if s.incrementResult != 0 {
 		return s.incrementResult, nil
 	}
@@ -69,7 +135,49 @@ func (s *fakeOTPStore) IncrementAttempts(ctx context.Context, tenantID int64, ph
 	return 0, nil
 }
 
-func (s *fakeOTPStore) Delete(ctx context.Context, tenantID int64, phone string) error {
+func (s *fakeOTPStore) Resend(ctx context.Context, state OTPState, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resendCalls++
+	if s.resendErr != nil {
+		return s.resendErr
+	}
+	if s.state == nil || s.state.RequestID != state.RequestID {
+		return ErrOTPNotFound
+	}
+	if s.state.ResendCount != state.ResendCount-1 {
+		return ErrOTPResendCooldown
+	}
+	s.resent = state
+	s.ttl = ttl
+	resent := state
+	resent.AttemptCount = s.state.AttemptCount
+	s.state = &resent
+	return nil
+}
+
+func (s *fakeOTPStore) RevertResend(ctx context.Context, previous OTPState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revertCalls++
+	if s.revertErr != nil {
+		return s.revertErr
+	}
+	if s.state == nil || s.state.RequestID != previous.RequestID {
+		return ErrOTPNotFound
+	}
+	if s.state.ResendCount != previous.ResendCount+1 {
+		return ErrOTPResendCooldown
+	}
+	reverted := previous
+	reverted.AttemptCount = s.state.AttemptCount
+	s.state = &reverted
+	return nil
+}
+
+func (s *fakeOTPStore) Delete(ctx context.Context, tenantID int64, phone string, purpose string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.deleteCalls++
 	if s.deleteErr != nil {
 		return s.deleteErr
@@ -78,7 +186,53 @@ func (s *fakeOTPStore) Delete(ctx context.Context, tenantID int64, phone string)
 	return nil
 }
 
+func (s *fakeOTPStore) DeleteRequest(ctx context.Context, tenantID int64, phone string, purpose string, requestID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleteCalls++
+	if s.deleteErr != nil {
+		return s.deleteErr
+	}
+	if s.state == nil || s.state.RequestID != requestID {
+		return ErrOTPNotFound
+	}
+	s.state = nil
+	return nil
+}
+
+func (s *fakeOTPStore) Cancel(ctx context.Context, tenantID int64, phone string, purpose string, requestID string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cancelCalls++
+	if s.cancelErr != nil {
+		return s.cancelErr
+	}
+	if s.state == nil || s.state.RequestID != requestID {
+		return ErrOTPNotFound
+	}
+	s.state = nil
+	s.cancelledID = requestID
+	s.ttl = ttl
+	return nil
+}
+
+func (s *fakeOTPStore) CancelledRequestID(ctx context.Context, tenantID int64, phone string, purpose string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancelledID == "" {
+		return "", ErrOTPNotFound
+	}
+	return s.cancelledID, nil
+}
+
+func (s *fakeOTPStore) IsCancelled(ctx context.Context, tenantID int64, requestID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cancelledID != "" && s.cancelledID == requestID, nil
+}
+
 type fakeSMSProvider struct {
+	mu    sync.Mutex
 	err   error
 	block bool
 	req   SMSRequest
@@ -86,8 +240,10 @@ type fakeSMSProvider struct {
 }
 
 func (p *fakeSMSProvider) SendOTP(ctx context.Context, req SMSRequest) (*SMSResult, error) {
+	p.mu.Lock()
 	p.calls++
 	p.req = req
+	p.mu.Unlock()
 	if p.block {
 		<-ctx.Done()
 		return nil, ctx.Err()
@@ -103,25 +259,103 @@ func (p *fakeSMSProvider) SendOTP(ctx context.Context, req SMSRequest) (*SMSResu
 	}, nil
 }
 
+// routingSMSProvider dispatches to a fake per provider name and records the call order.
+type routingSMSProvider struct {
+	mu        sync.Mutex
+	providers map[string]*fakeSMSProvider
+	order     []string
+}
+
+func (p *routingSMSProvider) SendOTP(ctx context.Context, req SMSRequest) (*SMSResult, error) {
+	p.mu.Lock()
+	p.order = append(p.order, req.Provider)
+	provider, ok := p.providers[req.Provider]
+	p.mu.Unlock()
+	if !ok {
+		return nil, &SMSProviderError{Provider: req.Provider, Kind: ErrSMSProviderNotConfigured}
+	}
+	return provider.SendOTP(ctx, req)
+}
+
 type fakeSendRateLimiter struct {
 	err      error
 	calls    int
 	tenantID int64
 	phone    string
+	purpose  string
 }
 
-func (l *fakeSendRateLimiter) AllowSend(ctx context.Context, tenantID int64, phone string) error {
+func (l *fakeSendRateLimiter) AllowSend(ctx context.Context, tenantID int64, phone string, purpose string) error {
 	l.calls++
 	l.tenantID = tenantID
 	l.phone = phone
+	l.purpose = purpose
+	return l.err
+}
+
+type fakeGlobalPhoneRateLimiter struct {
+	err   error
+	calls int
+	phone string
+}
+
+func (l *fakeGlobalPhoneRateLimiter) AllowPhoneSend(ctx context.Context, phone string) error {
+	l.calls++
+	l.phone = phone
+	return l.err
+}
+
+type fakeTenantSendRateLimiter struct {
+	err         error
+	calls       int
+	tenantID    int64
+	limitPerMin int
+}
+
+func (l *fakeTenantSendRateLimiter) AllowTenantSend(ctx context.Context, tenantID int64, limitPerMin int) error {
+	l.calls++
+	l.tenantID = tenantID
+	l.limitPerMin = limitPerMin
 	return l.err
 }
 
+type fakePhoneLockout struct {
+	checkErr     error
+	failureErr   error
+	unlockErr    error
+	checkCalls   int
+	failureCalls int
+	resetCalls   int
+	unlockCalls  int
+}
+
+func (l *fakePhoneLockout) Check(ctx context.Context, tenantID int64, phone string) error {
+	l.checkCalls++
+	return l.checkErr
+}
+
+func (l *fakePhoneLockout) RecordFailure(ctx context.Context, tenantID int64, phone string) error {
+	l.failureCalls++
+	return l.failureErr
+}
+
+func (l *fakePhoneLockout) Reset(ctx context.Context, tenantID int64, phone string) error {
+	l.resetCalls++
+	return nil
+}
+
+func (l *fakePhoneLockout) Unlock(ctx context.Context, tenantID int64, phone string) error {
+	l.unlockCalls++
+	return l.unlockErr
+}
+
 type fakeRequestLogger struct {
 	createErr   error
 	updateErr   error
 	createLog   OTPRequestLog
 	updateLogs  []OTPProviderResultLog
+	resultErr   error
+	resultLogs  []OTPVerificationResultLog
 	createCalls int
 	updateCalls int
 }
@@ -138,6 +372,11 @@ func (l *fakeRequestLogger) UpdateProviderResult(ctx context.Context, log OTPPro
 	return l.updateErr
 }
 
+func (l *fakeRequestLogger) UpdateVerificationResult(ctx context.Context, log OTPVerificationResultLog) error {
+	l.resultLogs = append(l.resultLogs, log)
+	return l.resultErr
+}
+
 type fakeVerificationLogger struct {
 	err   error
 	logs  []OTPVerificationLog
@@ -174,17 +413,17 @@ func TestServiceSendOTPSuccess(t *testing.T) {
 	require.NotNil(t, resp)
 	assert.NotEmpty(t, resp.RequestID)
 	assert.False(t, resp.ExpiredAt.IsZero())
-	assert.Equal(t, 1, store.calls)
+	assert.Equal(t, 1, store.reserveCalls)
 	assert.Equal(t, 1, store.getCalls)
-	assert.NotEmpty(t, store.saved.CodeHash)
-	assert.NotEqual(t, smsProvider.req.Code, store.saved.CodeHash)
-	assert.Equal(t, req.TenantID, store.saved.TenantID)
-	assert.Equal(t, req.Phone, store.saved.Phone)
-	assert.Equal(t, config.MaxAttempts, store.saved.MaxAttempts)
+	assert.NotEmpty(t, store.reserved.CodeHash)
+	assert.NotEqual(t, smsProvider.req.Code, store.reserved.CodeHash)
+	assert.Equal(t, req.TenantID, store.reserved.TenantID)
+	assert.Equal(t, req.Phone, store.reserved.Phone)
+	assert.Equal(t, config.MaxAttempts, store.reserved.MaxAttempts)
 	assert.Equal(t, config.TTL, store.ttl)
-	assert.True(t, resp.ExpiredAt.Equal(store.saved.ExpiresAt))
+	assert.True(t, resp.ExpiredAt.Equal(store.reserved.ExpiresAt))
 	assert.Equal(t, 1, smsProvider.calls)
-	assert.Equal(t, resp.RequestID, store.saved.RequestID)
+	assert.Equal(t, resp.RequestID, store.reserved.RequestID)
 	assert.Equal(t, resp.RequestID, smsProvider.req.RequestID)
 	assert.Equal(t, req.TenantID, smsProvider.req.TenantID)
 	assert.Equal(t, req.Phone, smsProvider.req.Phone)
@@ -216,6 +455,7 @@ func TestServiceSendOTPInvalidRequest(t *testing.T) {
 	}{
 		{name: "invalid tenant id", req: SendRequest{TenantID: 0, Phone: "+989121234567"}},
 		{name: "empty phone", req: SendRequest{TenantID: 42, Phone: ""}},
+		{name: "invalid purpose", req: SendRequest{TenantID: 42, Phone: "+989121234567", Purpose: "Login!"}},
 	}
 
 	for _, tt := range tests {
@@ -231,7 +471,7 @@ func TestServiceSendOTPInvalidRequest(t *testing.T) {
 			require.Nil(t, resp)
 			require.Error(t, err)
 			assert.Equal(t, 0, tenantProvider.calls)
-			assert.Equal(t, 0, store.calls)
+			assert.Equal(t, 0, store.reserveCalls)
 			assert.Equal(t, 0, smsProvider.calls)
 			assert.Equal(t, 0, requestLogger.createCalls)
 			assert.Equal(t, 0, requestLogger.updateCalls)
@@ -252,7 +492,7 @@ func TestServiceSendOTPTenantLookupError(t *testing.T) {
 	require.Nil(t, resp)
 	assert.ErrorIs(t, err, lookupErr)
 	assert.Equal(t, 1, tenantProvider.calls)
-	assert.Equal(t, 0, store.calls)
+	assert.Equal(t, 0, store.reserveCalls)
 	assert.Equal(t, 0, smsProvider.calls)
 	assert.Equal(t, 0, requestLogger.createCalls)
 	assert.Equal(t, 0, requestLogger.updateCalls)
@@ -283,7 +523,7 @@ func TestServiceSendOTPTenantDisabled(t *testing.T) {
 			assert.ErrorIs(t, err, ErrTenantDisabled)
 			assert.Equal(t, 1, tenantProvider.calls)
 			assert.Equal(t, 0, store.getCalls)
-			assert.Equal(t, 0, store.calls)
+			assert.Equal(t, 0, store.reserveCalls)
 			assert.Equal(t, 0, smsProvider.calls)
 			assert.Equal(t, 0, requestLogger.createCalls)
 			assert.Equal(t, 0, requestLogger.updateCalls)
@@ -302,11 +542,16 @@ func TestServiceSendOTPExistingActiveOTPBlocked(t *testing.T) {
 
 	require.Nil(t, resp)
 	assert.ErrorIs(t, err, ErrOTPAlreadyActive)
+	var limitErr *LimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, 1, limitErr.Limit)
+	assert.Equal(t, 0, limitErr.Remaining)
+	assert.InDelta(t, time.Minute, limitErr.ResetAfter, float64(5*time.Second))
 	assert.Equal(t, 1, tenantProvider.calls)
 	assert.Equal(t, 1, store.getCalls)
 	assert.Equal(t, 0, requestLogger.createCalls)
 	assert.Equal(t, 0, requestLogger.updateCalls)
-	assert.Equal(t, 0, store.calls)
+	assert.Equal(t, 0, store.reserveCalls)
 	assert.Equal(t, 0, smsProvider.calls)
 	assert.Equal(t, 0, store.deleteCalls)
 }
@@ -327,7 +572,7 @@ func TestServiceSendOTPExistingExpiredOTPDeletedAndProceeds(t *testing.T) {
 	assert.Equal(t, 1, store.getCalls)
 	assert.Equal(t, 1, store.deleteCalls)
 	assert.Equal(t, 1, requestLogger.createCalls)
-	assert.Equal(t, 1, store.calls)
+	assert.Equal(t, 1, store.reserveCalls)
 	assert.Equal(t, 1, smsProvider.calls)
 }
 
@@ -347,7 +592,7 @@ func TestServiceSendOTPExistingExpiredOTPDeleteFailureStillProceeds(t *testing.T
 	assert.Equal(t, 1, store.getCalls)
 	assert.Equal(t, 1, store.deleteCalls)
 	assert.Equal(t, 1, requestLogger.createCalls)
-	assert.Equal(t, 1, store.calls)
+	assert.Equal(t, 1, store.reserveCalls)
 	assert.Equal(t, 1, smsProvider.calls)
 }
 
@@ -364,7 +609,7 @@ func TestServiceSendOTPExistingOTPNotFoundProceeds(t *testing.T) {
 	require.NotNil(t, resp)
 	assert.Equal(t, 1, store.getCalls)
 	assert.Equal(t, 1, requestLogger.createCalls)
-	assert.Equal(t, 1, store.calls)
+	assert.Equal(t, 1, store.reserveCalls)
 	assert.Equal(t, 1, smsProvider.calls)
 }
 
@@ -383,7 +628,7 @@ func TestServiceSendOTPExistingOTPGetErrorAborts(t *testing.T) {
 	assert.Equal(t, 1, store.getCalls)
 	assert.Equal(t, 0, requestLogger.createCalls)
 	assert.Equal(t, 0, requestLogger.updateCalls)
-	assert.Equal(t, 0, store.calls)
+	assert.Equal(t, 0, store.reserveCalls)
 	assert.Equal(t, 0, smsProvider.calls)
 }
 
@@ -404,7 +649,7 @@ func TestServiceSendOTPLimiterAllowsSend(t *testing.T) {
 	assert.Equal(t, int64(42), limiter.tenantID)
 	assert.Equal(t, "+989121234567", limiter.phone)
 	assert.Equal(t, 1, requestLogger.createCalls)
-	assert.Equal(t, 1, store.calls)
+	assert.Equal(t, 1, store.reserveCalls)
 	assert.Equal(t, 1, smsProvider.calls)
 }
 
@@ -422,12 +667,97 @@ func TestServiceSendOTPLimiterRateLimited(t *testing.T) {
 	require.Nil(t, resp)
 	assert.ErrorIs(t, err, ErrOTPRateLimited)
 	assert.Equal(t, 1, limiter.calls)
-	assert.Equal(t, 0, requestLogger.createCalls)
+	assert.Equal(t, 1, requestLogger.createCalls)
+	assert.Equal(t, RequestStatusRejected, requestLogger.createLog.Status)
+	assert.Equal(t, SendReasonPhoneRateLimited, requestLogger.createLog.ErrorMessage)
 	assert.Equal(t, 0, requestLogger.updateCalls)
-	assert.Equal(t, 0, store.calls)
+	assert.Equal(t, 0, store.reserveCalls)
 	assert.Equal(t, 0, smsProvider.calls)
 }
 
+func TestServiceSendOTPLimiterRejectionLogIsBestEffort(t *testing.T) {
+	requestLogger := &fakeRequestLogger{createErr: errors.New("database unavailable")}
+	service := NewService(&fakeTenantProvider{settings: activeTenantSettings()}, &fakeOTPStore{}, &fakeSMSProvider{}, requestLogger, nil, Config{})
+	service.SetSendRateLimiter(&fakeSendRateLimiter{err: ErrOTPRateLimited})
+
+	_, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	assert.ErrorIs(t, err, ErrOTPRateLimited)
+	assert.Equal(t, 1, requestLogger.createCalls)
+}
+
+func TestServiceSendOTPGlobalPhoneLimiter(t *testing.T) {
+	limiter := &fakeSendRateLimiter{}
+	phoneLimiter := &fakeGlobalPhoneRateLimiter{}
+	requestLogger := &fakeRequestLogger{}
+	service := NewService(&fakeTenantProvider{settings: activeTenantSettings()}, &fakeOTPStore{}, &fakeSMSProvider{}, requestLogger, nil, Config{})
+	service.SetSendRateLimiter(limiter)
+	service.SetGlobalPhoneRateLimiter(phoneLimiter)
+
+	_, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, limiter.calls)
+	assert.Equal(t, 1, phoneLimiter.calls)
+	assert.Equal(t, "+989121234567", phoneLimiter.phone)
+	assert.Equal(t, RequestStatusPending, requestLogger.createLog.Status)
+}
+
+func TestServiceSendOTPGlobalPhoneLimiterRateLimited(t *testing.T) {
+	limitErr := &LimitError{Err: ErrOTPRateLimited, Limit: 10, ResetAfter: time.Hour}
+	store := &fakeOTPStore{}
+	smsProvider := &fakeSMSProvider{}
+	requestLogger := &fakeRequestLogger{}
+	tenantLimiter := &fakeTenantSendRateLimiter{}
+	tenant := activeTenantSettings()
+	tenant.RateLimitPerMin = 120
+	service := NewService(&fakeTenantProvider{settings: tenant}, store, smsProvider, requestLogger, nil, Config{})
+	service.SetGlobalPhoneRateLimiter(&fakeGlobalPhoneRateLimiter{err: limitErr})
+	service.SetTenantSendRateLimiter(tenantLimiter)
+
+	resp, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567", Metadata: map[string]interface{}{"source": "signup"}})
+
+	require.Nil(t, resp)
+	var got *LimitError
+	require.ErrorAs(t, err, &got)
+	assert.Same(t, limitErr, got)
+	assert.Equal(t, 1, requestLogger.createCalls)
+	assert.Equal(t, RequestStatusRejected, requestLogger.createLog.Status)
+	assert.Equal(t, SendReasonGlobalPhoneRateLimited, requestLogger.createLog.ErrorMessage)
+	assert.Equal(t, int64(42), requestLogger.createLog.TenantID)
+	assert.Equal(t, "signup", requestLogger.createLog.Metadata["source"])
+	assert.NotEmpty(t, requestLogger.createLog.RequestID)
+	assert.Equal(t, 1, tenantLimiter.calls)
+	assert.Equal(t, 0, store.reserveCalls)
+	assert.Equal(t, 0, smsProvider.calls)
+}
+
+func TestServiceSendOTPGlobalPhoneLimiterInfrastructureError(t *testing.T) {
+	limiterErr := errors.New("limiter unavailable")
+	requestLogger := &fakeRequestLogger{}
+	service := NewService(&fakeTenantProvider{settings: activeTenantSettings()}, &fakeOTPStore{}, &fakeSMSProvider{}, requestLogger, nil, Config{})
+	service.SetGlobalPhoneRateLimiter(&fakeGlobalPhoneRateLimiter{err: limiterErr})
+
+	_, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	assert.ErrorIs(t, err, limiterErr)
+	assert.NotErrorIs(t, err, ErrOTPRateLimited)
+	assert.Equal(t, 0, requestLogger.createCalls)
+}
+
+func TestServiceSendOTPLimiterKeepsLimitDetails(t *testing.T) {
+	limitErr := &LimitError{Err: ErrOTPRateLimited, Limit: 5, ResetAfter: 90 * time.Second}
+	service := NewService(&fakeTenantProvider{settings: activeTenantSettings()}, &fakeOTPStore{}, &fakeSMSProvider{}, &fakeRequestLogger{}, nil, Config{})
+	service.SetSendRateLimiter(&fakeSendRateLimiter{err: limitErr})
+
+	_, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	assert.ErrorIs(t, err, ErrOTPRateLimited)
+	var got *LimitError
+	require.ErrorAs(t, err, &got)
+	assert.Same(t, limitErr, got)
+}
+
 func TestServiceSendOTPLimiterInfrastructureError(t *testing.T) {
 	limitErr := errors.New("limiter unavailable")
 	tenantProvider := &fakeTenantProvider{settings: activeTenantSettings()}
@@ -445,10 +775,90 @@ func TestServiceSendOTPLimiterInfrastructureError(t *testing.T) {
 	assert.Equal(t, 1, limiter.calls)
 	assert.Equal(t, 0, requestLogger.createCalls)
 	assert.Equal(t, 0, requestLogger.updateCalls)
-	assert.Equal(t, 0, store.calls)
+	assert.Equal(t, 0, store.reserveCalls)
 	assert.Equal(t, 0, smsProvider.calls)
 }
 
+func TestServiceSendOTPTenantLimiterUsesTenantQuota(t *testing.T) {
+	tenant := activeTenantSettings()
+	tenant.RateLimitPerMin = 120
+	tenantLimiter := &fakeTenantSendRateLimiter{}
+	service := NewService(&fakeTenantProvider{settings: tenant}, &fakeOTPStore{}, &fakeSMSProvider{}, &fakeRequestLogger{}, nil, Config{})
+	service.SetTenantSendRateLimiter(tenantLimiter)
+
+	_, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, tenantLimiter.calls)
+	assert.Equal(t, int64(42), tenantLimiter.tenantID)
+	assert.Equal(t, 120, tenantLimiter.limitPerMin)
+}
+
+func TestServiceSendOTPTenantLimiterRateLimited(t *testing.T) {
+	store := &fakeOTPStore{}
+	smsProvider := &fakeSMSProvider{}
+	requestLogger := &fakeRequestLogger{}
+	phoneLimiter := &fakeSendRateLimiter{}
+	tenantLimiter := &fakeTenantSendRateLimiter{err: ErrTenantRateLimited}
+	globalLimiter := &fakeGlobalPhoneRateLimiter{}
+	service := NewService(&fakeTenantProvider{settings: activeTenantSettings()}, store, smsProvider, requestLogger, nil, Config{})
+	service.SetSendRateLimiter(phoneLimiter)
+	service.SetGlobalPhoneRateLimiter(globalLimiter)
+	service.SetTenantSendRateLimiter(tenantLimiter)
+
+	resp, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	require.Nil(t, resp)
+	assert.ErrorIs(t, err, ErrTenantRateLimited)
+	assert.NotErrorIs(t, err, ErrOTPRateLimited)
+	// The tenant quota is checked first, so the phone's budgets stay untouched.
+	assert.Equal(t, 0, phoneLimiter.calls)
+	assert.Equal(t, 0, globalLimiter.calls)
+	assert.Equal(t, 1, requestLogger.createCalls)
+	assert.Equal(t, RequestStatusRejected, requestLogger.createLog.Status)
+	assert.Equal(t, SendReasonTenantRateLimited, requestLogger.createLog.ErrorMessage)
+	assert.Equal(t, 0, store.reserveCalls)
+	assert.Equal(t, 0, smsProvider.calls)
+}
+
+func TestServiceSendOTPTenantLimiterRunsBeforePhoneLimiter(t *testing.T) {
+	phoneLimiter := &fakeSendRateLimiter{err: ErrOTPRateLimited}
+	tenantLimiter := &fakeTenantSendRateLimiter{}
+	service := NewService(&fakeTenantProvider{settings: activeTenantSettings()}, &fakeOTPStore{}, &fakeSMSProvider{}, &fakeRequestLogger{}, nil, Config{})
+	service.SetSendRateLimiter(phoneLimiter)
+	service.SetTenantSendRateLimiter(tenantLimiter)
+
+	_, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	assert.ErrorIs(t, err, ErrOTPRateLimited)
+	assert.Equal(t, 1, tenantLimiter.calls)
+	assert.Equal(t, 1, phoneLimiter.calls)
+}
+
+func TestServiceSendOTPTenantLimiterSkippedWithoutQuota(t *testing.T) {
+	tenant := activeTenantSettings()
+	tenant.RateLimitPerMin = 0
+	tenantLimiter := &fakeTenantSendRateLimiter{err: ErrTenantRateLimited}
+	service := NewService(&fakeTenantProvider{settings: tenant}, &fakeOTPStore{}, &fakeSMSProvider{}, &fakeRequestLogger{}, nil, Config{})
+	service.SetTenantSendRateLimiter(tenantLimiter)
+
+	_, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, tenantLimiter.calls)
+}
+
+func TestServiceSendOTPTenantLimiterInfrastructureError(t *testing.T) {
+	limitErr := errors.New("limiter unavailable")
+	service := NewService(&fakeTenantProvider{settings: activeTenantSettings()}, &fakeOTPStore{}, &fakeSMSProvider{}, &fakeRequestLogger{}, nil, Config{})
+	service.SetTenantSendRateLimiter(&fakeTenantSendRateLimiter{err: limitErr})
+
+	_, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	assert.ErrorIs(t, err, limitErr)
+	assert.NotErrorIs(t, err, ErrTenantRateLimited)
+}
+
 func TestServiceSendOTPActiveOTPBeforeLimiter(t *testing.T) {
 	tenantProvider := &fakeTenantProvider{settings: activeTenantSettings()}
 	store := &fakeOTPStore{state: activeOTPState("123456")}
@@ -464,7 +874,7 @@ func TestServiceSendOTPActiveOTPBeforeLimiter(t *testing.T) {
 	assert.ErrorIs(t, err, ErrOTPAlreadyActive)
 	assert.Equal(t, 0, limiter.calls)
 	assert.Equal(t, 0, requestLogger.createCalls)
-	assert.Equal(t, 0, store.calls)
+	assert.Equal(t, 0, store.reserveCalls)
 	assert.Equal(t, 0, smsProvider.calls)
 }
 
@@ -484,7 +894,7 @@ func TestServiceSendOTPTenantDisabledBeforeLimiter(t *testing.T) {
 	assert.Equal(t, 0, limiter.calls)
 	assert.Equal(t, 0, store.getCalls)
 	assert.Equal(t, 0, requestLogger.createCalls)
-	assert.Equal(t, 0, store.calls)
+	assert.Equal(t, 0, store.reserveCalls)
 	assert.Equal(t, 0, smsProvider.calls)
 }
 
@@ -502,14 +912,14 @@ func TestServiceSendOTPCreateRequestError(t *testing.T) {
 	assert.ErrorIs(t, err, createErr)
 	assert.Equal(t, 1, requestLogger.createCalls)
 	assert.Equal(t, 0, requestLogger.updateCalls)
-	assert.Equal(t, 0, store.calls)
+	assert.Equal(t, 0, store.reserveCalls)
 	assert.Equal(t, 0, smsProvider.calls)
 }
 
-func TestServiceSendOTPStoreSaveError(t *testing.T) {
-	saveErr := errors.New("save failed")
+func TestServiceSendOTPStoreReserveError(t *testing.T) {
+	reserveErr := errors.New("reserve failed")
 	tenantProvider := &fakeTenantProvider{settings: activeTenantSettings()}
-	store := &fakeOTPStore{saveErr: saveErr}
+	store := &fakeOTPStore{reserveErr: reserveErr}
 	smsProvider := &fakeSMSProvider{}
 	requestLogger := &fakeRequestLogger{}
 	service := NewService(tenantProvider, store, smsProvider, requestLogger, nil, Config{})
@@ -517,16 +927,142 @@ func TestServiceSendOTPStoreSaveError(t *testing.T) {
 	resp, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
 
 	require.Nil(t, resp)
-	assert.ErrorIs(t, err, saveErr)
+	assert.ErrorIs(t, err, reserveErr)
 	assert.Equal(t, 1, requestLogger.createCalls)
 	require.Equal(t, 1, requestLogger.updateCalls)
 	assert.Equal(t, RequestStatusFailed, requestLogger.updateLogs[0].Status)
 	assert.Equal(t, "fake", requestLogger.updateLogs[0].ProviderName)
-	assert.Contains(t, requestLogger.updateLogs[0].ErrorMessage, "save otp state")
-	assert.Equal(t, 1, store.calls)
+	assert.Contains(t, requestLogger.updateLogs[0].ErrorMessage, "reserve otp state")
+	assert.Equal(t, 1, store.reserveCalls)
 	assert.Equal(t, 0, smsProvider.calls)
 }
 
+func TestServiceSendOTPReserveLostRace(t *testing.T) {
+	tenantProvider := &fakeTenantProvider{settings: activeTenantSettings()}
+	store := &fakeOTPStore{reserveErr: ErrOTPAlreadyActive}
+	smsProvider := &fakeSMSProvider{}
+	requestLogger := &fakeRequestLogger{}
+	service := NewService(tenantProvider, store, smsProvider, requestLogger, nil, Config{})
+
+	resp, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	require.Nil(t, resp)
+	assert.ErrorIs(t, err, ErrOTPAlreadyActive)
+	var limitErr *LimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, 2*time.Minute, limitErr.ResetAfter)
+	assert.Equal(t, 1, store.reserveCalls)
+	assert.Equal(t, 1, requestLogger.createCalls)
+	require.Equal(t, 1, requestLogger.updateCalls)
+	assert.Equal(t, RequestStatusFailed, requestLogger.updateLogs[0].Status)
+	assert.Contains(t, requestLogger.updateLogs[0].ErrorMessage, ErrOTPAlreadyActive.Error())
+	assert.Equal(t, 0, smsProvider.calls)
+}
+
+func TestServiceSendOTPConcurrentSendsReserveOnce(t *testing.T) {
+	tenantProvider := &fakeTenantProvider{settings: activeTenantSettings()}
+	store := &fakeOTPStore{}
+	smsProvider := &fakeSMSProvider{}
+	service := NewService(tenantProvider, store, smsProvider, nil, nil, Config{})
+
+	totalSends := 10
+	start := make(chan struct{})
+	errs := make(chan error, totalSends)
+
+	var wg sync.WaitGroup
+	for i := 0; i < totalSends; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+			errs <- err
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	alreadyActive := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, ErrOTPAlreadyActive):
+			alreadyActive++
+		default:
+			require.NoError(t, err)
+		}
+	}
+
+	assert.Equal(t, 1, succeeded)
+	assert.Equal(t, totalSends-1, alreadyActive)
+	assert.Equal(t, 1, smsProvider.calls)
+}
+
+func TestServiceSendOTPUsesCodeHasher(t *testing.T) {
+	tenantProvider := &fakeTenantProvider{settings: activeTenantSettings()}
+	store := &fakeOTPStore{}
+	smsProvider := &fakeSMSProvider{}
+	service := NewService(tenantProvider, store, smsProvider, nil, nil, Config{})
+	service.SetCodeHasher(newTestCodeHasher(t, "k1", map[string]string{"k1": "pepper-one-0123456789"}))
+
+	resp, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, "k1", store.reserved.CodeHashKeyID)
+	assert.NotEqual(t, HashCode(smsProvider.req.Code), store.reserved.CodeHash)
+	assert.True(t, service.codeHasher.Verify(smsProvider.req.Code, store.reserved.CodeHash, store.reserved.CodeHashKeyID))
+}
+
+func TestServiceSendOTPUsesTenantPolicy(t *testing.T) {
+	tenant := activeTenantSettings()
+	tenant.OTPPolicy = &Policy{CodeLength: 8, TTL: 60 * time.Second, MaxAttempts: 3}
+	tenant.SMSTemplate = "verify-banking"
+	tenant.SMSSender = "10004346"
+	tenant.SMSTemplateParams = []string{"Acme Bank"}
+	store := &fakeOTPStore{}
+	smsProvider := &fakeSMSProvider{}
+	service := NewService(&fakeTenantProvider{settings: tenant}, store, smsProvider, nil, nil, Config{
+		CodeLength:  6,
+		TTL:         5 * time.Minute,
+		MaxAttempts: 5,
+	})
+
+	resp, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	require.NoError(t, err)
+	assert.Len(t, smsProvider.req.Code, 8)
+	assert.Equal(t, "verify-banking", smsProvider.req.Template)
+	assert.Equal(t, "10004346", smsProvider.req.Sender)
+	assert.Equal(t, []string{"Acme Bank"}, smsProvider.req.TemplateParams)
+	assert.Equal(t, 60*time.Second, store.ttl)
+	assert.Equal(t, 3, store.reserved.MaxAttempts)
+	assert.Equal(t, 60*time.Second, store.reserved.ExpiresAt.Sub(store.reserved.CreatedAt))
+	assert.True(t, resp.ExpiredAt.Equal(store.reserved.ExpiresAt))
+}
+
+func TestServiceSendOTPPartialTenantPolicyFallsBackToConfig(t *testing.T) {
+	tenant := activeTenantSettings()
+	tenant.OTPPolicy = &Policy{TTL: 60 * time.Second}
+	store := &fakeOTPStore{}
+	smsProvider := &fakeSMSProvider{}
+	service := NewService(&fakeTenantProvider{settings: tenant}, store, smsProvider, nil, nil, Config{
+		CodeLength:  6,
+		TTL:         5 * time.Minute,
+		MaxAttempts: 5,
+	})
+
+	_, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	require.NoError(t, err)
+	assert.Len(t, smsProvider.req.Code, 6)
+	assert.Equal(t, 60*time.Second, store.ttl)
+	assert.Equal(t, 5, store.reserved.MaxAttempts)
+}
+
 func TestServiceSendOTPSMSProviderError(t *testing.T) {
 	smsErr := errors.New("provider failed")
 	tenantProvider := &fakeTenantProvider{settings: activeTenantSettings()}
@@ -545,10 +1081,244 @@ func TestServiceSendOTPSMSProviderError(t *testing.T) {
 	assert.Equal(t, RequestStatusFailed, requestLogger.updateLogs[0].Status)
 	assert.Equal(t, "fake", requestLogger.updateLogs[0].ProviderName)
 	assert.Contains(t, requestLogger.updateLogs[0].ErrorMessage, smsErr.Error())
-	assert.Equal(t, 1, store.calls)
+	assert.Equal(t, 1, store.reserveCalls)
 	assert.Equal(t, 1, smsProvider.calls)
 }
 
+func TestServiceSendOTPClassifiedSMSProviderError(t *testing.T) {
+	smsErr := &SMSProviderError{
+		Provider:   "twilio",
+		Kind:       ErrSMSRecipientLandline,
+		Code:       "21614",
+		StatusCode: 400,
+		Message:    "'To' number is not a valid mobile number",
+	}
+	requestLogger := &fakeRequestLogger{}
+	service := NewService(
+		&fakeTenantProvider{settings: activeTenantSettings()},
+		&fakeOTPStore{},
+		&fakeSMSProvider{err: smsErr},
+		requestLogger,
+		nil,
+		Config{},
+	)
+
+	resp, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	require.Nil(t, resp)
+	assert.ErrorIs(t, err, ErrSMSProviderFailed)
+	assert.ErrorIs(t, err, ErrSMSRecipientLandline)
+	require.Equal(t, 1, requestLogger.updateCalls)
+	failedLog := requestLogger.updateLogs[0]
+	assert.Equal(t, RequestStatusFailed, failedLog.Status)
+	assert.Len(t, failedLog.ProviderResponse["attempts"], 1)
+	delete(failedLog.ProviderResponse, "attempts")
+	assert.Equal(t, map[string]interface{}{
+		"provider":    "twilio",
+		"error_kind":  ErrSMSRecipientLandline.Error(),
+		"error_code":  "21614",
+		"http_status": 400,
+		"retryable":   false,
+	}, failedLog.ProviderResponse)
+}
+
+func TestServiceSendOTPFailsOverToNextProvider(t *testing.T) {
+	tenant := activeTenantSettings()
+	tenant.SMSProvider = "kavenegar"
+	tenant.SMSFailover = []string{"ghasedak", "twilio"}
+	tenant.SMSProviders = providersWithCredentials("ghasedak", "twilio")
+	router := &routingSMSProvider{providers: map[string]*fakeSMSProvider{
+		"kavenegar": {err: &SMSProviderError{Provider: "kavenegar", Kind: ErrSMSProviderUnavailable, Retryable: true}},
+		"ghasedak":  {},
+		"twilio":    {},
+	}}
+	requestLogger := &fakeRequestLogger{}
+	service := NewService(&fakeTenantProvider{settings: tenant}, &fakeOTPStore{}, router, requestLogger, nil, Config{})
+
+	resp, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kavenegar", "ghasedak"}, router.order)
+	assert.Equal(t, resp.RequestID, router.providers["ghasedak"].req.RequestID)
+	assert.Equal(t, resp.RequestID, router.providers["kavenegar"].req.RequestID)
+	assert.Equal(t, "kavenegar", requestLogger.createLog.ProviderName)
+	require.Equal(t, 1, requestLogger.updateCalls)
+	sentLog := requestLogger.updateLogs[0]
+	assert.Equal(t, RequestStatusSent, sentLog.Status)
+	assert.Equal(t, "ghasedak", sentLog.ProviderName)
+	attempts := sentLog.ProviderResponse["attempts"].([]map[string]interface{})
+	require.Len(t, attempts, 2)
+	assert.Equal(t, "kavenegar", attempts[0]["provider"])
+	assert.Equal(t, RequestStatusFailed, attempts[0]["status"])
+	assert.Contains(t, attempts[0]["error"], ErrSMSProviderUnavailable.Error())
+	assert.Contains(t, attempts[0], "latency_ms")
+	assert.Equal(t, "ghasedak", attempts[1]["provider"])
+	assert.Equal(t, RequestStatusSent, attempts[1]["status"])
+	assert.NotContains(t, attempts[1], "error")
+}
+
+func TestServiceSendOTPFailoverSkipsProvidersWithoutCredentials(t *testing.T) {
+	tenant := activeTenantSettings()
+	tenant.SMSProvider = "kavenegar"
+	tenant.SMSFailover = []string{"twilio", "ghasedak"}
+	tenant.SMSProviders = map[string]ProviderSMSSettings{
+		"twilio":   {Sender: "+15557122661"},
+		"ghasedak": {HasCredentials: true},
+	}
+	router := &routingSMSProvider{providers: map[string]*fakeSMSProvider{
+		"kavenegar": {err: &SMSProviderError{Provider: "kavenegar", Kind: ErrSMSProviderUnavailable, Retryable: true}},
+		"twilio":    {},
+		"ghasedak":  {},
+	}}
+	service := NewService(&fakeTenantProvider{settings: tenant}, &fakeOTPStore{}, router, &fakeRequestLogger{}, nil, Config{})
+
+	_, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kavenegar", "ghasedak"}, router.order)
+}
+
+func TestServiceSendOTPFailoverUsesProviderOwnTemplateAndSender(t *testing.T) {
+	tenant := activeTenantSettings()
+	tenant.SMSProvider = "kavenegar"
+	tenant.SMSTemplate = "kavenegar-template"
+	tenant.SMSTemplateParams = []string{"Acme"}
+	tenant.SMSSender = "10004346"
+	tenant.SMSFailover = []string{"ghasedak", "twilio"}
+	tenant.SMSProviders = map[string]ProviderSMSSettings{
+		"kavenegar": {Sender: "20004346"},
+		"ghasedak":  {Template: "ghasedak-template", HasCredentials: true},
+		"twilio":    {Sender: "+15557122661", HasCredentials: true},
+	}
+	unavailable := &SMSProviderError{Kind: ErrSMSProviderUnavailable, Retryable: true}
+	router := &routingSMSProvider{providers: map[string]*fakeSMSProvider{
+		"kavenegar": {err: unavailable},
+		"ghasedak":  {err: unavailable},
+		"twilio":    {},
+	}}
+	service := NewService(&fakeTenantProvider{settings: tenant}, &fakeOTPStore{}, router, &fakeRequestLogger{}, nil, Config{})
+
+	_, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	require.NoError(t, err)
+	primary := router.providers["kavenegar"].req
+	assert.Equal(t, "kavenegar-template", primary.Template)
+	assert.Equal(t, []string{"Acme"}, primary.TemplateParams)
+	assert.Equal(t, "20004346", primary.Sender)
+	ghasedak := router.providers["ghasedak"].req
+	assert.Equal(t, "ghasedak-template", ghasedak.Template)
+	assert.Nil(t, ghasedak.TemplateParams)
+	assert.Empty(t, ghasedak.Sender)
+	twilio := router.providers["twilio"].req
+	assert.Empty(t, twilio.Template)
+	assert.Equal(t, "+15557122661", twilio.Sender)
+}
+
+func TestServiceSendOTPFailsOverPastOpenCircuit(t *testing.T) {
+	tenant := activeTenantSettings()
+	tenant.SMSProvider = "kavenegar"
+	tenant.SMSFailover = []string{"ghasedak"}
+	tenant.SMSProviders = providersWithCredentials("ghasedak")
+	router := &routingSMSProvider{providers: map[string]*fakeSMSProvider{
+		"kavenegar": {err: &SMSProviderError{Provider: "kavenegar", Kind: ErrSMSCircuitOpen, Retryable: true}},
+		"ghasedak":  {},
+	}}
+	service := NewService(&fakeTenantProvider{settings: tenant}, &fakeOTPStore{}, router, &fakeRequestLogger{}, nil, Config{})
+
+	_, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kavenegar", "ghasedak"}, router.order)
+}
+
+func TestServiceSendOTPFailoverOnProviderTimeout(t *testing.T) {
+	tenant := activeTenantSettings()
+	tenant.SMSProvider = "kavenegar"
+	tenant.SMSFailover = []string{"ghasedak"}
+	tenant.SMSProviders = providersWithCredentials("ghasedak")
+	router := &routingSMSProvider{providers: map[string]*fakeSMSProvider{
+		"kavenegar": {block: true},
+		"ghasedak":  {},
+	}}
+	requestLogger := &fakeRequestLogger{}
+	service := NewService(&fakeTenantProvider{settings: tenant}, &fakeOTPStore{}, router, requestLogger, nil, Config{
+		ProviderTimeout: 10 * time.Millisecond,
+		FailoverTimeout: time.Second,
+	})
+
+	_, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kavenegar", "ghasedak"}, router.order)
+	assert.Equal(t, "ghasedak", requestLogger.updateLogs[0].ProviderName)
+}
+
+func TestServiceSendOTPFailoverChainExhausted(t *testing.T) {
+	tenant := activeTenantSettings()
+	tenant.SMSProvider = "kavenegar"
+	tenant.SMSFailover = []string{"ghasedak", "kavenegar", ""}
+	tenant.SMSProviders = providersWithCredentials("ghasedak")
+	lastErr := &SMSProviderError{Provider: "ghasedak", Kind: ErrSMSInsufficientCredit, Code: "418"}
+	router := &routingSMSProvider{providers: map[string]*fakeSMSProvider{
+		"kavenegar": {err: &SMSProviderError{Provider: "kavenegar", Kind: ErrSMSInvalidCredentials}},
+		"ghasedak":  {err: lastErr},
+	}}
+	requestLogger := &fakeRequestLogger{}
+	service := NewService(&fakeTenantProvider{settings: tenant}, &fakeOTPStore{}, router, requestLogger, nil, Config{})
+
+	resp, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	require.Nil(t, resp)
+	assert.ErrorIs(t, err, ErrSMSProviderFailed)
+	assert.ErrorIs(t, err, ErrSMSInsufficientCredit)
+	assert.Equal(t, []string{"kavenegar", "ghasedak"}, router.order)
+	failedLog := requestLogger.updateLogs[0]
+	assert.Equal(t, RequestStatusFailed, failedLog.Status)
+	assert.Equal(t, "ghasedak", failedLog.ProviderName)
+	assert.Equal(t, "418", failedLog.ProviderResponse["error_code"])
+	assert.Len(t, failedLog.ProviderResponse["attempts"], 2)
+}
+
+func TestServiceSendOTPNoFailoverForRecipientErrors(t *testing.T) {
+	tenant := activeTenantSettings()
+	tenant.SMSProvider = "twilio"
+	tenant.SMSFailover = []string{"kavenegar"}
+	tenant.SMSProviders = providersWithCredentials("kavenegar")
+	router := &routingSMSProvider{providers: map[string]*fakeSMSProvider{
+		"twilio":    {err: &SMSProviderError{Provider: "twilio", Kind: ErrSMSRecipientLandline}},
+		"kavenegar": {},
+	}}
+	service := NewService(&fakeTenantProvider{settings: tenant}, &fakeOTPStore{}, router, &fakeRequestLogger{}, nil, Config{})
+
+	_, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	assert.ErrorIs(t, err, ErrSMSRecipientLandline)
+	assert.Equal(t, []string{"twilio"}, router.order)
+}
+
+func TestServiceSendOTPFailoverStopsAtOverallDeadline(t *testing.T) {
+	tenant := activeTenantSettings()
+	tenant.SMSProvider = "kavenegar"
+	tenant.SMSFailover = []string{"ghasedak"}
+	tenant.SMSProviders = providersWithCredentials("ghasedak")
+	router := &routingSMSProvider{providers: map[string]*fakeSMSProvider{
+		"kavenegar": {block: true},
+		"ghasedak":  {},
+	}}
+	requestLogger := &fakeRequestLogger{}
+	service := NewService(&fakeTenantProvider{settings: tenant}, &fakeOTPStore{}, router, requestLogger, nil, Config{
+		ProviderTimeout: time.Second,
+		FailoverTimeout: 20 * time.Millisecond,
+	})
+
+	_, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	assert.ErrorIs(t, err, ErrSMSProviderFailed)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, []string{"kavenegar"}, router.order)
+	assert.Equal(t, "kavenegar", requestLogger.updateLogs[0].ProviderName)
+}
+
 func TestServiceSendOTPSMSProviderTimeout(t *testing.T) {
 	tenantProvider := &fakeTenantProvider{settings: activeTenantSettings()}
 	store := &fakeOTPStore{}
@@ -566,7 +1336,7 @@ func TestServiceSendOTPSMSProviderTimeout(t *testing.T) {
 	require.Equal(t, 1, requestLogger.updateCalls)
 	assert.Equal(t, RequestStatusFailed, requestLogger.updateLogs[0].Status)
 	assert.Contains(t, requestLogger.updateLogs[0].ErrorMessage, context.DeadlineExceeded.Error())
-	assert.Equal(t, 1, store.calls)
+	assert.Equal(t, 1, store.reserveCalls)
 	assert.Equal(t, 1, smsProvider.calls)
 }
 
@@ -584,7 +1354,7 @@ func TestServiceSendOTPSuccessUpdateProviderResultError(t *testing.T) {
 	assert.ErrorIs(t, err, updateErr)
 	assert.Equal(t, 1, requestLogger.createCalls)
 	assert.Equal(t, 1, requestLogger.updateCalls)
-	assert.Equal(t, 1, store.calls)
+	assert.Equal(t, 1, store.reserveCalls)
 	assert.Equal(t, 1, smsProvider.calls)
 }
 
@@ -608,6 +1378,45 @@ func TestServiceVerifyOTPSuccess(t *testing.T) {
 	assertVerificationLog(t, verifyLogger, VerificationResultSuccess, ReasonVerified, "request-verify", 0)
 }
 
+func TestServiceVerifyOTPWithRotatedCodeHashKey(t *testing.T) {
+	oldHasher := newTestCodeHasher(t, "k1", map[string]string{"k1": "pepper-one-0123456789"})
+	state := activeOTPState("123456")
+	state.CodeHash, state.CodeHashKeyID = oldHasher.Hash("123456")
+	store := &fakeOTPStore{state: state}
+	verifyLogger := &fakeVerificationLogger{}
+	service := NewService(nil, store, nil, nil, verifyLogger, Config{})
+	service.SetCodeHasher(newTestCodeHasher(t, "k2", map[string]string{
+		"k1": "pepper-one-0123456789",
+		"k2": "pepper-two-0123456789",
+	}))
+
+	resp, err := service.VerifyOTP(context.Background(), VerifyRequest{
+		TenantID: 42,
+		Phone:    "+989121234567",
+		Code:     "123456",
+	})
+
+	require.NoError(t, err)
+	assert.True(t, resp.Verified)
+	assert.Equal(t, 0, store.incrementCalls)
+}
+
+func TestServiceVerifyOTPLegacyHashWithCodeHasher(t *testing.T) {
+	store := &fakeOTPStore{state: activeOTPState("123456")}
+	verifyLogger := &fakeVerificationLogger{}
+	service := NewService(nil, store, nil, nil, verifyLogger, Config{})
+	service.SetCodeHasher(newTestCodeHasher(t, "k1", map[string]string{"k1": "pepper-one-0123456789"}))
+
+	resp, err := service.VerifyOTP(context.Background(), VerifyRequest{
+		TenantID: 42,
+		Phone:    "+989121234567",
+		Code:     "123456",
+	})
+
+	require.NoError(t, err)
+	assert.True(t, resp.Verified)
+}
+
 func TestServiceVerifyOTPInvalidRequest(t *testing.T) {
 	tests := []struct {
 		name string
@@ -634,6 +1443,111 @@ func TestServiceVerifyOTPInvalidRequest(t *testing.T) {
 	}
 }
 
+func TestServiceSendOTPNormalizesPhone(t *testing.T) {
+	tenant := activeTenantSettings()
+	tenant.Timezone = "Asia/Tehran"
+	store := &fakeOTPStore{}
+	smsProvider := &fakeSMSProvider{}
+	requestLogger := &fakeRequestLogger{}
+	limiter := &fakeSendRateLimiter{}
+	service := NewService(&fakeTenantProvider{settings: tenant}, store, smsProvider, requestLogger, nil, Config{})
+	service.SetSendRateLimiter(limiter)
+
+	_, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "0912 123 4567"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "+989121234567", limiter.phone)
+	assert.Equal(t, "+989121234567", store.reserved.Phone)
+	assert.Equal(t, "+989121234567", requestLogger.createLog.Phone)
+	assert.Equal(t, "+989121234567", smsProvider.req.Phone)
+}
+
+func TestServiceSendOTPUsesDefaultPhoneRegion(t *testing.T) {
+	store := &fakeOTPStore{}
+	normalizer, err := NewPhoneNormalizer("IR")
+	require.NoError(t, err)
+	service := NewService(&fakeTenantProvider{settings: activeTenantSettings()}, store, &fakeSMSProvider{}, &fakeRequestLogger{}, nil, Config{})
+	service.SetPhoneNormalizer(normalizer)
+
+	_, err = service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "989121234567"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "+989121234567", store.reserved.Phone)
+}
+
+func TestServiceSendOTPInvalidPhone(t *testing.T) {
+	store := &fakeOTPStore{}
+	requestLogger := &fakeRequestLogger{}
+	limiter := &fakeSendRateLimiter{}
+	lockout := &fakePhoneLockout{}
+	service := NewService(&fakeTenantProvider{settings: activeTenantSettings()}, store, &fakeSMSProvider{}, requestLogger, nil, Config{})
+	service.SetSendRateLimiter(limiter)
+	service.SetPhoneLockout(lockout)
+
+	// The tenant has no phone region, so national numbers cannot be read.
+	resp, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "09121234567"})
+
+	require.Nil(t, resp)
+	assert.ErrorIs(t, err, ErrInvalidPhone)
+	assert.Equal(t, 0, lockout.checkCalls)
+	assert.Equal(t, 0, store.getCalls)
+	assert.Equal(t, 0, limiter.calls)
+	assert.Equal(t, 0, requestLogger.createCalls)
+}
+
+func TestServiceVerifyOTPNormalizesPhone(t *testing.T) {
+	tenant := activeTenantSettings()
+	tenant.Metadata = map[string]interface{}{PhoneRegionMetadataKey: "IR"}
+	tenantProvider := &fakeTenantProvider{settings: tenant}
+	store := &fakeOTPStore{getErr: ErrOTPNotFound}
+	verifyLogger := &fakeVerificationLogger{}
+	service := NewService(tenantProvider, store, nil, nil, verifyLogger, Config{})
+
+	_, err := service.VerifyOTP(context.Background(), VerifyRequest{TenantID: 42, Phone: "09121234567", Code: "123456"})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, tenantProvider.calls)
+	assert.Equal(t, "+989121234567", store.getPhone)
+	require.Len(t, verifyLogger.logs, 1)
+	assert.Equal(t, "+989121234567", verifyLogger.logs[0].Phone)
+
+	// International numbers need no tenant region.
+	_, err = service.VerifyOTP(context.Background(), VerifyRequest{TenantID: 42, Phone: "0098 912 123 4567", Code: "123456"})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, tenantProvider.calls)
+	assert.Equal(t, "+989121234567", store.getPhone)
+}
+
+func TestServiceVerifyOTPInvalidPhone(t *testing.T) {
+	tests := []struct {
+		name           string
+		tenantProvider *fakeTenantProvider
+		phone          string
+		wantErr        error
+	}{
+		{name: "invalid international number", tenantProvider: &fakeTenantProvider{settings: activeTenantSettings()}, phone: "+98912", wantErr: ErrInvalidPhone},
+		{name: "national number without region", tenantProvider: &fakeTenantProvider{settings: activeTenantSettings()}, phone: "09121234567", wantErr: ErrInvalidPhone},
+		{name: "tenant lookup error", tenantProvider: &fakeTenantProvider{err: errors.New("tenant store unavailable")}, phone: "09121234567"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeOTPStore{}
+			service := NewService(tt.tenantProvider, store, nil, nil, &fakeVerificationLogger{}, Config{})
+
+			resp, err := service.VerifyOTP(context.Background(), VerifyRequest{TenantID: 42, Phone: tt.phone, Code: "123456"})
+
+			require.Nil(t, resp)
+			require.Error(t, err)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+			assert.Equal(t, 0, store.getCalls)
+		})
+	}
+}
+
 func TestServiceVerifyOTPNotFound(t *testing.T) {
 	store := &fakeOTPStore{getErr: ErrOTPNotFound}
 	verifyLogger := &fakeVerificationLogger{}
@@ -851,6 +1765,204 @@ func TestServiceVerifyOTPMaxAttemptsFallback(t *testing.T) {
 	assertVerificationLog(t, verifyLogger, VerificationResultFailed, ReasonMaxAttemptsExceeded, "request-verify", 2)
 }
 
+func TestServiceVerifyOTPMaxAttemptsFallbackUsesTenantPolicy(t *testing.T) {
+	state := activeOTPState("123456")
+	state.AttemptCount = 1
+	state.MaxAttempts = 0
+	store := &fakeOTPStore{state: state, incrementResult: 2}
+	tenant := activeTenantSettings()
+	tenant.OTPPolicy = &Policy{MaxAttempts: 2}
+	tenantProvider := &fakeTenantProvider{settings: tenant}
+	verifyLogger := &fakeVerificationLogger{}
+	service := NewService(tenantProvider, store, nil, nil, verifyLogger, Config{MaxAttempts: 5})
+
+	resp, err := service.VerifyOTP(context.Background(), VerifyRequest{
+		TenantID: 42,
+		Phone:    "+989121234567",
+		Code:     "000000",
+	})
+
+	require.NoError(t, err)
+	assert.False(t, resp.Verified)
+	assert.Equal(t, ReasonMaxAttemptsExceeded, resp.Reason)
+	assert.Equal(t, 1, tenantProvider.calls)
+	assertVerificationLog(t, verifyLogger, VerificationResultFailed, ReasonMaxAttemptsExceeded, "request-verify", 2)
+}
+
+func TestServiceVerifyOTPMaxAttemptsFallbackTenantLookupError(t *testing.T) {
+	state := activeOTPState("123456")
+	state.AttemptCount = 1
+	state.MaxAttempts = 0
+	store := &fakeOTPStore{state: state, incrementResult: 2}
+	tenantProvider := &fakeTenantProvider{err: errors.New("tenant lookup failed")}
+	verifyLogger := &fakeVerificationLogger{}
+	service := NewService(tenantProvider, store, nil, nil, verifyLogger, Config{MaxAttempts: 5})
+
+	resp, err := service.VerifyOTP(context.Background(), VerifyRequest{
+		TenantID: 42,
+		Phone:    "+989121234567",
+		Code:     "000000",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, ReasonInvalidCode, resp.Reason)
+	assertVerificationLog(t, verifyLogger, VerificationResultFailed, ReasonInvalidCode, "request-verify", 2)
+}
+
+func TestServiceSendOTPPhoneLocked(t *testing.T) {
+	store := &fakeOTPStore{}
+	limiter := &fakeSendRateLimiter{}
+	smsProvider := &fakeSMSProvider{}
+	lockout := &fakePhoneLockout{checkErr: &LimitError{Err: ErrPhoneLocked, Limit: 10, ResetAfter: 15 * time.Minute}}
+	service := NewService(&fakeTenantProvider{settings: activeTenantSettings()}, store, smsProvider, nil, nil, Config{})
+	service.SetSendRateLimiter(limiter)
+	service.SetPhoneLockout(lockout)
+
+	resp, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	require.Nil(t, resp)
+	require.ErrorIs(t, err, ErrPhoneLocked)
+	var limitErr *LimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, 15*time.Minute, limitErr.ResetAfter)
+	assert.Equal(t, 0, store.getCalls)
+	assert.Equal(t, 0, limiter.calls)
+	assert.Equal(t, 0, smsProvider.calls)
+}
+
+func TestServiceSendOTPLockoutInfrastructureError(t *testing.T) {
+	lockoutErr := errors.New("redis unavailable")
+	service := NewService(&fakeTenantProvider{settings: activeTenantSettings()}, &fakeOTPStore{}, &fakeSMSProvider{}, nil, nil, Config{})
+	service.SetPhoneLockout(&fakePhoneLockout{checkErr: lockoutErr})
+
+	_, err := service.SendOTP(context.Background(), SendRequest{TenantID: 42, Phone: "+989121234567"})
+
+	require.ErrorIs(t, err, lockoutErr)
+	assert.NotErrorIs(t, err, ErrPhoneLocked)
+}
+
+func TestServiceVerifyOTPPhoneLocked(t *testing.T) {
+	store := &fakeOTPStore{state: activeOTPState("123456")}
+	verifyLogger := &fakeVerificationLogger{}
+	service := NewService(nil, store, nil, nil, verifyLogger, Config{})
+	service.SetPhoneLockout(&fakePhoneLockout{checkErr: &LimitError{Err: ErrPhoneLocked, Limit: 10, ResetAfter: time.Hour}})
+
+	resp, err := service.VerifyOTP(context.Background(), VerifyRequest{TenantID: 42, Phone: "+989121234567", Code: "123456"})
+
+	require.Nil(t, resp)
+	require.ErrorIs(t, err, ErrPhoneLocked)
+	assert.Equal(t, 0, store.getCalls)
+	assert.Equal(t, 0, verifyLogger.calls)
+}
+
+func TestServiceVerifyOTPInvalidCodeRecordsFailure(t *testing.T) {
+	store := &fakeOTPStore{state: activeOTPState("123456"), incrementResult: 1}
+	lockout := &fakePhoneLockout{}
+	service := NewService(nil, store, nil, nil, &fakeVerificationLogger{}, Config{})
+	service.SetPhoneLockout(lockout)
+
+	resp, err := service.VerifyOTP(context.Background(), VerifyRequest{TenantID: 42, Phone: "+989121234567", Code: "000000"})
+
+	require.NoError(t, err)
+	assert.Equal(t, ReasonInvalidCode, resp.Reason)
+	assert.Equal(t, 1, lockout.failureCalls)
+	assert.Equal(t, 0, lockout.resetCalls)
+}
+
+func TestServiceVerifyOTPFailureLocksPhone(t *testing.T) {
+	store := &fakeOTPStore{state: activeOTPState("123456"), incrementResult: 1}
+	verifyLogger := &fakeVerificationLogger{}
+	service := NewService(nil, store, nil, nil, verifyLogger, Config{})
+	service.SetPhoneLockout(&fakePhoneLockout{failureErr: &LimitError{Err: ErrPhoneLocked, Limit: 10, ResetAfter: 15 * time.Minute}})
+
+	resp, err := service.VerifyOTP(context.Background(), VerifyRequest{TenantID: 42, Phone: "+989121234567", Code: "000000"})
+
+	require.NoError(t, err)
+	assert.False(t, resp.Verified)
+	assert.Equal(t, ReasonPhoneLocked, resp.Reason)
+	// The code that was being guessed is discarded along with the lock.
+	assert.Equal(t, 1, store.deleteCalls)
+	assertVerificationLog(t, verifyLogger, VerificationResultFailed, ReasonPhoneLocked, "request-verify", 1)
+}
+
+func TestServiceVerifyOTPRecordFailureError(t *testing.T) {
+	lockoutErr := errors.New("redis unavailable")
+	store := &fakeOTPStore{state: activeOTPState("123456"), incrementResult: 1}
+	service := NewService(nil, store, nil, nil, nil, Config{})
+	service.SetPhoneLockout(&fakePhoneLockout{failureErr: lockoutErr})
+
+	resp, err := service.VerifyOTP(context.Background(), VerifyRequest{TenantID: 42, Phone: "+989121234567", Code: "000000"})
+
+	require.Nil(t, resp)
+	require.ErrorIs(t, err, lockoutErr)
+}
+
+func TestServiceVerifyOTPSuccessResetsFailures(t *testing.T) {
+	lockout := &fakePhoneLockout{}
+	service := NewService(nil, &fakeOTPStore{state: activeOTPState("123456")}, nil, nil, nil, Config{})
+	service.SetPhoneLockout(lockout)
+
+	resp, err := service.VerifyOTP(context.Background(), VerifyRequest{TenantID: 42, Phone: "+989121234567", Code: "123456"})
+
+	require.NoError(t, err)
+	assert.True(t, resp.Verified)
+	assert.Equal(t, 1, lockout.resetCalls)
+	assert.Equal(t, 0, lockout.failureCalls)
+}
+
+const testAdminToken = "tenant-42-admin-token"
+
+// adminTenantSettings returns an active tenant whose admin token is testAdminToken.
+func adminTenantSettings() *TenantSettings {
+	tenant := activeTenantSettings()
+	sum := sha256.Sum256([]byte(testAdminToken))
+	tenant.Metadata = map[string]interface{}{AdminTokenHashMetadataKey: hex.EncodeToString(sum[:])}
+	return tenant
+}
+
+func TestServiceUnlockPhone(t *testing.T) {
+	lockout := &fakePhoneLockout{}
+	service := NewService(&fakeTenantProvider{settings: adminTenantSettings()}, &fakeOTPStore{}, nil, nil, nil, Config{})
+	service.SetPhoneLockout(lockout)
+
+	require.NoError(t, service.UnlockPhone(context.Background(), UnlockRequest{TenantID: 42, Phone: "+989121234567", AdminToken: testAdminToken}))
+	assert.Equal(t, 1, lockout.unlockCalls)
+}
+
+func TestServiceUnlockPhoneErrors(t *testing.T) {
+	unlockErr := errors.New("redis unavailable")
+	tests := []struct {
+		name    string
+		tenants *fakeTenantProvider
+		lockout *fakePhoneLockout
+		req     UnlockRequest
+		want    error
+	}{
+		{name: "missing tenant id", tenants: &fakeTenantProvider{}, lockout: &fakePhoneLockout{}, req: UnlockRequest{Phone: "+989121234567"}},
+		{name: "missing phone", tenants: &fakeTenantProvider{}, lockout: &fakePhoneLockout{}, req: UnlockRequest{TenantID: 42}},
+		{name: "unknown tenant", tenants: &fakeTenantProvider{}, lockout: &fakePhoneLockout{}, req: UnlockRequest{TenantID: 42, Phone: "+989121234567"}, want: ErrTenantNotFound},
+		{name: "unlock failure", tenants: &fakeTenantProvider{settings: adminTenantSettings()}, lockout: &fakePhoneLockout{unlockErr: unlockErr}, req: UnlockRequest{TenantID: 42, Phone: "+989121234567", AdminToken: testAdminToken}, want: unlockErr},
+		{name: "invalid phone", tenants: &fakeTenantProvider{settings: adminTenantSettings()}, lockout: &fakePhoneLockout{}, req: UnlockRequest{TenantID: 42, Phone: "+98912", AdminToken: testAdminToken}, want: ErrInvalidPhone},
+		{name: "missing admin token", tenants: &fakeTenantProvider{settings: adminTenantSettings()}, lockout: &fakePhoneLockout{}, req: UnlockRequest{TenantID: 42, Phone: "+989121234567"}, want: ErrAdminUnauthorized},
+		{name: "wrong admin token", tenants: &fakeTenantProvider{settings: adminTenantSettings()}, lockout: &fakePhoneLockout{}, req: UnlockRequest{TenantID: 42, Phone: "+989121234567", AdminToken: "tenant-43-admin-token"}, want: ErrAdminUnauthorized},
+		{name: "tenant without admin token", tenants: &fakeTenantProvider{settings: activeTenantSettings()}, lockout: &fakePhoneLockout{}, req: UnlockRequest{TenantID: 42, Phone: "+989121234567", AdminToken: testAdminToken}, want: ErrAdminUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewService(tt.tenants, &fakeOTPStore{}, nil, nil, nil, Config{})
+			service.SetPhoneLockout(tt.lockout)
+
+			err := service.UnlockPhone(context.Background(), tt.req)
+
+			require.Error(t, err)
+			if tt.want != nil {
+				assert.ErrorIs(t, err, tt.want)
+			}
+		})
+	}
+}
+
 func activeTenantSettings() *TenantSettings {
 	return &TenantSettings{
 		ID:              42,
@@ -864,6 +1976,14 @@ func activeTenantSettings() *TenantSettings {
 	}
 }
 
+func providersWithCredentials(names ...string) map[string]ProviderSMSSettings {
+	providers := make(map[string]ProviderSMSSettings, len(names))
+	for _, name := range names {
+		providers[name] = ProviderSMSSettings{HasCredentials: true}
+	}
+	return providers
+}
+
 func assertVerificationLog(t *testing.T, logger *fakeVerificationLogger, result string, reason string, requestID string, attemptCount int) {
 	t.Helper()
 
@@ -885,6 +2005,7 @@ func activeOTPState(code string) *OTPState {
 		RequestID:    "request-verify",
 		TenantID:     42,
 		Phone:        "+989121234567",
+		Purpose:      PurposeDefault,
 		CodeHash:     HashCode(code),
 		AttemptCount: 0,
 		MaxAttempts:  3,
@@ -892,3 +2013,94 @@ func activeOTPState(code string) *OTPState {
 		ExpiresAt:    time.Now().UTC().Add(time.Minute),
 	}
 }
+
+func TestServiceVerifyOTPRecordsVerifiedRequest(t *testing.T) {
+	state := activeOTPState("123456")
+	state.AttemptCount = 1
+	requestLogger := &fakeRequestLogger{}
+	service := NewService(nil, &fakeOTPStore{state: state}, nil, requestLogger, nil, Config{})
+
+	resp, err := service.VerifyOTP(context.Background(), VerifyRequest{TenantID: 42, Phone: "+989121234567", Code: "123456"})
+
+	require.NoError(t, err)
+	assert.True(t, resp.Verified)
+	require.Len(t, requestLogger.resultLogs, 1)
+	result := requestLogger.resultLogs[0]
+	assert.Equal(t, "request-verify", result.RequestID)
+	assert.Equal(t, RequestStatusVerified, result.Status)
+	assert.Empty(t, result.ErrorMessage)
+	assert.Equal(t, 1, result.AttemptCount)
+	assert.False(t, result.VerifiedAt.IsZero())
+	assert.Equal(t, result.VerifiedAt, result.UpdatedAt)
+}
+
+func TestServiceVerifyOTPRecordsTerminalFailures(t *testing.T) {
+	expired := activeOTPState("123456")
+	expired.ExpiresAt = time.Now().UTC().Add(-time.Second)
+	exhausted := activeOTPState("123456")
+	exhausted.AttemptCount = 3
+	lastAttempt := activeOTPState("123456")
+	lastAttempt.AttemptCount = 2
+
+	tests := []struct {
+		name     string
+		state    *OTPState
+		lockout  *fakePhoneLockout
+		reason   string
+		attempts int
+	}{
+		{name: "expired", state: expired, reason: ReasonExpired},
+		{name: "max attempts already reached", state: exhausted, reason: ReasonMaxAttemptsExceeded, attempts: 3},
+		{name: "max attempts reached", state: lastAttempt, reason: ReasonMaxAttemptsExceeded, attempts: 3},
+		{
+			name:     "phone locked",
+			state:    activeOTPState("123456"),
+			lockout:  &fakePhoneLockout{failureErr: &LimitError{Err: ErrPhoneLocked}},
+			reason:   ReasonPhoneLocked,
+			attempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requestLogger := &fakeRequestLogger{}
+			service := NewService(nil, &fakeOTPStore{state: tt.state}, nil, requestLogger, nil, Config{})
+			if tt.lockout != nil {
+				service.SetPhoneLockout(tt.lockout)
+			}
+
+			resp, err := service.VerifyOTP(context.Background(), VerifyRequest{TenantID: 42, Phone: "+989121234567", Code: "000000"})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.reason, resp.Reason)
+			require.Len(t, requestLogger.resultLogs, 1)
+			result := requestLogger.resultLogs[0]
+			assert.Equal(t, RequestStatusVerifyFailed, result.Status)
+			assert.Equal(t, tt.reason, result.ErrorMessage)
+			assert.Equal(t, tt.attempts, result.AttemptCount)
+			assert.True(t, result.VerifiedAt.IsZero())
+		})
+	}
+}
+
+func TestServiceVerifyOTPInvalidCodeDoesNotRecordResult(t *testing.T) {
+	requestLogger := &fakeRequestLogger{}
+	service := NewService(nil, &fakeOTPStore{state: activeOTPState("123456")}, nil, requestLogger, nil, Config{})
+
+	resp, err := service.VerifyOTP(context.Background(), VerifyRequest{TenantID: 42, Phone: "+989121234567", Code: "000000"})
+
+	require.NoError(t, err)
+	assert.Equal(t, ReasonInvalidCode, resp.Reason)
+	assert.Empty(t, requestLogger.resultLogs)
+}
+
+func TestServiceVerifyOTPResultLogErrorDoesNotChangeResponse(t *testing.T) {
+	requestLogger := &fakeRequestLogger{resultErr: errors.New("database down")}
+	service := NewService(nil, &fakeOTPStore{state: activeOTPState("123456")}, nil, requestLogger, nil, Config{})
+
+	resp, err := service.VerifyOTP(context.Background(), VerifyRequest{TenantID: 42, Phone: "+989121234567", Code: "123456"})
+
+	require.NoError(t, err)
+	assert.True(t, resp.Verified)
+	assert.Len(t, requestLogger.resultLogs, 1)
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-backend-service/internal/otp"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisIPRateLimiter limits requests per client IP and scope with a Redis fixed window.
+type RedisIPRateLimiter struct {
+	client *redis.Client
+	limit  int
+	window time.Duration
+}
+
+// NewRedisIPRateLimiter creates a Redis-backed client IP rate limiter.
+func NewRedisIPRateLimiter(client *redis.Client, limit int, window time.Duration) *RedisIPRateLimiter {
+	return &RedisIPRateLimiter{
+		client: client,
+		limit:  limit,
+		window: window,
+	}
+}
+
+// AllowIP returns nil when a request is allowed, or an *otp.LimitError wrapping
+// otp.ErrIPRateLimited when the limit is exceeded.
+func (l *RedisIPRateLimiter) AllowIP(ctx context.Context, scope string, ip string) error {
+	if l.client == nil {
+		return fmt.Errorf("redis ip rate limiter: client is nil")
+	}
+	if l.limit <= 0 {
+		return fmt.Errorf("redis ip rate limiter: limit must be positive")
+	}
+	if l.window <= 0 {
+		return fmt.Errorf("redis ip rate limiter: window must be positive")
+	}
+
+	key := redisIPRateLimitKey(scope, ip)
+	count, resetAfter, err := runFixedWindow(ctx, l.client, key, l.window)
+	if err != nil {
+		return fmt.Errorf("redis ip rate limiter allow ip: %w", err)
+	}
+	if count > int64(l.limit) {
+		return &otp.LimitError{Err: otp.ErrIPRateLimited, Limit: l.limit, ResetAfter: resetAfter}
+	}
+
+	return nil
+}
+
+func redisIPRateLimitKey(scope string, ip string) string {
+	return fmt.Sprintf("otp:rate:ip:%s:%s", scope, ip)
+}
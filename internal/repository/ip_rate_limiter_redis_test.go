@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-backend-service/internal/otp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisIPRateLimiterBlocksAfterLimit(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	ctx := context.Background()
+	ip := "203.0.113.10"
+	key := redisIPRateLimitKey("send", ip)
+	defer client.Del(ctx, key)
+	require.NoError(t, client.Del(ctx, key).Err())
+
+	limiter := NewRedisIPRateLimiter(client, 2, time.Minute)
+
+	require.NoError(t, limiter.AllowIP(ctx, "send", ip))
+	require.NoError(t, limiter.AllowIP(ctx, "send", ip))
+	err := limiter.AllowIP(ctx, "send", ip)
+
+	assert.ErrorIs(t, err, otp.ErrIPRateLimited)
+	var limitErr *otp.LimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, 2, limitErr.Limit)
+	assert.Greater(t, limitErr.ResetAfter, time.Duration(0))
+	assert.LessOrEqual(t, limitErr.ResetAfter, time.Minute)
+}
+
+func TestRedisIPRateLimiterIsolatesScopesAndIPs(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	ctx := context.Background()
+	keys := []string{
+		redisIPRateLimitKey("send", "203.0.113.11"),
+		redisIPRateLimitKey("verify", "203.0.113.11"),
+		redisIPRateLimitKey("send", "2001:db8::11"),
+	}
+	defer client.Del(ctx, keys...)
+	require.NoError(t, client.Del(ctx, keys...).Err())
+
+	limiter := NewRedisIPRateLimiter(client, 1, time.Minute)
+
+	require.NoError(t, limiter.AllowIP(ctx, "send", "203.0.113.11"))
+	require.NoError(t, limiter.AllowIP(ctx, "verify", "203.0.113.11"))
+	require.NoError(t, limiter.AllowIP(ctx, "send", "2001:db8::11"))
+	assert.ErrorIs(t, limiter.AllowIP(ctx, "send", "203.0.113.11"), otp.ErrIPRateLimited)
+}
+
+func TestRedisIPRateLimiterInvalidConfig(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	ctx := context.Background()
+
+	assert.Error(t, NewRedisIPRateLimiter(nil, 1, time.Minute).AllowIP(ctx, "send", "203.0.113.12"))
+	assert.Error(t, NewRedisIPRateLimiter(client, 0, time.Minute).AllowIP(ctx, "send", "203.0.113.12"))
+	assert.Error(t, NewRedisIPRateLimiter(client, 1, 0).AllowIP(ctx, "send", "203.0.113.12"))
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-backend-service/internal/otp"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisGlobalPhoneRateLimiter limits OTP sends per phone across all tenants with a
+// Redis fixed window, so one victim number cannot be pumped through several tenants.
+type RedisGlobalPhoneRateLimiter struct {
+	phoneKeys
+	client *redis.Client
+	limit  int
+	window time.Duration
+}
+
+// NewRedisGlobalPhoneRateLimiter creates a Redis-backed cross-tenant phone rate limiter.
+func NewRedisGlobalPhoneRateLimiter(client *redis.Client, limit int, window time.Duration) *RedisGlobalPhoneRateLimiter {
+	return &RedisGlobalPhoneRateLimiter{
+		client: client,
+		limit:  limit,
+		window: window,
+	}
+}
+
+// AllowPhoneSend returns nil when an OTP send is allowed, or an *otp.LimitError wrapping
+// otp.ErrOTPRateLimited when the phone exceeded the limit across all tenants.
+func (l *RedisGlobalPhoneRateLimiter) AllowPhoneSend(ctx context.Context, phone string) error {
+	if l.client == nil {
+		return fmt.Errorf("redis global phone rate limiter: client is nil")
+	}
+	if l.limit <= 0 {
+		return fmt.Errorf("redis global phone rate limiter: limit must be positive")
+	}
+	if l.window <= 0 {
+		return fmt.Errorf("redis global phone rate limiter: window must be positive")
+	}
+
+	key := redisGlobalPhoneRateLimitKey(l.globalPhoneKey(phone))
+	count, resetAfter, err := runFixedWindow(ctx, l.client, key, l.window)
+	if err != nil {
+		return fmt.Errorf("redis global phone rate limiter allow send: %w", err)
+	}
+	if count > int64(l.limit) {
+		return &otp.LimitError{Err: otp.ErrOTPRateLimited, Limit: l.limit, ResetAfter: resetAfter}
+	}
+
+	return nil
+}
+
+func redisGlobalPhoneRateLimitKey(phoneKey string) string {
+	return fmt.Sprintf("otp:rate:phone:%s", phoneKey)
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-backend-service/internal/otp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisGlobalPhoneRateLimiterBlocksAfterLimit(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	ctx := context.Background()
+	phone := "+989123335001"
+	key := redisGlobalPhoneRateLimitKey(phone)
+	defer client.Del(ctx, key)
+	require.NoError(t, client.Del(ctx, key).Err())
+
+	limiter := NewRedisGlobalPhoneRateLimiter(client, 2, time.Hour)
+
+	require.NoError(t, limiter.AllowPhoneSend(ctx, phone))
+	require.NoError(t, limiter.AllowPhoneSend(ctx, phone))
+	err := limiter.AllowPhoneSend(ctx, phone)
+
+	assert.ErrorIs(t, err, otp.ErrOTPRateLimited)
+	var limitErr *otp.LimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, 2, limitErr.Limit)
+	assert.Greater(t, limitErr.ResetAfter, time.Duration(0))
+	assert.LessOrEqual(t, limitErr.ResetAfter, time.Hour)
+
+	ttl, err := client.PTTL(ctx, key).Result()
+	require.NoError(t, err)
+	assert.Greater(t, ttl, time.Duration(0))
+	assert.LessOrEqual(t, ttl, time.Hour)
+}
+
+func TestRedisGlobalPhoneRateLimiterIsolatesPhones(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	ctx := context.Background()
+	keyA := redisGlobalPhoneRateLimitKey("+989123335002")
+	keyB := redisGlobalPhoneRateLimitKey("+989123335003")
+	defer client.Del(ctx, keyA, keyB)
+	require.NoError(t, client.Del(ctx, keyA, keyB).Err())
+
+	limiter := NewRedisGlobalPhoneRateLimiter(client, 1, time.Hour)
+
+	require.NoError(t, limiter.AllowPhoneSend(ctx, "+989123335002"))
+	require.NoError(t, limiter.AllowPhoneSend(ctx, "+989123335003"))
+	assert.ErrorIs(t, limiter.AllowPhoneSend(ctx, "+989123335002"), otp.ErrOTPRateLimited)
+}
+
+func TestRedisGlobalPhoneRateLimiterInvalidConfig(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	ctx := context.Background()
+
+	assert.Error(t, NewRedisGlobalPhoneRateLimiter(nil, 1, time.Hour).AllowPhoneSend(ctx, "+989123335004"))
+	assert.Error(t, NewRedisGlobalPhoneRateLimiter(client, 0, time.Hour).AllowPhoneSend(ctx, "+989123335004"))
+	assert.Error(t, NewRedisGlobalPhoneRateLimiter(client, 1, 0).AllowPhoneSend(ctx, "+989123335004"))
+}
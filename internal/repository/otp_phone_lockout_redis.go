@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go-backend-service/internal/otp"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisPhoneLockout locks a tenant's phone after repeated failed verifications,
+// counting failures across OTP lifetimes. Each lockout moves one step up durations;
+// the escalation level is forgotten one failure window after the last lock ends.
+type RedisPhoneLockout struct {
+	legacyPhoneKeys
+	client        *redis.Client
+	maxFailures   int
+	failureWindow time.Duration
+	durations     []time.Duration
+}
+
+// otpPhoneLockoutScript counts a failure and returns the lock duration in milliseconds,
+// or 0 when the phone is not locked yet.
+// ARGV: max_failures, failure_window_ms, lock durations in ms from the first level up.
+var otpPhoneLockoutScript = redis.NewScript(`
+local failures = redis.call("INCR", KEYS[1])
+if failures == 1 or redis.call("PTTL", KEYS[1]) < 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+if failures < tonumber(ARGV[1]) then
+	return 0
+end
+local level = redis.call("INCR", KEYS[2])
+local duration = tonumber(ARGV[math.min(level, #ARGV - 2) + 2])
+redis.call("SET", KEYS[3], level, "PX", duration)
+redis.call("PEXPIRE", KEYS[2], duration + tonumber(ARGV[2]))
+redis.call("DEL", KEYS[1])
+return duration
+`)
+
+// NewRedisPhoneLockout creates a Redis-backed phone lockout, e.g. locking for 15m, then 1h,
+// then 24h each time maxFailures verifications fail within failureWindow.
+func NewRedisPhoneLockout(client *redis.Client, maxFailures int, failureWindow time.Duration, durations []time.Duration) *RedisPhoneLockout {
+	return &RedisPhoneLockout{
+		client:        client,
+		maxFailures:   maxFailures,
+		failureWindow: failureWindow,
+		durations:     durations,
+	}
+}
+
+// Check returns an *otp.LimitError wrapping otp.ErrPhoneLocked while the phone is locked.
+// With legacy reads on, a lock set under the plaintext phone key still applies.
+func (l *RedisPhoneLockout) Check(ctx context.Context, tenantID int64, phone string) error {
+	if l.client == nil {
+		return fmt.Errorf("redis phone lockout: client is nil")
+	}
+
+	var locked time.Duration
+	for _, phoneKey := range l.tenantPhoneKeys(tenantID, phone) {
+		ttl, err := l.client.PTTL(ctx, redisPhoneLockKey(tenantID, phoneKey)).Result()
+		if err != nil {
+			return fmt.Errorf("redis phone lockout check: %w", err)
+		}
+		if ttl > locked {
+			locked = ttl
+		}
+	}
+	if locked > 0 {
+		return &otp.LimitError{Err: otp.ErrPhoneLocked, Limit: l.maxFailures, ResetAfter: locked}
+	}
+	return nil
+}
+
+// RecordFailure counts a failed verification and returns an *otp.LimitError wrapping
+// otp.ErrPhoneLocked when it locks the phone.
+func (l *RedisPhoneLockout) RecordFailure(ctx context.Context, tenantID int64, phone string) error {
+	if err := l.validate(); err != nil {
+		return err
+	}
+
+	args := make([]interface{}, 0, len(l.durations)+2)
+	args = append(args, strconv.Itoa(l.maxFailures), strconv.FormatInt(l.failureWindow.Milliseconds(), 10))
+	for _, duration := range l.durations {
+		args = append(args, strconv.FormatInt(duration.Milliseconds(), 10))
+	}
+
+	phoneKey := l.tenantPhoneKey(tenantID, phone)
+	keys := []string{
+		redisPhoneLockoutFailuresKey(tenantID, phoneKey),
+		redisPhoneLockoutLevelKey(tenantID, phoneKey),
+		redisPhoneLockKey(tenantID, phoneKey),
+	}
+	lockMs, err := otpPhoneLockoutScript.Run(ctx, l.client, keys, args...).Int64()
+	if err != nil {
+		return fmt.Errorf("redis phone lockout record failure: %w", err)
+	}
+	if lockMs > 0 {
+		return &otp.LimitError{Err: otp.ErrPhoneLocked, Limit: l.maxFailures, ResetAfter: time.Duration(lockMs) * time.Millisecond}
+	}
+	return nil
+}
+
+// Reset forgets failures after a successful verification; an active lock and the
+// escalation level stay.
+func (l *RedisPhoneLockout) Reset(ctx context.Context, tenantID int64, phone string) error {
+	if l.client == nil {
+		return fmt.Errorf("redis phone lockout: client is nil")
+	}
+	keys := make([]string, 0, 2)
+	for _, phoneKey := range l.tenantPhoneKeys(tenantID, phone) {
+		keys = append(keys, redisPhoneLockoutFailuresKey(tenantID, phoneKey))
+	}
+	if err := l.client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("redis phone lockout reset: %w", err)
+	}
+	return nil
+}
+
+// Unlock lifts the lock and forgets the failures and escalation level.
+func (l *RedisPhoneLockout) Unlock(ctx context.Context, tenantID int64, phone string) error {
+	if l.client == nil {
+		return fmt.Errorf("redis phone lockout: client is nil")
+	}
+	keys := make([]string, 0, 6)
+	for _, phoneKey := range l.tenantPhoneKeys(tenantID, phone) {
+		keys = append(keys,
+			redisPhoneLockoutFailuresKey(tenantID, phoneKey),
+			redisPhoneLockoutLevelKey(tenantID, phoneKey),
+			redisPhoneLockKey(tenantID, phoneKey),
+		)
+	}
+	if err := l.client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("redis phone lockout unlock: %w", err)
+	}
+	return nil
+}
+
+func (l *RedisPhoneLockout) validate() error {
+	if l.client == nil {
+		return fmt.Errorf("redis phone lockout: client is nil")
+	}
+	if l.maxFailures <= 0 {
+		return fmt.Errorf("redis phone lockout: max failures must be positive")
+	}
+	if l.failureWindow <= 0 {
+		return fmt.Errorf("redis phone lockout: failure window must be positive")
+	}
+	if len(l.durations) == 0 {
+		return fmt.Errorf("redis phone lockout: at least one lock duration is required")
+	}
+	for _, duration := range l.durations {
+		if duration < time.Millisecond {
+			return fmt.Errorf("redis phone lockout: lock durations must be at least 1ms")
+		}
+	}
+	return nil
+}
+
+func redisPhoneLockoutFailuresKey(tenantID int64, phoneKey string) string {
+	return fmt.Sprintf("otp:lockout:failures:%d:%s", tenantID, phoneKey)
+}
+
+func redisPhoneLockoutLevelKey(tenantID int64, phoneKey string) string {
+	return fmt.Sprintf("otp:lockout:level:%d:%s", tenantID, phoneKey)
+}
+
+func redisPhoneLockKey(tenantID int64, phoneKey string) string {
+	return fmt.Sprintf("otp:lockout:lock:%d:%s", tenantID, phoneKey)
+}
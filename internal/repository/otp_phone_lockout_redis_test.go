@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-backend-service/internal/otp"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cleanPhoneLockoutKeys(t *testing.T, client *redis.Client, tenantID int64, phone string) {
+	t.Helper()
+	ctx := context.Background()
+	keys := []string{
+		redisPhoneLockoutFailuresKey(tenantID, phone),
+		redisPhoneLockoutLevelKey(tenantID, phone),
+		redisPhoneLockKey(tenantID, phone),
+	}
+	require.NoError(t, client.Del(ctx, keys...).Err())
+	t.Cleanup(func() { client.Del(ctx, keys...) })
+}
+
+func TestRedisPhoneLockoutLocksAfterMaxFailures(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	ctx := context.Background()
+	tenantID := int64(3301)
+	phone := "+989123335001"
+	cleanPhoneLockoutKeys(t, client, tenantID, phone)
+
+	lockout := NewRedisPhoneLockout(client, 3, time.Hour, []time.Duration{15 * time.Minute, time.Hour})
+
+	require.NoError(t, lockout.RecordFailure(ctx, tenantID, phone))
+	require.NoError(t, lockout.RecordFailure(ctx, tenantID, phone))
+	require.NoError(t, lockout.Check(ctx, tenantID, phone))
+
+	err := lockout.RecordFailure(ctx, tenantID, phone)
+	require.ErrorIs(t, err, otp.ErrPhoneLocked)
+	var limitErr *otp.LimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, 3, limitErr.Limit)
+	assert.Equal(t, 15*time.Minute, limitErr.ResetAfter)
+
+	err = lockout.Check(ctx, tenantID, phone)
+	require.ErrorAs(t, err, &limitErr)
+	assert.Greater(t, limitErr.ResetAfter, 14*time.Minute)
+	assert.LessOrEqual(t, limitErr.ResetAfter, 15*time.Minute)
+}
+
+func TestRedisPhoneLockoutEscalates(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	ctx := context.Background()
+	tenantID := int64(3302)
+	phone := "+989123335002"
+	cleanPhoneLockoutKeys(t, client, tenantID, phone)
+
+	lockout := NewRedisPhoneLockout(client, 1, time.Hour, []time.Duration{15 * time.Minute, time.Hour, 24 * time.Hour})
+	lockDuration := func() time.Duration {
+		t.Helper()
+		var limitErr *otp.LimitError
+		require.ErrorAs(t, lockout.RecordFailure(ctx, tenantID, phone), &limitErr)
+		return limitErr.ResetAfter
+	}
+
+	assert.Equal(t, 15*time.Minute, lockDuration())
+	assert.Equal(t, time.Hour, lockDuration())
+	assert.Equal(t, 24*time.Hour, lockDuration())
+	// The last step repeats.
+	assert.Equal(t, 24*time.Hour, lockDuration())
+
+	ttl, err := client.PTTL(ctx, redisPhoneLockoutLevelKey(tenantID, phone)).Result()
+	require.NoError(t, err)
+	assert.Greater(t, ttl, 24*time.Hour)
+	assert.LessOrEqual(t, ttl, 25*time.Hour)
+}
+
+func TestRedisPhoneLockoutResetKeepsLockAndLevel(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	ctx := context.Background()
+	tenantID := int64(3303)
+	phone := "+989123335003"
+	cleanPhoneLockoutKeys(t, client, tenantID, phone)
+
+	lockout := NewRedisPhoneLockout(client, 2, time.Hour, []time.Duration{15 * time.Minute, time.Hour})
+
+	require.NoError(t, lockout.RecordFailure(ctx, tenantID, phone))
+	require.NoError(t, lockout.Reset(ctx, tenantID, phone))
+	require.NoError(t, lockout.RecordFailure(ctx, tenantID, phone))
+	assert.ErrorIs(t, lockout.RecordFailure(ctx, tenantID, phone), otp.ErrPhoneLocked)
+
+	require.NoError(t, lockout.Reset(ctx, tenantID, phone))
+	assert.ErrorIs(t, lockout.Check(ctx, tenantID, phone), otp.ErrPhoneLocked)
+	level, err := client.Get(ctx, redisPhoneLockoutLevelKey(tenantID, phone)).Int()
+	require.NoError(t, err)
+	assert.Equal(t, 1, level)
+}
+
+func TestRedisPhoneLockoutUnlock(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	ctx := context.Background()
+	tenantID := int64(3304)
+	phone := "+989123335004"
+	cleanPhoneLockoutKeys(t, client, tenantID, phone)
+
+	lockout := NewRedisPhoneLockout(client, 1, time.Hour, []time.Duration{15 * time.Minute, time.Hour})
+	require.ErrorIs(t, lockout.RecordFailure(ctx, tenantID, phone), otp.ErrPhoneLocked)
+
+	require.NoError(t, lockout.Unlock(ctx, tenantID, phone))
+
+	require.NoError(t, lockout.Check(ctx, tenantID, phone))
+	// Escalation starts over after an admin unlock.
+	var limitErr *otp.LimitError
+	require.ErrorAs(t, lockout.RecordFailure(ctx, tenantID, phone), &limitErr)
+	assert.Equal(t, 15*time.Minute, limitErr.ResetAfter)
+}
+
+func TestRedisPhoneLockoutIsolatesTenantsAndPhones(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	ctx := context.Background()
+	phone := "+989123335005"
+	otherPhone := "+989123335006"
+	cleanPhoneLockoutKeys(t, client, 3305, phone)
+	cleanPhoneLockoutKeys(t, client, 3306, phone)
+	cleanPhoneLockoutKeys(t, client, 3305, otherPhone)
+
+	lockout := NewRedisPhoneLockout(client, 1, time.Hour, []time.Duration{time.Minute})
+	require.ErrorIs(t, lockout.RecordFailure(ctx, 3305, phone), otp.ErrPhoneLocked)
+
+	require.NoError(t, lockout.Check(ctx, 3306, phone))
+	require.NoError(t, lockout.Check(ctx, 3305, otherPhone))
+}
+
+func TestRedisPhoneLockoutInvalidConfig(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	ctx := context.Background()
+	durations := []time.Duration{time.Minute}
+
+	assert.Error(t, NewRedisPhoneLockout(nil, 1, time.Hour, durations).Check(ctx, 3307, "+989123335007"))
+	assert.Error(t, NewRedisPhoneLockout(nil, 1, time.Hour, durations).RecordFailure(ctx, 3307, "+989123335007"))
+	assert.Error(t, NewRedisPhoneLockout(client, 0, time.Hour, durations).RecordFailure(ctx, 3307, "+989123335007"))
+	assert.Error(t, NewRedisPhoneLockout(client, 1, 0, durations).RecordFailure(ctx, 3307, "+989123335007"))
+	assert.Error(t, NewRedisPhoneLockout(client, 1, time.Hour, nil).RecordFailure(ctx, 3307, "+989123335007"))
+}
+
+func TestRedisPhoneLockoutLegacyPhoneKeyReads(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	hasher, err := otp.NewPhoneKeyHasher([]byte("test-phone-key-secret"))
+	require.NoError(t, err)
+	ctx := context.Background()
+	tenantID := int64(3310)
+	phone := "+989123335010"
+	cleanPhoneLockoutKeys(t, client, tenantID, phone)
+	cleanPhoneLockoutKeys(t, client, tenantID, hasher.TenantKey(tenantID, phone))
+
+	durations := []time.Duration{15 * time.Minute}
+	require.ErrorIs(t, NewRedisPhoneLockout(client, 1, time.Hour, durations).RecordFailure(ctx, tenantID, phone), otp.ErrPhoneLocked)
+
+	lockout := NewRedisPhoneLockout(client, 1, time.Hour, durations)
+	lockout.SetPhoneKeyHasher(hasher)
+	require.NoError(t, lockout.Check(ctx, tenantID, phone))
+
+	lockout.SetLegacyPhoneKeyReads(true)
+	assert.ErrorIs(t, lockout.Check(ctx, tenantID, phone), otp.ErrPhoneLocked)
+
+	require.NoError(t, lockout.Unlock(ctx, tenantID, phone))
+	assert.NoError(t, lockout.Check(ctx, tenantID, phone))
+}
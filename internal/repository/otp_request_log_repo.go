@@ -10,7 +10,7 @@ import (
 	"go-backend-service/internal/otp"
 )
 
-// OTPRequestLogRepository persists OTP request and provider result logs.
+// OTPRequestLogRepository persists OTP request, provider result and verification result logs.
 type OTPRequestLogRepository struct {
 	db *sql.DB
 }
@@ -64,7 +64,8 @@ func (r *OTPRequestLogRepository) CreateRequest(ctx context.Context, log otp.OTP
 	return nil
 }
 
-// UpdateProviderResult updates provider result fields for an existing request.
+// UpdateProviderResult updates provider result fields for an existing request. The
+// resend count only moves forward, so a late first-delivery update cannot reset it.
 func (r *OTPRequestLogRepository) UpdateProviderResult(ctx context.Context, log otp.OTPProviderResultLog) error {
 	providerResponse, err := marshalJSONMap(log.ProviderResponse)
 	if err != nil {
@@ -82,8 +83,9 @@ func (r *OTPRequestLogRepository) UpdateProviderResult(ctx context.Context, log
 			provider_name = $2,
 			provider_response = $3::jsonb,
 			error_message = $4,
-			updated_at = $5
-		WHERE request_id = $6
+			resend_count = GREATEST(resend_count, $5),
+			updated_at = $6
+		WHERE request_id = $7
 	`
 
 	result, err := r.db.ExecContext(
@@ -93,6 +95,7 @@ func (r *OTPRequestLogRepository) UpdateProviderResult(ctx context.Context, log
 		log.ProviderName,
 		string(providerResponse),
 		nullableString(log.ErrorMessage),
+		log.ResendCount,
 		updatedAt,
 		log.RequestID,
 	)
@@ -111,6 +114,53 @@ func (r *OTPRequestLogRepository) UpdateProviderResult(ctx context.Context, log
 	return nil
 }
 
+// UpdateVerificationResult records how verification of an existing request ended. A
+// zero VerifiedAt leaves verified_at NULL.
+func (r *OTPRequestLogRepository) UpdateVerificationResult(ctx context.Context, log otp.OTPVerificationResultLog) error {
+	updatedAt := log.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = time.Now().UTC()
+	}
+	var verifiedAt interface{}
+	if !log.VerifiedAt.IsZero() {
+		verifiedAt = log.VerifiedAt
+	}
+
+	query := `
+		UPDATE otp_requests
+		SET status = $1,
+			error_message = $2,
+			attempt_count = $3,
+			verified_at = $4,
+			updated_at = $5
+		WHERE request_id = $6
+	`
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		log.Status,
+		nullableString(log.ErrorMessage),
+		log.AttemptCount,
+		verifiedAt,
+		updatedAt,
+		log.RequestID,
+	)
+	if err != nil {
+		return fmt.Errorf("update otp verification result: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("update otp verification result: rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("update otp verification result: request_id %q not found", log.RequestID)
+	}
+
+	return nil
+}
+
 func marshalJSONMap(value map[string]interface{}) ([]byte, error) {
 	if value == nil {
 		value = map[string]interface{}{}
@@ -190,3 +190,120 @@ func TestOTPRequestLogRepositoryCreateRequestDuplicateRequestID(t *testing.T) {
 func cleanupOTPRequestLog(ctx context.Context, db *sql.DB, requestID string) {
 	_, _ = db.ExecContext(ctx, "DELETE FROM otp_requests WHERE request_id = $1", requestID)
 }
+
+func TestOTPRequestLogRepositoryUpdateProviderResultResendCount(t *testing.T) {
+	testDB := setupOTPRequestLogTestDB(t)
+	if testDB == nil {
+		return
+	}
+	defer testDB.Close()
+
+	repo := NewOTPRequestLogRepository(testDB)
+	ctx := context.Background()
+	requestID := "test-resend-" + time.Now().UTC().Format("20060102150405.000000000")
+	defer cleanupOTPRequestLog(ctx, testDB, requestID)
+
+	require.NoError(t, repo.CreateRequest(ctx, otp.OTPRequestLog{
+		RequestID: requestID,
+		TenantID:  104,
+		Phone:     "+989121110104",
+		Status:    otp.RequestStatusPending,
+	}))
+	for _, resendCount := range []int{2, 1, 0} {
+		require.NoError(t, repo.UpdateProviderResult(ctx, otp.OTPProviderResultLog{
+			RequestID:    requestID,
+			Status:       otp.RequestStatusSent,
+			ProviderName: "fake",
+			ResendCount:  resendCount,
+		}))
+	}
+
+	var resendCount int
+	err := testDB.QueryRowContext(ctx, `
+		SELECT resend_count
+		FROM otp_requests
+		WHERE request_id = $1
+	`, requestID).Scan(&resendCount)
+	require.NoError(t, err)
+	assert.Equal(t, 2, resendCount)
+}
+
+func TestOTPRequestLogRepositoryUpdateVerificationResult(t *testing.T) {
+	testDB := setupOTPRequestLogTestDB(t)
+	if testDB == nil {
+		return
+	}
+	defer testDB.Close()
+
+	repo := NewOTPRequestLogRepository(testDB)
+	ctx := context.Background()
+	suffix := time.Now().UTC().Format("20060102150405.000000000")
+	verifiedID := "test-verified-" + suffix
+	failedID := "test-verify-failed-" + suffix
+	defer cleanupOTPRequestLog(ctx, testDB, verifiedID)
+	defer cleanupOTPRequestLog(ctx, testDB, failedID)
+
+	createdAt := time.Now().UTC().Add(-time.Minute).Truncate(time.Microsecond)
+	for _, requestID := range []string{verifiedID, failedID} {
+		require.NoError(t, repo.CreateRequest(ctx, otp.OTPRequestLog{
+			RequestID: requestID,
+			TenantID:  105,
+			Phone:     "+989121110105",
+			Status:    otp.RequestStatusSent,
+			CreatedAt: createdAt,
+		}))
+	}
+
+	verifiedAt := createdAt.Add(20 * time.Second)
+	require.NoError(t, repo.UpdateVerificationResult(ctx, otp.OTPVerificationResultLog{
+		RequestID:    verifiedID,
+		Status:       otp.RequestStatusVerified,
+		AttemptCount: 1,
+		VerifiedAt:   verifiedAt,
+	}))
+	require.NoError(t, repo.UpdateVerificationResult(ctx, otp.OTPVerificationResultLog{
+		RequestID:    failedID,
+		Status:       otp.RequestStatusVerifyFailed,
+		ErrorMessage: otp.ReasonMaxAttemptsExceeded,
+		AttemptCount: 3,
+	}))
+
+	var status string
+	var attemptCount int
+	var gotVerifiedAt sql.NullTime
+	var errorMessage sql.NullString
+	query := `
+		SELECT status, attempt_count, verified_at, error_message
+		FROM otp_requests
+		WHERE request_id = $1
+	`
+	require.NoError(t, testDB.QueryRowContext(ctx, query, verifiedID).Scan(&status, &attemptCount, &gotVerifiedAt, &errorMessage))
+	assert.Equal(t, otp.RequestStatusVerified, status)
+	assert.Equal(t, 1, attemptCount)
+	require.True(t, gotVerifiedAt.Valid)
+	assert.True(t, verifiedAt.Equal(gotVerifiedAt.Time))
+	assert.False(t, errorMessage.Valid)
+
+	require.NoError(t, testDB.QueryRowContext(ctx, query, failedID).Scan(&status, &attemptCount, &gotVerifiedAt, &errorMessage))
+	assert.Equal(t, otp.RequestStatusVerifyFailed, status)
+	assert.Equal(t, 3, attemptCount)
+	assert.False(t, gotVerifiedAt.Valid)
+	assert.Equal(t, otp.ReasonMaxAttemptsExceeded, errorMessage.String)
+}
+
+func TestOTPRequestLogRepositoryUpdateVerificationResultNotFound(t *testing.T) {
+	testDB := setupOTPRequestLogTestDB(t)
+	if testDB == nil {
+		return
+	}
+	defer testDB.Close()
+
+	repo := NewOTPRequestLogRepository(testDB)
+	err := repo.UpdateVerificationResult(context.Background(), otp.OTPVerificationResultLog{
+		RequestID: "missing-request-id",
+		Status:    otp.RequestStatusVerified,
+	})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+}
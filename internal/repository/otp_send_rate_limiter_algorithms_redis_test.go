@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go-backend-service/internal/otp"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sendRateLimiterAlgorithm describes one algorithm for the shared limiter suite.
+type sendRateLimiterAlgorithm struct {
+	name     string
+	tenantID int64
+	key      func(tenantID int64, phone string) string
+	// boundaryAllowed is how many of limit sends pass right after the first window ends,
+	// following one send at its start and limit-1 sends near its end.
+	boundaryAllowed func(limit int) int
+}
+
+var sendRateLimiterAlgorithms = []sendRateLimiterAlgorithm{
+	{
+		name:     SendRateLimitFixedWindow,
+		tenantID: 3201,
+		key:      redisOTPSendRateLimitKey,
+		// The window resets, so a client gets 2x limit across the boundary.
+		boundaryAllowed: func(limit int) int { return limit },
+	},
+	{
+		name:     SendRateLimitSlidingWindow,
+		tenantID: 3202,
+		key:      redisOTPSendSlidingWindowKey,
+		// Only the send at the window start has aged out.
+		boundaryAllowed: func(limit int) int { return 1 },
+	},
+	{
+		name:     SendRateLimitGCRA,
+		tenantID: 3203,
+		key:      redisOTPSendGCRAKey,
+		// The bucket refills one send per window/limit; with limit 4 and a 1s window the
+		// 1150ms mark has refilled two sends since the burst emptied it.
+		boundaryAllowed: func(limit int) int { return 2 },
+	},
+}
+
+func newAlgorithmSendRateLimiter(t *testing.T, client *redis.Client, algorithm string, limit int, window time.Duration) otp.SendRateLimiter {
+	t.Helper()
+	limiter, err := NewRedisOTPSendRateLimiterWithAlgorithm(client, algorithm, limit, window, nil)
+	require.NoError(t, err)
+	return limiter
+}
+
+func countAllowed(t *testing.T, limiter otp.SendRateLimiter, tenantID int64, phone string, n int) int {
+	t.Helper()
+	allowed := 0
+	for i := 0; i < n; i++ {
+		err := limiter.AllowSend(context.Background(), tenantID, phone, otp.PurposeDefault)
+		switch {
+		case err == nil:
+			allowed++
+		case errors.Is(err, otp.ErrOTPRateLimited):
+		default:
+			require.NoError(t, err)
+		}
+	}
+	return allowed
+}
+
+func TestRedisOTPSendRateLimiterAlgorithms(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	for _, algorithm := range sendRateLimiterAlgorithms {
+		t.Run(algorithm.name, func(t *testing.T) {
+			ctx := context.Background()
+			phoneA := "+989123334001"
+			phoneB := "+989123334002"
+			otherTenantID := algorithm.tenantID + 100
+			keys := []string{
+				algorithm.key(algorithm.tenantID, phoneA),
+				algorithm.key(algorithm.tenantID, phoneB),
+				algorithm.key(otherTenantID, phoneA),
+			}
+			cleanup := func() { require.NoError(t, client.Del(ctx, keys...).Err()) }
+			cleanup()
+			defer cleanup()
+
+			limiter := newAlgorithmSendRateLimiter(t, client, algorithm.name, 2, time.Minute)
+
+			t.Run("blocks after limit", func(t *testing.T) {
+				require.NoError(t, limiter.AllowSend(ctx, algorithm.tenantID, phoneA, otp.PurposeDefault))
+				require.NoError(t, limiter.AllowSend(ctx, algorithm.tenantID, phoneA, otp.PurposeDefault))
+				err := limiter.AllowSend(ctx, algorithm.tenantID, phoneA, otp.PurposeDefault)
+				require.ErrorIs(t, err, otp.ErrOTPRateLimited)
+
+				var limitErr *otp.LimitError
+				require.ErrorAs(t, err, &limitErr)
+				assert.Equal(t, 2, limitErr.Limit)
+				assert.Greater(t, limitErr.ResetAfter, time.Duration(0))
+				assert.LessOrEqual(t, limitErr.ResetAfter, time.Minute)
+			})
+
+			t.Run("isolates phones and tenants", func(t *testing.T) {
+				require.NoError(t, limiter.AllowSend(ctx, algorithm.tenantID, phoneB, otp.PurposeDefault))
+				require.NoError(t, limiter.AllowSend(ctx, otherTenantID, phoneA, otp.PurposeDefault))
+			})
+
+			t.Run("sets ttl", func(t *testing.T) {
+				ttl, err := client.PTTL(ctx, algorithm.key(algorithm.tenantID, phoneA)).Result()
+				require.NoError(t, err)
+				assert.Greater(t, ttl, time.Duration(0))
+				assert.LessOrEqual(t, ttl, time.Minute)
+			})
+		})
+	}
+}
+
+func TestRedisOTPSendRateLimiterAlgorithmsConcurrentCalls(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	for _, algorithm := range sendRateLimiterAlgorithms {
+		t.Run(algorithm.name, func(t *testing.T) {
+			ctx := context.Background()
+			phone := "+989123334003"
+			key := algorithm.key(algorithm.tenantID, phone)
+			require.NoError(t, client.Del(ctx, key).Err())
+			defer client.Del(ctx, key)
+
+			limit := 3
+			limiter := newAlgorithmSendRateLimiter(t, client, algorithm.name, limit, time.Minute)
+			totalCalls := 10
+			errs := make(chan error, totalCalls)
+
+			var wg sync.WaitGroup
+			for i := 0; i < totalCalls; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					errs <- limiter.AllowSend(ctx, algorithm.tenantID, phone, otp.PurposeDefault)
+				}()
+			}
+			wg.Wait()
+			close(errs)
+
+			allowed := 0
+			for err := range errs {
+				if err == nil {
+					allowed++
+					continue
+				}
+				require.ErrorIs(t, err, otp.ErrOTPRateLimited)
+			}
+			assert.Equal(t, limit, allowed)
+		})
+	}
+}
+
+// TestRedisOTPSendRateLimiterAlgorithmsBoundaryBurst sends once at the start of a window,
+// limit-1 times near its end, then limit times just after it ends. Only the fixed window
+// lets the client burst 2x limit across the boundary.
+func TestRedisOTPSendRateLimiterAlgorithmsBoundaryBurst(t *testing.T) {
+	client := setupTestRedis(t)
+	// Cleanup, not defer: the parallel subtests run after this function returns.
+	t.Cleanup(func() { _ = client.Close() })
+
+	const (
+		limit  = 4
+		window = time.Second
+	)
+
+	for _, algorithm := range sendRateLimiterAlgorithms {
+		t.Run(algorithm.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			phone := "+989123334004"
+			key := algorithm.key(algorithm.tenantID, phone)
+			require.NoError(t, client.Del(ctx, key).Err())
+			defer client.Del(ctx, key)
+
+			limiter := newAlgorithmSendRateLimiter(t, client, algorithm.name, limit, window)
+			start := time.Now()
+
+			require.Equal(t, 1, countAllowed(t, limiter, algorithm.tenantID, phone, 1))
+			time.Sleep(time.Until(start.Add(800 * time.Millisecond)))
+			require.Equal(t, limit-1, countAllowed(t, limiter, algorithm.tenantID, phone, limit-1))
+			time.Sleep(time.Until(start.Add(1150 * time.Millisecond)))
+
+			assert.Equal(t, algorithm.boundaryAllowed(limit), countAllowed(t, limiter, algorithm.tenantID, phone, limit))
+		})
+	}
+}
+
+func TestRedisSlidingWindowOTPSendRateLimiterSlidesWithClock(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	ctx := context.Background()
+	tenantID := int64(3204)
+	phone := "+989123334005"
+	key := redisOTPSendSlidingWindowKey(tenantID, phone)
+	require.NoError(t, client.Del(ctx, key).Err())
+	defer client.Del(ctx, key)
+
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewRedisSlidingWindowOTPSendRateLimiter(client, 2, time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	require.NoError(t, limiter.AllowSend(ctx, tenantID, phone, otp.PurposeDefault))
+	now = now.Add(30 * time.Second)
+	require.NoError(t, limiter.AllowSend(ctx, tenantID, phone, otp.PurposeDefault))
+	now = now.Add(29 * time.Second)
+	err := limiter.AllowSend(ctx, tenantID, phone, otp.PurposeDefault)
+	var limitErr *otp.LimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, time.Second, limitErr.ResetAfter)
+
+	// The first send ages out exactly one window after it was accepted.
+	now = now.Add(time.Second)
+	require.NoError(t, limiter.AllowSend(ctx, tenantID, phone, otp.PurposeDefault))
+	assert.ErrorIs(t, limiter.AllowSend(ctx, tenantID, phone, otp.PurposeDefault), otp.ErrOTPRateLimited)
+}
+
+func TestRedisGCRAOTPSendRateLimiterRefillsAtSteadyRate(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	ctx := context.Background()
+	tenantID := int64(3205)
+	phone := "+989123334006"
+	key := redisOTPSendGCRAKey(tenantID, phone)
+	require.NoError(t, client.Del(ctx, key).Err())
+	defer client.Del(ctx, key)
+
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewRedisGCRAOTPSendRateLimiter(client, 3, time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, limiter.AllowSend(ctx, tenantID, phone, otp.PurposeDefault))
+	}
+	assert.ErrorIs(t, limiter.AllowSend(ctx, tenantID, phone, otp.PurposeDefault), otp.ErrOTPRateLimited)
+
+	// One send is refilled every window/limit = 20s.
+	now = now.Add(19 * time.Second)
+	err := limiter.AllowSend(ctx, tenantID, phone, otp.PurposeDefault)
+	var limitErr *otp.LimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, time.Second, limitErr.ResetAfter)
+	now = now.Add(time.Second)
+	require.NoError(t, limiter.AllowSend(ctx, tenantID, phone, otp.PurposeDefault))
+	assert.ErrorIs(t, limiter.AllowSend(ctx, tenantID, phone, otp.PurposeDefault), otp.ErrOTPRateLimited)
+}
+
+func TestNewRedisOTPSendRateLimiterWithAlgorithm(t *testing.T) {
+	limiter, err := NewRedisOTPSendRateLimiterWithAlgorithm(nil, "", 1, time.Minute, nil)
+	require.NoError(t, err)
+	assert.IsType(t, &RedisOTPSendRateLimiter{}, limiter)
+
+	limiter, err = NewRedisOTPSendRateLimiterWithAlgorithm(nil, SendRateLimitSlidingWindow, 1, time.Minute, nil)
+	require.NoError(t, err)
+	assert.IsType(t, &RedisSlidingWindowOTPSendRateLimiter{}, limiter)
+
+	limiter, err = NewRedisOTPSendRateLimiterWithAlgorithm(nil, SendRateLimitGCRA, 1, time.Minute, nil)
+	require.NoError(t, err)
+	assert.IsType(t, &RedisGCRAOTPSendRateLimiter{}, limiter)
+
+	_, err = NewRedisOTPSendRateLimiterWithAlgorithm(nil, "leaky_bucket", 1, time.Minute, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go-backend-service/internal/logger"
+	"go-backend-service/internal/metrics"
+	"go-backend-service/internal/otp"
+)
+
+// Send rate limiter modes reported by FallbackOTPSendRateLimiter.
+const (
+	SendRateLimiterModeRedis  = "redis"
+	SendRateLimiterModeMemory = "memory"
+)
+
+// FallbackOTPSendRateLimiter uses the Redis limiter normally and switches to an
+// in-memory limiter when Redis errors, so a Redis outage degrades send limits instead
+// of failing every send. While in fallback it retries Redis once per probeInterval and
+// switches back on the first call Redis answers, allowed or limited.
+type FallbackOTPSendRateLimiter struct {
+	primary       otp.SendRateLimiter
+	fallback      otp.SendRateLimiter
+	probeInterval time.Duration
+	now           func() time.Time
+
+	mu        sync.Mutex
+	mode      string
+	nextProbe time.Time
+}
+
+// NewFallbackOTPSendRateLimiter wraps primary with fallback, typically a Redis limiter
+// with a MemoryOTPSendRateLimiter of the same limit and window.
+func NewFallbackOTPSendRateLimiter(primary otp.SendRateLimiter, fallback otp.SendRateLimiter, probeInterval time.Duration) *FallbackOTPSendRateLimiter {
+	metrics.OTPSendRateLimiterMode.Set(0)
+	return &FallbackOTPSendRateLimiter{
+		primary:       primary,
+		fallback:      fallback,
+		probeInterval: probeInterval,
+		now:           time.Now,
+		mode:          SendRateLimiterModeRedis,
+	}
+}
+
+// Mode returns SendRateLimiterModeRedis or SendRateLimiterModeMemory.
+func (l *FallbackOTPSendRateLimiter) Mode() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.mode
+}
+
+// AllowSend checks the send against the active limiter.
+func (l *FallbackOTPSendRateLimiter) AllowSend(ctx context.Context, tenantID int64, phone string, purpose string) error {
+	if !l.usePrimary() {
+		return l.fallback.AllowSend(ctx, tenantID, phone, purpose)
+	}
+
+	err := l.primary.AllowSend(ctx, tenantID, phone, purpose)
+	if err == nil || errors.Is(err, otp.ErrOTPRateLimited) {
+		l.switchTo(SendRateLimiterModeRedis, nil)
+		return err
+	}
+	if ctx.Err() != nil {
+		// The caller gave up; that says nothing about Redis health.
+		return err
+	}
+
+	l.switchTo(SendRateLimiterModeMemory, err)
+	return l.fallback.AllowSend(ctx, tenantID, phone, purpose)
+}
+
+// usePrimary reports whether to call Redis: always in redis mode, and at most once per
+// probe interval in memory mode.
+func (l *FallbackOTPSendRateLimiter) usePrimary() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.mode == SendRateLimiterModeRedis {
+		return true
+	}
+	now := l.now()
+	if now.Before(l.nextProbe) {
+		return false
+	}
+	l.nextProbe = now.Add(l.probeInterval)
+	return true
+}
+
+func (l *FallbackOTPSendRateLimiter) switchTo(mode string, cause error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if mode == SendRateLimiterModeMemory {
+		l.nextProbe = l.now().Add(l.probeInterval)
+	}
+	from := l.mode
+	if from == mode {
+		return
+	}
+	l.mode = mode
+
+	gauge := 0.0
+	if mode == SendRateLimiterModeMemory {
+		gauge = 1
+	}
+	metrics.OTPSendRateLimiterMode.Set(gauge)
+	metrics.OTPSendRateLimiterModeTransitions.WithLabelValues(from, mode).Inc()
+	log := logger.Get()
+	event := log.Warn()
+	if cause != nil {
+		event = event.Err(cause)
+	}
+	event.
+		Str("from", from).
+		Str("to", mode).
+		Msg("OTP send rate limiter mode changed")
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-backend-service/internal/metrics"
+	"go-backend-service/internal/otp"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedSendRateLimiter returns err for every call and counts the calls.
+type scriptedSendRateLimiter struct {
+	err   error
+	calls int
+}
+
+func (l *scriptedSendRateLimiter) AllowSend(ctx context.Context, tenantID int64, phone string, purpose string) error {
+	l.calls++
+	return l.err
+}
+
+func newTestFallbackLimiter(primary, fallback otp.SendRateLimiter, now *time.Time) *FallbackOTPSendRateLimiter {
+	limiter := NewFallbackOTPSendRateLimiter(primary, fallback, 10*time.Second)
+	limiter.now = func() time.Time { return *now }
+	return limiter
+}
+
+func TestFallbackOTPSendRateLimiterUsesRedisWhenHealthy(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	primary := &scriptedSendRateLimiter{}
+	fallback := &scriptedSendRateLimiter{}
+	limiter := newTestFallbackLimiter(primary, fallback, &now)
+
+	require.NoError(t, limiter.AllowSend(context.Background(), 1, "+989121234567", otp.PurposeDefault))
+
+	primary.err = &otp.LimitError{Err: otp.ErrOTPRateLimited, Limit: 5}
+	assert.ErrorIs(t, limiter.AllowSend(context.Background(), 1, "+989121234567", otp.PurposeDefault), otp.ErrOTPRateLimited)
+
+	assert.Equal(t, 2, primary.calls)
+	assert.Equal(t, 0, fallback.calls)
+	assert.Equal(t, SendRateLimiterModeRedis, limiter.Mode())
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.OTPSendRateLimiterMode))
+}
+
+func TestFallbackOTPSendRateLimiterSwitchesToMemoryAndBack(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	primary := &scriptedSendRateLimiter{err: errors.New("dial tcp: connection refused")}
+	fallback := &scriptedSendRateLimiter{err: &otp.LimitError{Err: otp.ErrOTPRateLimited, Limit: 5}}
+	limiter := newTestFallbackLimiter(primary, fallback, &now)
+	toMemory := testutil.ToFloat64(metrics.OTPSendRateLimiterModeTransitions.WithLabelValues(SendRateLimiterModeRedis, SendRateLimiterModeMemory))
+
+	// The failing Redis call is answered by the fallback, limits included.
+	assert.ErrorIs(t, limiter.AllowSend(ctx, 1, "+989121234567", otp.PurposeDefault), otp.ErrOTPRateLimited)
+	assert.Equal(t, SendRateLimiterModeMemory, limiter.Mode())
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.OTPSendRateLimiterMode))
+	assert.Equal(t, toMemory+1, testutil.ToFloat64(metrics.OTPSendRateLimiterModeTransitions.WithLabelValues(SendRateLimiterModeRedis, SendRateLimiterModeMemory)))
+
+	// Redis is not called again until the probe interval has passed.
+	fallback.err = nil
+	require.NoError(t, limiter.AllowSend(ctx, 1, "+989121234567", otp.PurposeDefault))
+	assert.Equal(t, 1, primary.calls)
+
+	// A failed probe stays in memory mode and waits another interval.
+	now = now.Add(10 * time.Second)
+	require.NoError(t, limiter.AllowSend(ctx, 1, "+989121234567", otp.PurposeDefault))
+	require.NoError(t, limiter.AllowSend(ctx, 1, "+989121234567", otp.PurposeDefault))
+	assert.Equal(t, 2, primary.calls)
+	assert.Equal(t, SendRateLimiterModeMemory, limiter.Mode())
+
+	// The first probe Redis answers switches back.
+	primary.err = nil
+	now = now.Add(10 * time.Second)
+	require.NoError(t, limiter.AllowSend(ctx, 1, "+989121234567", otp.PurposeDefault))
+	require.NoError(t, limiter.AllowSend(ctx, 1, "+989121234567", otp.PurposeDefault))
+	assert.Equal(t, 4, primary.calls)
+	assert.Equal(t, 4, fallback.calls)
+	assert.Equal(t, SendRateLimiterModeRedis, limiter.Mode())
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.OTPSendRateLimiterMode))
+}
+
+func TestFallbackOTPSendRateLimiterIgnoresCanceledContext(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	primary := &scriptedSendRateLimiter{err: context.Canceled}
+	fallback := &scriptedSendRateLimiter{}
+	limiter := newTestFallbackLimiter(primary, fallback, &now)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := limiter.AllowSend(ctx, 1, "+989121234567", otp.PurposeDefault)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, fallback.calls)
+	assert.Equal(t, SendRateLimiterModeRedis, limiter.Mode())
+}
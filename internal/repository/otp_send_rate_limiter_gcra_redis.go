@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go-backend-service/internal/otp"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisGCRAOTPSendRateLimiter limits OTP send attempts with the generic cell rate
+// algorithm, a token bucket stored as a single theoretical arrival time (TAT).
+// Sends refill at limit per window and bursts are capped at limit.
+type RedisGCRAOTPSendRateLimiter struct {
+	phoneKeys
+	client *redis.Client
+	limit  int
+	window time.Duration
+	now    func() time.Time
+}
+
+// otpSendGCRAScript returns {allowed, microseconds until the next send is allowed}.
+// Times are integer microseconds; string.format keeps them out of Lua's float notation.
+var otpSendGCRAScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local burst = tonumber(ARGV[3])
+local tat = tonumber(redis.call("GET", KEYS[1]) or ARGV[1])
+if tat < now then
+	tat = now
+end
+local new_tat = tat + interval
+if new_tat - burst > now then
+	return {0, new_tat - burst - now}
+end
+redis.call("SET", KEYS[1], string.format("%d", new_tat), "PX", string.format("%d", math.ceil((new_tat - now) / 1000)))
+return {1, 0}
+`)
+
+// NewRedisGCRAOTPSendRateLimiter creates a Redis-backed GCRA OTP send rate limiter.
+func NewRedisGCRAOTPSendRateLimiter(client *redis.Client, limit int, window time.Duration) *RedisGCRAOTPSendRateLimiter {
+	return &RedisGCRAOTPSendRateLimiter{
+		client: client,
+		limit:  limit,
+		window: window,
+		now:    time.Now,
+	}
+}
+
+// AllowSend returns nil when an OTP send is allowed, or an *otp.LimitError wrapping
+// otp.ErrOTPRateLimited when the limit is exceeded.
+func (l *RedisGCRAOTPSendRateLimiter) AllowSend(ctx context.Context, tenantID int64, phone string, purpose string) error {
+	if l.client == nil {
+		return fmt.Errorf("redis otp gcra rate limiter: client is nil")
+	}
+	if l.limit <= 0 {
+		return fmt.Errorf("redis otp gcra rate limiter: limit must be positive")
+	}
+	interval := l.window.Microseconds() / int64(l.limit)
+	if interval <= 0 {
+		return fmt.Errorf("redis otp gcra rate limiter: window must be at least 1µs per allowed send")
+	}
+
+	key := redisOTPSendGCRAKey(tenantID, purposePhoneKey(purpose, l.tenantPhoneKey(tenantID, phone)))
+	result, err := otpSendGCRAScript.Run(ctx, l.client, []string{key},
+		strconv.FormatInt(l.now().UnixMicro(), 10),
+		strconv.FormatInt(interval, 10),
+		strconv.FormatInt(interval*int64(l.limit), 10),
+	).Int64Slice()
+	if err != nil {
+		return fmt.Errorf("redis otp gcra rate limiter allow send: %w", err)
+	}
+	if len(result) != 2 {
+		return fmt.Errorf("redis otp gcra rate limiter: unexpected script result %v", result)
+	}
+	if result[0] == 0 {
+		return &otp.LimitError{Err: otp.ErrOTPRateLimited, Limit: l.limit, ResetAfter: time.Duration(result[1]) * time.Microsecond}
+	}
+
+	return nil
+}
+
+func redisOTPSendGCRAKey(tenantID int64, phoneKey string) string {
+	return fmt.Sprintf("otp:rate:send:gcra:%d:%s", tenantID, phoneKey)
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go-backend-service/internal/otp"
+)
+
+// MemoryOTPSendRateLimiter limits OTP sends with an in-process token bucket per tenant
+// and phone. Buckets hold limit tokens and refill at limit per window. At most maxKeys
+// buckets are kept; the least recently used bucket is evicted first, which forgets
+// that phone's history. Limits are per process, so N replicas allow up to N x limit.
+type MemoryOTPSendRateLimiter struct {
+	limit   int
+	window  time.Duration
+	maxKeys int
+	now     func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*list.Element
+	lru     *list.List
+}
+
+type memoryTokenBucket struct {
+	key       string
+	tokens    float64
+	updatedAt time.Time
+}
+
+// NewMemoryOTPSendRateLimiter creates an in-memory OTP send rate limiter.
+func NewMemoryOTPSendRateLimiter(limit int, window time.Duration, maxKeys int) *MemoryOTPSendRateLimiter {
+	return &MemoryOTPSendRateLimiter{
+		limit:   limit,
+		window:  window,
+		maxKeys: maxKeys,
+		now:     time.Now,
+		buckets: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// AllowSend returns nil when an OTP send is allowed, or an *otp.LimitError wrapping
+// otp.ErrOTPRateLimited when the bucket is empty.
+func (l *MemoryOTPSendRateLimiter) AllowSend(ctx context.Context, tenantID int64, phone string, purpose string) error {
+	if l.limit <= 0 || l.window <= 0 || l.maxKeys <= 0 {
+		return fmt.Errorf("memory otp rate limiter: limit, window and max keys must be positive")
+	}
+
+	now := l.now()
+	rate := float64(l.limit) / float64(l.window)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket := l.bucketLocked(fmt.Sprintf("%d:%s", tenantID, purposePhoneKey(purpose, phone)), now)
+	elapsed := now.Sub(bucket.updatedAt)
+	if elapsed > 0 {
+		bucket.tokens += float64(elapsed) * rate
+		if bucket.tokens > float64(l.limit) {
+			bucket.tokens = float64(l.limit)
+		}
+		bucket.updatedAt = now
+	}
+
+	if bucket.tokens < 1 {
+		return &otp.LimitError{
+			Err:        otp.ErrOTPRateLimited,
+			Limit:      l.limit,
+			ResetAfter: time.Duration((1 - bucket.tokens) / rate),
+		}
+	}
+	bucket.tokens--
+	return nil
+}
+
+// bucketLocked returns the bucket for key, creating a full one and evicting the least
+// recently used bucket when the limiter is at capacity.
+func (l *MemoryOTPSendRateLimiter) bucketLocked(key string, now time.Time) *memoryTokenBucket {
+	if element, ok := l.buckets[key]; ok {
+		l.lru.MoveToFront(element)
+		return element.Value.(*memoryTokenBucket)
+	}
+
+	if l.lru.Len() >= l.maxKeys {
+		oldest := l.lru.Back()
+		l.lru.Remove(oldest)
+		delete(l.buckets, oldest.Value.(*memoryTokenBucket).key)
+	}
+	bucket := &memoryTokenBucket{key: key, tokens: float64(l.limit), updatedAt: now}
+	l.buckets[key] = l.lru.PushFront(bucket)
+	return bucket
+}
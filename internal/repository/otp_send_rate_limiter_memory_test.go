@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-backend-service/internal/otp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryOTPSendRateLimiterRefillsTokens(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewMemoryOTPSendRateLimiter(3, time.Minute, 10)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, limiter.AllowSend(ctx, 1, "+989121234567", otp.PurposeDefault))
+	}
+	err := limiter.AllowSend(ctx, 1, "+989121234567", otp.PurposeDefault)
+	require.ErrorIs(t, err, otp.ErrOTPRateLimited)
+	var limitErr *otp.LimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, 3, limitErr.Limit)
+	assert.Equal(t, 20*time.Second, limitErr.ResetAfter)
+
+	// Other phones and tenants have their own buckets.
+	require.NoError(t, limiter.AllowSend(ctx, 1, "+989121234568", otp.PurposeDefault))
+	require.NoError(t, limiter.AllowSend(ctx, 2, "+989121234567", otp.PurposeDefault))
+
+	// One token refills every window/limit = 20s.
+	now = now.Add(20 * time.Second)
+	require.NoError(t, limiter.AllowSend(ctx, 1, "+989121234567", otp.PurposeDefault))
+	assert.ErrorIs(t, limiter.AllowSend(ctx, 1, "+989121234567", otp.PurposeDefault), otp.ErrOTPRateLimited)
+
+	// A long pause refills at most limit tokens.
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, limiter.AllowSend(ctx, 1, "+989121234567", otp.PurposeDefault))
+	}
+	assert.ErrorIs(t, limiter.AllowSend(ctx, 1, "+989121234567", otp.PurposeDefault), otp.ErrOTPRateLimited)
+}
+
+func TestMemoryOTPSendRateLimiterEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewMemoryOTPSendRateLimiter(1, time.Minute, 2)
+	limiter.now = func() time.Time { return time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC) }
+
+	require.NoError(t, limiter.AllowSend(ctx, 1, "+989120000001", otp.PurposeDefault))
+	require.NoError(t, limiter.AllowSend(ctx, 1, "+989120000002", otp.PurposeDefault))
+	// Touch the first phone so the second becomes least recently used.
+	require.ErrorIs(t, limiter.AllowSend(ctx, 1, "+989120000001", otp.PurposeDefault), otp.ErrOTPRateLimited)
+	require.NoError(t, limiter.AllowSend(ctx, 1, "+989120000003", otp.PurposeDefault))
+
+	assert.Equal(t, 2, limiter.lru.Len())
+	assert.ErrorIs(t, limiter.AllowSend(ctx, 1, "+989120000001", otp.PurposeDefault), otp.ErrOTPRateLimited)
+	// The evicted phone starts over with a full bucket.
+	assert.NoError(t, limiter.AllowSend(ctx, 1, "+989120000002", otp.PurposeDefault))
+}
+
+func TestMemoryOTPSendRateLimiterInvalidConfig(t *testing.T) {
+	limiter := NewMemoryOTPSendRateLimiter(0, time.Minute, 10)
+
+	err := limiter.AllowSend(context.Background(), 1, "+989121234567", otp.PurposeDefault)
+
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, otp.ErrOTPRateLimited)
+}
@@ -11,19 +11,30 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// OTP send rate limit algorithms accepted by NewRedisOTPSendRateLimiterWithAlgorithm.
+const (
+	SendRateLimitFixedWindow   = "fixed_window"
+	SendRateLimitSlidingWindow = "sliding_window"
+	SendRateLimitGCRA          = "gcra"
+)
+
 // RedisOTPSendRateLimiter limits OTP send attempts with a Redis fixed window.
 type RedisOTPSendRateLimiter struct {
+	phoneKeys
 	client *redis.Client
 	limit  int
 	window time.Duration
 }
 
+// otpSendRateLimitScript returns the window count and the milliseconds until the window resets.
 var otpSendRateLimitScript = redis.NewScript(`
 local current = redis.call("INCR", KEYS[1])
-if current == 1 or redis.call("PTTL", KEYS[1]) < 0 then
+local ttl = redis.call("PTTL", KEYS[1])
+if current == 1 or ttl < 0 then
 	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+	ttl = tonumber(ARGV[1])
 end
-return current
+return {current, ttl}
 `)
 
 // NewRedisOTPSendRateLimiter creates a Redis-backed OTP send rate limiter.
@@ -35,8 +46,32 @@ func NewRedisOTPSendRateLimiter(client *redis.Client, limit int, window time.Dur
 	}
 }
 
-// AllowSend returns nil when an OTP send is allowed, or otp.ErrOTPRateLimited when the limit is exceeded.
-func (l *RedisOTPSendRateLimiter) AllowSend(ctx context.Context, tenantID int64, phone string) error {
+// NewRedisOTPSendRateLimiterWithAlgorithm creates a Redis-backed OTP send rate limiter
+// using the fixed window, sliding window log or GCRA algorithm. Each algorithm keeps its
+// own keys, so switching algorithms starts every phone with a fresh allowance. A nil
+// phoneKeyHasher keeps the plaintext phone in key names.
+func NewRedisOTPSendRateLimiterWithAlgorithm(client *redis.Client, algorithm string, limit int, window time.Duration, phoneKeyHasher *otp.PhoneKeyHasher) (otp.SendRateLimiter, error) {
+	switch algorithm {
+	case "", SendRateLimitFixedWindow:
+		limiter := NewRedisOTPSendRateLimiter(client, limit, window)
+		limiter.SetPhoneKeyHasher(phoneKeyHasher)
+		return limiter, nil
+	case SendRateLimitSlidingWindow:
+		limiter := NewRedisSlidingWindowOTPSendRateLimiter(client, limit, window)
+		limiter.SetPhoneKeyHasher(phoneKeyHasher)
+		return limiter, nil
+	case SendRateLimitGCRA:
+		limiter := NewRedisGCRAOTPSendRateLimiter(client, limit, window)
+		limiter.SetPhoneKeyHasher(phoneKeyHasher)
+		return limiter, nil
+	default:
+		return nil, fmt.Errorf("unknown otp send rate limit algorithm %q", algorithm)
+	}
+}
+
+// AllowSend returns nil when an OTP send is allowed, or an *otp.LimitError wrapping
+// otp.ErrOTPRateLimited when the limit is exceeded.
+func (l *RedisOTPSendRateLimiter) AllowSend(ctx context.Context, tenantID int64, phone string, purpose string) error {
 	if l.client == nil {
 		return fmt.Errorf("redis otp send rate limiter: client is nil")
 	}
@@ -47,18 +82,30 @@ func (l *RedisOTPSendRateLimiter) AllowSend(ctx context.Context, tenantID int64,
 		return fmt.Errorf("redis otp send rate limiter: window must be positive")
 	}
 
-	key := redisOTPSendRateLimitKey(tenantID, phone)
-	count, err := otpSendRateLimitScript.Run(ctx, l.client, []string{key}, strconv.FormatInt(l.window.Milliseconds(), 10)).Int()
+	key := redisOTPSendRateLimitKey(tenantID, purposePhoneKey(purpose, l.tenantPhoneKey(tenantID, phone)))
+	count, resetAfter, err := runFixedWindow(ctx, l.client, key, l.window)
 	if err != nil {
 		return fmt.Errorf("redis otp send rate limiter allow send: %w", err)
 	}
-	if count > l.limit {
-		return otp.ErrOTPRateLimited
+	if count > int64(l.limit) {
+		return &otp.LimitError{Err: otp.ErrOTPRateLimited, Limit: l.limit, ResetAfter: resetAfter}
 	}
 
 	return nil
 }
 
-func redisOTPSendRateLimitKey(tenantID int64, phone string) string {
-	return fmt.Sprintf("otp:rate:send:%d:%s", tenantID, phone)
+// runFixedWindow counts a hit in the fixed window at key and reports the window's reset time.
+func runFixedWindow(ctx context.Context, client *redis.Client, key string, window time.Duration) (int64, time.Duration, error) {
+	result, err := otpSendRateLimitScript.Run(ctx, client, []string{key}, strconv.FormatInt(window.Milliseconds(), 10)).Int64Slice()
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(result) != 2 {
+		return 0, 0, fmt.Errorf("unexpected fixed window script result %v", result)
+	}
+	return result[0], time.Duration(result[1]) * time.Millisecond, nil
+}
+
+func redisOTPSendRateLimitKey(tenantID int64, phoneKey string) string {
+	return fmt.Sprintf("otp:rate:send:%d:%s", tenantID, phoneKey)
 }
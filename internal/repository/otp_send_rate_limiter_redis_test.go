@@ -26,8 +26,8 @@ func TestRedisOTPSendRateLimiterAllowsUnderLimit(t *testing.T) {
 
 	limiter := NewRedisOTPSendRateLimiter(client, 2, time.Minute)
 
-	require.NoError(t, limiter.AllowSend(ctx, tenantID, phone))
-	require.NoError(t, limiter.AllowSend(ctx, tenantID, phone))
+	require.NoError(t, limiter.AllowSend(ctx, tenantID, phone, otp.PurposeDefault))
+	require.NoError(t, limiter.AllowSend(ctx, tenantID, phone, otp.PurposeDefault))
 }
 
 func TestRedisOTPSendRateLimiterBlocksAfterLimit(t *testing.T) {
@@ -43,10 +43,15 @@ func TestRedisOTPSendRateLimiterBlocksAfterLimit(t *testing.T) {
 
 	limiter := NewRedisOTPSendRateLimiter(client, 1, time.Minute)
 
-	require.NoError(t, limiter.AllowSend(ctx, tenantID, phone))
-	err := limiter.AllowSend(ctx, tenantID, phone)
+	require.NoError(t, limiter.AllowSend(ctx, tenantID, phone, otp.PurposeDefault))
+	err := limiter.AllowSend(ctx, tenantID, phone, otp.PurposeDefault)
 
 	assert.ErrorIs(t, err, otp.ErrOTPRateLimited)
+	var limitErr *otp.LimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, 1, limitErr.Limit)
+	assert.Greater(t, limitErr.ResetAfter, time.Duration(0))
+	assert.LessOrEqual(t, limitErr.ResetAfter, time.Minute)
 }
 
 func TestRedisOTPSendRateLimiterSetsTTL(t *testing.T) {
@@ -62,7 +67,7 @@ func TestRedisOTPSendRateLimiterSetsTTL(t *testing.T) {
 
 	limiter := NewRedisOTPSendRateLimiter(client, 2, time.Minute)
 
-	require.NoError(t, limiter.AllowSend(ctx, tenantID, phone))
+	require.NoError(t, limiter.AllowSend(ctx, tenantID, phone, otp.PurposeDefault))
 
 	ttl, err := client.TTL(ctx, key).Result()
 	require.NoError(t, err)
@@ -82,9 +87,9 @@ func TestRedisOTPSendRateLimiterIsolatesTenantIDs(t *testing.T) {
 
 	limiter := NewRedisOTPSendRateLimiter(client, 1, time.Minute)
 
-	require.NoError(t, limiter.AllowSend(ctx, 3004, phone))
-	require.NoError(t, limiter.AllowSend(ctx, 3005, phone))
-	assert.ErrorIs(t, limiter.AllowSend(ctx, 3004, phone), otp.ErrOTPRateLimited)
+	require.NoError(t, limiter.AllowSend(ctx, 3004, phone, otp.PurposeDefault))
+	require.NoError(t, limiter.AllowSend(ctx, 3005, phone, otp.PurposeDefault))
+	assert.ErrorIs(t, limiter.AllowSend(ctx, 3004, phone, otp.PurposeDefault), otp.ErrOTPRateLimited)
 }
 
 func TestRedisOTPSendRateLimiterIsolatesPhones(t *testing.T) {
@@ -102,9 +107,9 @@ func TestRedisOTPSendRateLimiterIsolatesPhones(t *testing.T) {
 
 	limiter := NewRedisOTPSendRateLimiter(client, 1, time.Minute)
 
-	require.NoError(t, limiter.AllowSend(ctx, tenantID, phoneA))
-	require.NoError(t, limiter.AllowSend(ctx, tenantID, phoneB))
-	assert.ErrorIs(t, limiter.AllowSend(ctx, tenantID, phoneA), otp.ErrOTPRateLimited)
+	require.NoError(t, limiter.AllowSend(ctx, tenantID, phoneA, otp.PurposeDefault))
+	require.NoError(t, limiter.AllowSend(ctx, tenantID, phoneB, otp.PurposeDefault))
+	assert.ErrorIs(t, limiter.AllowSend(ctx, tenantID, phoneA, otp.PurposeDefault), otp.ErrOTPRateLimited)
 }
 
 func TestRedisOTPSendRateLimiterInvalidConfig(t *testing.T) {
@@ -122,7 +127,7 @@ func TestRedisOTPSendRateLimiterInvalidConfig(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.limiter.AllowSend(context.Background(), 3007, "+989123333008")
+			err := tt.limiter.AllowSend(context.Background(), 3007, "+989123333008", otp.PurposeDefault)
 
 			require.Error(t, err)
 			assert.False(t, errors.Is(err, otp.ErrOTPRateLimited))
@@ -144,7 +149,7 @@ func TestRedisOTPSendRateLimiterRepairsMissingTTL(t *testing.T) {
 
 	limiter := NewRedisOTPSendRateLimiter(client, 3, time.Minute)
 
-	require.NoError(t, limiter.AllowSend(ctx, tenantID, phone))
+	require.NoError(t, limiter.AllowSend(ctx, tenantID, phone, otp.PurposeDefault))
 
 	ttl, err := client.TTL(ctx, key).Result()
 	require.NoError(t, err)
@@ -172,7 +177,7 @@ func TestRedisOTPSendRateLimiterConcurrentCalls(t *testing.T) {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			errs <- limiter.AllowSend(ctx, tenantID, phone)
+			errs <- limiter.AllowSend(ctx, tenantID, phone, otp.PurposeDefault)
 		}()
 	}
 	wg.Wait()
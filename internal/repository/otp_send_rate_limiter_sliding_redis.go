@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go-backend-service/internal/otp"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisSlidingWindowOTPSendRateLimiter limits OTP send attempts with a Redis sliding
+// window log: a sorted set of accepted send timestamps. Unlike the fixed window it never
+// admits more than limit sends in any window-long interval.
+type RedisSlidingWindowOTPSendRateLimiter struct {
+	phoneKeys
+	client *redis.Client
+	limit  int
+	window time.Duration
+	now    func() time.Time
+}
+
+// otpSendSlidingWindowScript returns {allowed, milliseconds until the oldest send ages out}.
+// Rejected sends are not logged, so a throttled client is not kept locked out.
+var otpSendSlidingWindowScript = redis.NewScript(`
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", ARGV[2])
+if redis.call("ZCARD", KEYS[1]) >= tonumber(ARGV[4]) then
+	local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+	return {0, tonumber(oldest[2]) + tonumber(ARGV[3]) - tonumber(ARGV[1])}
+end
+redis.call("ZADD", KEYS[1], ARGV[1], ARGV[5])
+redis.call("PEXPIRE", KEYS[1], ARGV[3])
+return {1, 0}
+`)
+
+// NewRedisSlidingWindowOTPSendRateLimiter creates a Redis-backed sliding window log OTP send rate limiter.
+func NewRedisSlidingWindowOTPSendRateLimiter(client *redis.Client, limit int, window time.Duration) *RedisSlidingWindowOTPSendRateLimiter {
+	return &RedisSlidingWindowOTPSendRateLimiter{
+		client: client,
+		limit:  limit,
+		window: window,
+		now:    time.Now,
+	}
+}
+
+// AllowSend returns nil when an OTP send is allowed, or an *otp.LimitError wrapping
+// otp.ErrOTPRateLimited when the limit is exceeded.
+func (l *RedisSlidingWindowOTPSendRateLimiter) AllowSend(ctx context.Context, tenantID int64, phone string, purpose string) error {
+	if l.client == nil {
+		return fmt.Errorf("redis otp sliding window rate limiter: client is nil")
+	}
+	if l.limit <= 0 {
+		return fmt.Errorf("redis otp sliding window rate limiter: limit must be positive")
+	}
+	if l.window < time.Millisecond {
+		return fmt.Errorf("redis otp sliding window rate limiter: window must be at least 1ms")
+	}
+
+	nowMs := l.now().UnixMilli()
+	windowMs := l.window.Milliseconds()
+	key := redisOTPSendSlidingWindowKey(tenantID, purposePhoneKey(purpose, l.tenantPhoneKey(tenantID, phone)))
+	result, err := otpSendSlidingWindowScript.Run(ctx, l.client, []string{key},
+		strconv.FormatInt(nowMs, 10),
+		strconv.FormatInt(nowMs-windowMs, 10),
+		strconv.FormatInt(windowMs, 10),
+		strconv.Itoa(l.limit),
+		strconv.FormatInt(nowMs, 10)+"-"+uuid.NewString(),
+	).Int64Slice()
+	if err != nil {
+		return fmt.Errorf("redis otp sliding window rate limiter allow send: %w", err)
+	}
+	if len(result) != 2 {
+		return fmt.Errorf("redis otp sliding window rate limiter: unexpected script result %v", result)
+	}
+	if result[0] == 0 {
+		return &otp.LimitError{Err: otp.ErrOTPRateLimited, Limit: l.limit, ResetAfter: time.Duration(result[1]) * time.Millisecond}
+	}
+
+	return nil
+}
+
+func redisOTPSendSlidingWindowKey(tenantID int64, phoneKey string) string {
+	return fmt.Sprintf("otp:rate:send:sliding:%d:%s", tenantID, phoneKey)
+}
@@ -11,21 +11,110 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
-// RedisOTPStore stores short-lived OTP verification state in Redis hashes.
+// RedisOTPStore stores short-lived OTP verification state in Redis hashes. The hash has
+// no phone field; the phone is only part of the key name, hashed when a phone key hasher
+// is set. Purposes other than the default one prefix the phone in the key name.
+//
+// A request ID index hash, expiring with the OTP, maps each request ID to the tenant,
+// the OTP key name and the phone. The phone is sealed when a phone key hasher is set.
+// A cancelled OTP leaves two markers that expire with it: one per request ID holding the
+// tenant ID and one per tenant and phone holding the request ID.
 type RedisOTPStore struct {
+	legacyPhoneKeys
 	client *redis.Client
 }
 
+// incrementOTPAttemptsScript returns -1 when KEYS[1] is missing and -3 when it holds
+// another request than ARGV[1].
 var incrementOTPAttemptsScript = redis.NewScript(`
 if redis.call("EXISTS", KEYS[1]) == 0 then
 	return -1
 end
+if redis.call("HGET", KEYS[1], "request_id") ~= ARGV[1] then
+	return -3
+end
 if redis.call("HEXISTS", KEYS[1], "attempt_count") == 0 then
 	return -2
 end
 return redis.call("HINCRBY", KEYS[1], "attempt_count", 1)
 `)
 
+// reserveOTPScript writes KEYS[3] and its request ID index KEYS[1], and clears the
+// phone's cancellation marker KEYS[2], unless any of KEYS[3..], the current and legacy
+// key names of the same OTP, exists. ARGV[2..4] are the tenant ID and the phone field
+// and value of the index.
+var reserveOTPScript = redis.NewScript(`
+for i = 3, #KEYS do
+	if redis.call("EXISTS", KEYS[i]) == 1 then
+		return 0
+	end
+end
+redis.call("HSET", KEYS[3], unpack(ARGV, 5))
+redis.call("PEXPIRE", KEYS[3], ARGV[1])
+redis.call("HSET", KEYS[1], "tenant_id", ARGV[2], "key", KEYS[3], ARGV[3], ARGV[4])
+redis.call("PEXPIRE", KEYS[1], ARGV[1])
+redis.call("DEL", KEYS[2])
+return 1
+`)
+
+// resendOTPScript updates the first existing of KEYS[2..], the current and legacy key
+// names of the same OTP, when it holds request ARGV[2] with ARGV[3] resends, and
+// rewrites its request ID index KEYS[1] from ARGV[4..6]. It returns 1 when applied, -1
+// when no such OTP exists and 0 when another resend got there first.
+var resendOTPScript = redis.NewScript(`
+for i = 2, #KEYS do
+	local key = KEYS[i]
+	if redis.call("EXISTS", key) == 1 then
+		if redis.call("HGET", key, "request_id") ~= ARGV[2] then
+			return -1
+		end
+		if tonumber(redis.call("HGET", key, "resend_count") or "0") ~= tonumber(ARGV[3]) then
+			return 0
+		end
+		redis.call("HSET", key, unpack(ARGV, 7))
+		redis.call("PEXPIRE", key, ARGV[1])
+		redis.call("HSET", KEYS[1], "tenant_id", ARGV[4], "key", key, ARGV[5], ARGV[6])
+		redis.call("PEXPIRE", KEYS[1], ARGV[1])
+		return 1
+	end
+end
+return -1
+`)
+
+// deleteOTPRequestScript deletes the first existing of KEYS[2..] and the request ID index
+// KEYS[1] when it holds request ARGV[1]. It returns 1 when deleted and 0 otherwise.
+var deleteOTPRequestScript = redis.NewScript(`
+for i = 2, #KEYS do
+	if redis.call("EXISTS", KEYS[i]) == 1 then
+		if redis.call("HGET", KEYS[i], "request_id") ~= ARGV[1] then
+			return 0
+		end
+		redis.call("DEL", KEYS[i], KEYS[1])
+		return 1
+	end
+end
+return 0
+`)
+
+// cancelOTPScript deletes the first existing of KEYS[4..] and the request ID index
+// KEYS[1] when it holds request ARGV[1], then marks the request KEYS[2] with tenant ID
+// ARGV[3] and the phone KEYS[3] with the request ID for ARGV[2] milliseconds. It
+// returns 1 when cancelled and 0 otherwise.
+var cancelOTPScript = redis.NewScript(`
+for i = 4, #KEYS do
+	if redis.call("EXISTS", KEYS[i]) == 1 then
+		if redis.call("HGET", KEYS[i], "request_id") ~= ARGV[1] then
+			return 0
+		end
+		redis.call("DEL", KEYS[i], KEYS[1])
+		redis.call("SET", KEYS[2], ARGV[3], "PX", ARGV[2])
+		redis.call("SET", KEYS[3], ARGV[1], "PX", ARGV[2])
+		return 1
+	end
+end
+return 0
+`)
+
 // NewRedisOTPStore creates a Redis-backed OTP store.
 func NewRedisOTPStore(client *redis.Client) *RedisOTPStore {
 	return &RedisOTPStore{client: client}
@@ -37,21 +126,22 @@ func (s *RedisOTPStore) Save(ctx context.Context, state otp.OTPState, ttl time.D
 		return fmt.Errorf("redis otp store save: ttl must be positive")
 	}
 
-	key := redisOTPKey(state.TenantID, state.Phone)
-	fields := map[string]interface{}{
-		"request_id":    state.RequestID,
-		"tenant_id":     strconv.FormatInt(state.TenantID, 10),
-		"phone":         state.Phone,
-		"code_hash":     state.CodeHash,
-		"attempt_count": strconv.Itoa(state.AttemptCount),
-		"max_attempts":  strconv.Itoa(state.MaxAttempts),
-		"created_at":    state.CreatedAt.Format(time.RFC3339Nano),
-		"expires_at":    state.ExpiresAt.Format(time.RFC3339Nano),
+	keys := s.keys(state.TenantID, state.Phone, state.Purpose)
+	phoneField, phoneValue, err := s.indexPhone(state.Phone, state.RequestID)
+	if err != nil {
+		return fmt.Errorf("redis otp store save: %w", err)
 	}
 
 	pipe := s.client.TxPipeline()
-	pipe.HSet(ctx, key, fields)
-	pipe.Expire(ctx, key, ttl)
+	pipe.HSet(ctx, keys[0], otpStateFields(state)...)
+	pipe.Expire(ctx, keys[0], ttl)
+	indexKey := redisOTPRequestKey(state.RequestID)
+	pipe.HSet(ctx, indexKey, "tenant_id", strconv.FormatInt(state.TenantID, 10), "key", keys[0], phoneField, phoneValue)
+	pipe.Expire(ctx, indexKey, ttl)
+	if len(keys) > 1 {
+		pipe.Del(ctx, keys[1:]...)
+	}
+	pipe.Del(ctx, s.cancelledPhoneKey(state.TenantID, state.Phone, state.Purpose))
 
 	if _, err := pipe.Exec(ctx); err != nil {
 		return fmt.Errorf("redis otp store save: %w", err)
@@ -60,54 +150,316 @@ func (s *RedisOTPStore) Save(ctx context.Context, state otp.OTPState, ttl time.D
 	return nil
 }
 
+// Reserve atomically stores OTP verification state only when no state exists for the
+// tenant and phone. The Redis TTL tracks ExpiresAt, so an existing key is an active OTP.
+func (s *RedisOTPStore) Reserve(ctx context.Context, state otp.OTPState, ttl time.Duration) error {
+	if ttl <= 0 {
+		return fmt.Errorf("redis otp store reserve: ttl must be positive")
+	}
+
+	phoneField, phoneValue, err := s.indexPhone(state.Phone, state.RequestID)
+	if err != nil {
+		return fmt.Errorf("redis otp store reserve: %w", err)
+	}
+	args := append([]interface{}{
+		strconv.FormatInt(ttl.Milliseconds(), 10),
+		strconv.FormatInt(state.TenantID, 10),
+		phoneField,
+		phoneValue,
+	}, otpStateFields(state)...)
+	keys := append([]string{
+		redisOTPRequestKey(state.RequestID),
+		s.cancelledPhoneKey(state.TenantID, state.Phone, state.Purpose),
+	}, s.keys(state.TenantID, state.Phone, state.Purpose)...)
+	reserved, err := reserveOTPScript.Run(ctx, s.client, keys, args...).Int()
+	if err != nil {
+		return fmt.Errorf("redis otp store reserve: %w", err)
+	}
+	if reserved == 0 {
+		return otp.ErrOTPAlreadyActive
+	}
+
+	return nil
+}
+
 // Get retrieves OTP verification state from Redis.
-func (s *RedisOTPStore) Get(ctx context.Context, tenantID int64, phone string) (*otp.OTPState, error) {
-	key := redisOTPKey(tenantID, phone)
+func (s *RedisOTPStore) Get(ctx context.Context, tenantID int64, phone string, purpose string) (*otp.OTPState, error) {
+	for _, key := range s.keys(tenantID, phone, purpose) {
+		values, err := s.client.HGetAll(ctx, key).Result()
+		if err != nil {
+			return nil, fmt.Errorf("redis otp store get: %w", err)
+		}
+		if len(values) == 0 {
+			continue
+		}
+
+		state, err := parseOTPState(values)
+		if err != nil {
+			return nil, fmt.Errorf("redis otp store get: %w", err)
+		}
+		state.Phone = phone
+		return state, nil
+	}
+
+	return nil, otp.ErrOTPNotFound
+}
+
+// GetByRequestID retrieves OTP verification state through the request ID index.
+func (s *RedisOTPStore) GetByRequestID(ctx context.Context, tenantID int64, requestID string) (*otp.OTPState, error) {
+	index, err := s.client.HGetAll(ctx, redisOTPRequestKey(requestID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis otp store get by request id: %w", err)
+	}
+	if len(index) == 0 || index["tenant_id"] != strconv.FormatInt(tenantID, 10) {
+		return nil, otp.ErrOTPNotFound
+	}
+	key, err := parseStringField(index, "key")
+	if err != nil {
+		return nil, fmt.Errorf("redis otp store get by request id: %w", err)
+	}
+	phone, err := s.openIndexPhone(index, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("redis otp store get by request id: %w", err)
+	}
+
 	values, err := s.client.HGetAll(ctx, key).Result()
 	if err != nil {
-		return nil, fmt.Errorf("redis otp store get: %w", err)
+		return nil, fmt.Errorf("redis otp store get by request id: %w", err)
 	}
 	if len(values) == 0 {
 		return nil, otp.ErrOTPNotFound
 	}
-
 	state, err := parseOTPState(values)
 	if err != nil {
-		return nil, fmt.Errorf("redis otp store get: %w", err)
+		return nil, fmt.Errorf("redis otp store get by request id: %w", err)
 	}
-
+	// The index outlives a deleted OTP until its TTL, and the key may since hold a
+	// newer OTP of the same phone.
+	if state.RequestID != requestID || state.TenantID != tenantID {
+		return nil, otp.ErrOTPNotFound
+	}
+	state.Phone = phone
 	return state, nil
 }
 
-// IncrementAttempts is intentionally left for the next phase, where it will be atomic.
-func (s *RedisOTPStore) IncrementAttempts(ctx context.Context, tenantID int64, phone string) (int, error) {
-	key := redisOTPKey(tenantID, phone)
-	attempts, err := incrementOTPAttemptsScript.Run(ctx, s.client, []string{key}).Int()
-	if err != nil {
-		return 0, fmt.Errorf("redis otp store increment attempts: %w", err)
+// IncrementAttempts atomically counts a failed attempt while the OTP holds requestID.
+func (s *RedisOTPStore) IncrementAttempts(ctx context.Context, tenantID int64, phone string, purpose string, requestID string) (int, error) {
+	for _, key := range s.keys(tenantID, phone, purpose) {
+		attempts, err := incrementOTPAttemptsScript.Run(ctx, s.client, []string{key}, requestID).Int()
+		if err != nil {
+			return 0, fmt.Errorf("redis otp store increment attempts: %w", err)
+		}
+
+		switch attempts {
+		case -1:
+			continue
+		case -3:
+			return 0, otp.ErrOTPNotFound
+		case -2:
+			return 0, fmt.Errorf("redis otp store increment attempts: missing field %q", "attempt_count")
+		default:
+			return attempts, nil
+		}
 	}
 
-	switch attempts {
+	return 0, otp.ErrOTPNotFound
+}
+
+// Resend atomically replaces the code and expiry of the active OTP and counts the
+// resend, leaving its attempt count alone.
+func (s *RedisOTPStore) Resend(ctx context.Context, state otp.OTPState, ttl time.Duration) error {
+	if ttl <= 0 {
+		return fmt.Errorf("redis otp store resend: ttl must be positive")
+	}
+	return s.applyResend(ctx, "resend", state, state.ResendCount-1, ttl)
+}
+
+// RevertResend restores previous in the active OTP while it holds the resend that
+// followed previous. The OTP keeps whatever is left of its original expiry.
+func (s *RedisOTPStore) RevertResend(ctx context.Context, previous otp.OTPState) error {
+	// PX rejects 0, so an already elapsed expiry rounds up and lets the OTP expire.
+	ttl := max(time.Until(previous.ExpiresAt), time.Millisecond)
+	return s.applyResend(ctx, "revert resend", previous, previous.ResendCount+1, ttl)
+}
+
+// applyResend writes the code, resend count, last sent time and expiry of state while the
+// stored OTP has state.RequestID and expectedResendCount resends.
+func (s *RedisOTPStore) applyResend(ctx context.Context, op string, state otp.OTPState, expectedResendCount int, ttl time.Duration) error {
+	phoneField, phoneValue, err := s.indexPhone(state.Phone, state.RequestID)
+	if err != nil {
+		return fmt.Errorf("redis otp store %s: %w", op, err)
+	}
+	args := []interface{}{
+		strconv.FormatInt(ttl.Milliseconds(), 10),
+		state.RequestID,
+		strconv.Itoa(expectedResendCount),
+		strconv.FormatInt(state.TenantID, 10),
+		phoneField,
+		phoneValue,
+		"code_hash", state.CodeHash,
+		"code_hash_key_id", state.CodeHashKeyID,
+		"sealed_code", state.SealedCode,
+		"resend_count", strconv.Itoa(state.ResendCount),
+		"last_sent_at", state.LastSentAt.Format(time.RFC3339Nano),
+		"expires_at", state.ExpiresAt.Format(time.RFC3339Nano),
+	}
+	keys := append([]string{redisOTPRequestKey(state.RequestID)}, s.keys(state.TenantID, state.Phone, state.Purpose)...)
+	applied, err := resendOTPScript.Run(ctx, s.client, keys, args...).Int()
+	if err != nil {
+		return fmt.Errorf("redis otp store %s: %w", op, err)
+	}
+	switch applied {
 	case -1:
-		return 0, otp.ErrOTPNotFound
-	case -2:
-		return 0, fmt.Errorf("redis otp store increment attempts: missing field %q", "attempt_count")
+		return otp.ErrOTPNotFound
+	case 0:
+		return otp.ErrOTPResendCooldown
 	default:
-		return attempts, nil
+		return nil
 	}
 }
 
-// Delete removes OTP verification state from Redis.
-func (s *RedisOTPStore) Delete(ctx context.Context, tenantID int64, phone string) error {
-	key := redisOTPKey(tenantID, phone)
-	if err := s.client.Del(ctx, key).Err(); err != nil {
+// Delete removes OTP verification state from Redis. Its request ID index expires on its
+// own and no longer resolves once the state is gone.
+func (s *RedisOTPStore) Delete(ctx context.Context, tenantID int64, phone string, purpose string) error {
+	if err := s.client.Del(ctx, s.keys(tenantID, phone, purpose)...).Err(); err != nil {
 		return fmt.Errorf("redis otp store delete: %w", err)
 	}
 	return nil
 }
 
-func redisOTPKey(tenantID int64, phone string) string {
-	return fmt.Sprintf("otp:%d:%s", tenantID, phone)
+// DeleteRequest atomically removes the OTP and its request ID index while the OTP holds
+// requestID.
+func (s *RedisOTPStore) DeleteRequest(ctx context.Context, tenantID int64, phone string, purpose string, requestID string) error {
+	keys := append([]string{redisOTPRequestKey(requestID)}, s.keys(tenantID, phone, purpose)...)
+	deleted, err := deleteOTPRequestScript.Run(ctx, s.client, keys, requestID).Int()
+	if err != nil {
+		return fmt.Errorf("redis otp store delete request: %w", err)
+	}
+	if deleted == 0 {
+		return otp.ErrOTPNotFound
+	}
+	return nil
+}
+
+// Cancel atomically removes the OTP and its request ID index while the OTP holds
+// requestID, and marks the request and the phone cancelled for ttl.
+func (s *RedisOTPStore) Cancel(ctx context.Context, tenantID int64, phone string, purpose string, requestID string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return fmt.Errorf("redis otp store cancel: ttl must be positive")
+	}
+
+	keys := append([]string{
+		redisOTPRequestKey(requestID),
+		redisOTPCancelledRequestKey(requestID),
+		s.cancelledPhoneKey(tenantID, phone, purpose),
+	}, s.keys(tenantID, phone, purpose)...)
+	// PX rejects 0, so a sub-millisecond remainder rounds up.
+	ttlMillis := max(ttl.Milliseconds(), 1)
+	cancelled, err := cancelOTPScript.Run(ctx, s.client, keys,
+		requestID,
+		strconv.FormatInt(ttlMillis, 10),
+		strconv.FormatInt(tenantID, 10),
+	).Int()
+	if err != nil {
+		return fmt.Errorf("redis otp store cancel: %w", err)
+	}
+	if cancelled == 0 {
+		return otp.ErrOTPNotFound
+	}
+	return nil
+}
+
+// CancelledRequestID returns the request ID of the phone's cancelled OTP.
+func (s *RedisOTPStore) CancelledRequestID(ctx context.Context, tenantID int64, phone string, purpose string) (string, error) {
+	requestID, err := s.client.Get(ctx, s.cancelledPhoneKey(tenantID, phone, purpose)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return "", otp.ErrOTPNotFound
+		}
+		return "", fmt.Errorf("redis otp store cancelled request id: %w", err)
+	}
+	return requestID, nil
+}
+
+// IsCancelled reports whether the tenant's OTP issued under requestID was cancelled.
+func (s *RedisOTPStore) IsCancelled(ctx context.Context, tenantID int64, requestID string) (bool, error) {
+	cancelledTenantID, err := s.client.Get(ctx, redisOTPCancelledRequestKey(requestID)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return false, nil
+		}
+		return false, fmt.Errorf("redis otp store is cancelled: %w", err)
+	}
+	return cancelledTenantID == strconv.FormatInt(tenantID, 10), nil
+}
+
+// keys returns the OTP key name to write first, followed by the legacy plaintext phone
+// key name while legacy reads are on.
+func (s *RedisOTPStore) keys(tenantID int64, phone string, purpose string) []string {
+	phoneKeys := s.tenantPhoneKeys(tenantID, phone)
+	keys := make([]string, len(phoneKeys))
+	for i, phoneKey := range phoneKeys {
+		keys[i] = redisOTPKey(tenantID, purposePhoneKey(purpose, phoneKey))
+	}
+	return keys
+}
+
+// cancelledPhoneKey returns the key name marking the phone's cancelled OTP for purpose. Only the
+// current phone key name is used: markers live no longer than an OTP, so a missed legacy
+// marker only reports not_found instead of cancelled.
+func (s *RedisOTPStore) cancelledPhoneKey(tenantID int64, phone string, purpose string) string {
+	return fmt.Sprintf("otp:cancelled:%d:%s", tenantID, purposePhoneKey(purpose, s.tenantPhoneKey(tenantID, phone)))
+}
+
+// indexPhone returns the request ID index field and value holding phone: the phone
+// itself without a phone key hasher, else the phone sealed for requestID.
+func (s *RedisOTPStore) indexPhone(phone string, requestID string) (string, string, error) {
+	if s.phoneKeyHasher == nil {
+		return "phone", phone, nil
+	}
+	sealed, err := s.phoneKeyHasher.SealPhone(phone, requestID)
+	if err != nil {
+		return "", "", err
+	}
+	return "sealed_phone", sealed, nil
+}
+
+// openIndexPhone reads the phone from a request ID index written by indexPhone. Indexes
+// written before the hasher was set hold the plaintext phone.
+func (s *RedisOTPStore) openIndexPhone(index map[string]string, requestID string) (string, error) {
+	if sealed := index["sealed_phone"]; sealed != "" {
+		return s.phoneKeyHasher.OpenPhone(sealed, requestID)
+	}
+	return parseStringField(index, "phone")
+}
+
+func redisOTPKey(tenantID int64, phoneKey string) string {
+	return fmt.Sprintf("otp:%d:%s", tenantID, phoneKey)
+}
+
+func redisOTPRequestKey(requestID string) string {
+	return "otp:request:" + requestID
+}
+
+func redisOTPCancelledRequestKey(requestID string) string {
+	return "otp:cancelled:request:" + requestID
+}
+
+func otpStateFields(state otp.OTPState) []interface{} {
+	return []interface{}{
+		"request_id", state.RequestID,
+		"tenant_id", strconv.FormatInt(state.TenantID, 10),
+		"purpose", state.Purpose,
+		"code_hash", state.CodeHash,
+		"code_hash_key_id", state.CodeHashKeyID,
+		"sealed_code", state.SealedCode,
+		"attempt_count", strconv.Itoa(state.AttemptCount),
+		"max_attempts", strconv.Itoa(state.MaxAttempts),
+		"resend_count", strconv.Itoa(state.ResendCount),
+		"created_at", state.CreatedAt.Format(time.RFC3339Nano),
+		"last_sent_at", state.LastSentAt.Format(time.RFC3339Nano),
+		"expires_at", state.ExpiresAt.Format(time.RFC3339Nano),
+	}
 }
 
 func parseOTPState(values map[string]string) (*otp.OTPState, error) {
@@ -119,14 +471,17 @@ func parseOTPState(values map[string]string) (*otp.OTPState, error) {
 	if err != nil {
 		return nil, err
 	}
-	phone, err := parseStringField(values, "phone")
-	if err != nil {
-		return nil, err
+	// States written before purposes existed have no purpose and used the default key.
+	purpose := values["purpose"]
+	if purpose == "" {
+		purpose = otp.PurposeDefault
 	}
 	codeHash, err := parseStringField(values, "code_hash")
 	if err != nil {
 		return nil, err
 	}
+	// code_hash_key_id is empty or missing for states hashed with legacy SHA-256.
+	codeHashKeyID := values["code_hash_key_id"]
 	attemptCount, err := parseIntField(values, "attempt_count")
 	if err != nil {
 		return nil, err
@@ -143,16 +498,35 @@ func parseOTPState(values map[string]string) (*otp.OTPState, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Resend fields are missing from states written before resends existed.
+	var resendCount int
+	if _, ok := values["resend_count"]; ok {
+		if resendCount, err = parseIntField(values, "resend_count"); err != nil {
+			return nil, err
+		}
+	}
+	var lastSentAt time.Time
+	if _, ok := values["last_sent_at"]; ok {
+		if lastSentAt, err = parseTimeField(values, "last_sent_at"); err != nil {
+			return nil, err
+		}
+	}
 
+	// Phone is left empty: a phone field is only present in states written before phone
+	// key hashing, and the caller knows the phone it looked the state up by.
 	return &otp.OTPState{
-		RequestID:    requestID,
-		TenantID:     tenantID,
-		Phone:        phone,
-		CodeHash:     codeHash,
-		AttemptCount: attemptCount,
-		MaxAttempts:  maxAttempts,
-		CreatedAt:    createdAt,
-		ExpiresAt:    expiresAt,
+		RequestID:     requestID,
+		TenantID:      tenantID,
+		Purpose:       purpose,
+		CodeHash:      codeHash,
+		CodeHashKeyID: codeHashKeyID,
+		SealedCode:    values["sealed_code"],
+		AttemptCount:  attemptCount,
+		MaxAttempts:   maxAttempts,
+		ResendCount:   resendCount,
+		CreatedAt:     createdAt,
+		LastSentAt:    lastSentAt,
+		ExpiresAt:     expiresAt,
 	}, nil
 }
 
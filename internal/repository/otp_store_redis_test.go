@@ -2,6 +2,9 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -52,7 +55,7 @@ func TestRedisOTPStoreSaveGetDelete(t *testing.T) {
 	err := store.Save(ctx, state, 2*time.Minute)
 	require.NoError(t, err)
 
-	got, err := store.Get(ctx, state.TenantID, state.Phone)
+	got, err := store.Get(ctx, state.TenantID, state.Phone, otp.PurposeDefault)
 	require.NoError(t, err)
 	assert.Equal(t, state.RequestID, got.RequestID)
 	assert.Equal(t, state.TenantID, got.TenantID)
@@ -63,10 +66,10 @@ func TestRedisOTPStoreSaveGetDelete(t *testing.T) {
 	assert.True(t, state.CreatedAt.Equal(got.CreatedAt))
 	assert.True(t, state.ExpiresAt.Equal(got.ExpiresAt))
 
-	err = store.Delete(ctx, state.TenantID, state.Phone)
+	err = store.Delete(ctx, state.TenantID, state.Phone, otp.PurposeDefault)
 	require.NoError(t, err)
 
-	_, err = store.Get(ctx, state.TenantID, state.Phone)
+	_, err = store.Get(ctx, state.TenantID, state.Phone, otp.PurposeDefault)
 	assert.ErrorIs(t, err, otp.ErrOTPNotFound)
 }
 
@@ -76,7 +79,7 @@ func TestRedisOTPStoreGetMissing(t *testing.T) {
 
 	store := NewRedisOTPStore(client)
 
-	_, err := store.Get(context.Background(), 1002, "+989120001002")
+	_, err := store.Get(context.Background(), 1002, "+989120001002", otp.PurposeDefault)
 	assert.ErrorIs(t, err, otp.ErrOTPNotFound)
 }
 
@@ -101,7 +104,7 @@ func TestRedisOTPStoreGetMalformedValue(t *testing.T) {
 	}).Err()
 	require.NoError(t, err)
 
-	_, err = store.Get(ctx, 1003, "+989120001003")
+	_, err = store.Get(ctx, 1003, "+989120001003", otp.PurposeDefault)
 	require.Error(t, err)
 	assert.NotErrorIs(t, err, otp.ErrOTPNotFound)
 }
@@ -127,15 +130,15 @@ func TestRedisOTPStoreIncrementAttempts(t *testing.T) {
 	err := store.Save(ctx, state, 2*time.Minute)
 	require.NoError(t, err)
 
-	attempts, err := store.IncrementAttempts(ctx, state.TenantID, state.Phone)
+	attempts, err := store.IncrementAttempts(ctx, state.TenantID, state.Phone, otp.PurposeDefault, state.RequestID)
 	require.NoError(t, err)
 	assert.Equal(t, 1, attempts)
 
-	attempts, err = store.IncrementAttempts(ctx, state.TenantID, state.Phone)
+	attempts, err = store.IncrementAttempts(ctx, state.TenantID, state.Phone, otp.PurposeDefault, state.RequestID)
 	require.NoError(t, err)
 	assert.Equal(t, 2, attempts)
 
-	got, err := store.Get(ctx, state.TenantID, state.Phone)
+	got, err := store.Get(ctx, state.TenantID, state.Phone, otp.PurposeDefault)
 	require.NoError(t, err)
 	assert.Equal(t, 2, got.AttemptCount)
 }
@@ -146,7 +149,7 @@ func TestRedisOTPStoreIncrementAttemptsMissingKey(t *testing.T) {
 
 	store := NewRedisOTPStore(client)
 
-	attempts, err := store.IncrementAttempts(context.Background(), 1005, "+989120001005")
+	attempts, err := store.IncrementAttempts(context.Background(), 1005, "+989120001005", otp.PurposeDefault, "request-missing")
 
 	assert.Equal(t, 0, attempts)
 	assert.ErrorIs(t, err, otp.ErrOTPNotFound)
@@ -169,7 +172,7 @@ func TestRedisOTPStoreIncrementAttemptsMissingField(t *testing.T) {
 	}).Err()
 	require.NoError(t, err)
 
-	attempts, err := store.IncrementAttempts(ctx, 1006, "+989120001006")
+	attempts, err := store.IncrementAttempts(ctx, 1006, "+989120001006", otp.PurposeDefault, "request-missing-attempt-count")
 
 	assert.Equal(t, 0, attempts)
 	require.Error(t, err)
@@ -194,9 +197,596 @@ func TestRedisOTPStoreIncrementAttemptsNonIntegerField(t *testing.T) {
 	}).Err()
 	require.NoError(t, err)
 
-	attempts, err := store.IncrementAttempts(ctx, 1007, "+989120001007")
+	attempts, err := store.IncrementAttempts(ctx, 1007, "+989120001007", otp.PurposeDefault, "request-non-integer-attempt-count")
 
 	assert.Equal(t, 0, attempts)
 	require.Error(t, err)
 	assert.NotErrorIs(t, err, otp.ErrOTPNotFound)
 }
+
+func TestRedisOTPStoreReserve(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	store := NewRedisOTPStore(client)
+	ctx := context.Background()
+	state := otp.OTPState{
+		RequestID:    "request-reserve",
+		TenantID:     1008,
+		Phone:        "+989120001008",
+		CodeHash:     otp.HashCode("123456"),
+		AttemptCount: 0,
+		MaxAttempts:  3,
+		CreatedAt:    time.Now().UTC().Round(0),
+		ExpiresAt:    time.Now().UTC().Add(2 * time.Minute).Round(0),
+	}
+	key := redisOTPKey(state.TenantID, state.Phone)
+	defer client.Del(ctx, key)
+	require.NoError(t, client.Del(ctx, key).Err())
+
+	require.NoError(t, store.Reserve(ctx, state, 2*time.Minute))
+
+	second := state
+	second.RequestID = "request-reserve-second"
+	second.CodeHash = otp.HashCode("654321")
+	err := store.Reserve(ctx, second, 2*time.Minute)
+	assert.ErrorIs(t, err, otp.ErrOTPAlreadyActive)
+
+	got, err := store.Get(ctx, state.TenantID, state.Phone, otp.PurposeDefault)
+	require.NoError(t, err)
+	assert.Equal(t, state.RequestID, got.RequestID)
+	assert.Equal(t, state.CodeHash, got.CodeHash)
+
+	ttl, err := client.TTL(ctx, key).Result()
+	require.NoError(t, err)
+	assert.Greater(t, ttl, time.Duration(0))
+}
+
+func TestRedisOTPStoreReserveInvalidTTL(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	store := NewRedisOTPStore(client)
+
+	err := store.Reserve(context.Background(), otp.OTPState{TenantID: 1009, Phone: "+989120001009"}, 0)
+
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, otp.ErrOTPAlreadyActive)
+}
+
+func TestRedisOTPStoreReserveConcurrent(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	store := NewRedisOTPStore(client)
+	ctx := context.Background()
+	tenantID := int64(1010)
+	phone := "+989120001010"
+	key := redisOTPKey(tenantID, phone)
+	defer client.Del(ctx, key)
+	require.NoError(t, client.Del(ctx, key).Err())
+
+	totalCalls := 10
+	errs := make(chan error, totalCalls)
+
+	var wg sync.WaitGroup
+	for i := 0; i < totalCalls; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- store.Reserve(ctx, otp.OTPState{
+				RequestID:    fmt.Sprintf("request-reserve-concurrent-%d", i),
+				TenantID:     tenantID,
+				Phone:        phone,
+				CodeHash:     otp.HashCode("123456"),
+				AttemptCount: 0,
+				MaxAttempts:  3,
+				CreatedAt:    time.Now().UTC(),
+				ExpiresAt:    time.Now().UTC().Add(2 * time.Minute),
+			}, 2*time.Minute)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	reserved := 0
+	alreadyActive := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			reserved++
+		case errors.Is(err, otp.ErrOTPAlreadyActive):
+			alreadyActive++
+		default:
+			require.NoError(t, err)
+		}
+	}
+
+	assert.Equal(t, 1, reserved)
+	assert.Equal(t, totalCalls-1, alreadyActive)
+}
+
+func TestRedisOTPStoreCodeHashKeyID(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	store := NewRedisOTPStore(client)
+	ctx := context.Background()
+	state := otp.OTPState{
+		RequestID:     "request-code-hash-key-id",
+		TenantID:      1011,
+		Phone:         "+989120001011",
+		CodeHash:      "keyed-hash",
+		CodeHashKeyID: "k1",
+		AttemptCount:  0,
+		MaxAttempts:   3,
+		CreatedAt:     time.Now().UTC().Round(0),
+		ExpiresAt:     time.Now().UTC().Add(2 * time.Minute).Round(0),
+	}
+	defer client.Del(ctx, redisOTPKey(state.TenantID, state.Phone))
+
+	require.NoError(t, store.Save(ctx, state, 2*time.Minute))
+
+	got, err := store.Get(ctx, state.TenantID, state.Phone, otp.PurposeDefault)
+	require.NoError(t, err)
+	assert.Equal(t, "k1", got.CodeHashKeyID)
+}
+
+func TestRedisOTPStoreGetLegacyStateWithoutCodeHashKeyID(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	store := NewRedisOTPStore(client)
+	ctx := context.Background()
+	key := redisOTPKey(1012, "+989120001012")
+	defer client.Del(ctx, key)
+
+	err := client.HSet(ctx, key, map[string]interface{}{
+		"request_id":    "request-legacy-hash",
+		"tenant_id":     "1012",
+		"phone":         "+989120001012",
+		"code_hash":     otp.HashCode("123456"),
+		"attempt_count": "0",
+		"max_attempts":  "3",
+		"created_at":    time.Now().UTC().Format(time.RFC3339Nano),
+		"expires_at":    time.Now().UTC().Add(2 * time.Minute).Format(time.RFC3339Nano),
+	}).Err()
+	require.NoError(t, err)
+
+	got, err := store.Get(ctx, 1012, "+989120001012", otp.PurposeDefault)
+	require.NoError(t, err)
+	assert.Empty(t, got.CodeHashKeyID)
+	assert.Equal(t, otp.HashCode("123456"), got.CodeHash)
+}
+
+func newTestPhoneKeyHasher(t *testing.T) *otp.PhoneKeyHasher {
+	t.Helper()
+	hasher, err := otp.NewPhoneKeyHasher([]byte("test-phone-key-secret"))
+	require.NoError(t, err)
+	return hasher
+}
+
+func TestRedisOTPStoreHashedPhoneKey(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	hasher := newTestPhoneKeyHasher(t)
+	store := NewRedisOTPStore(client)
+	store.SetPhoneKeyHasher(hasher)
+	ctx := context.Background()
+	state := otp.OTPState{
+		RequestID:    "request-hashed-phone-key",
+		TenantID:     1013,
+		Phone:        "+989120001013",
+		CodeHash:     otp.HashCode("123456"),
+		AttemptCount: 0,
+		MaxAttempts:  3,
+		CreatedAt:    time.Now().UTC().Round(0),
+		ExpiresAt:    time.Now().UTC().Add(2 * time.Minute).Round(0),
+	}
+	key := redisOTPKey(state.TenantID, hasher.TenantKey(state.TenantID, state.Phone))
+	defer client.Del(ctx, key)
+
+	require.NoError(t, store.Reserve(ctx, state, 2*time.Minute))
+
+	// Neither the key name nor the hash carries the phone.
+	exists, err := client.Exists(ctx, redisOTPKey(state.TenantID, state.Phone)).Result()
+	require.NoError(t, err)
+	assert.Zero(t, exists)
+	fields, err := client.HGetAll(ctx, key).Result()
+	require.NoError(t, err)
+	assert.NotEmpty(t, fields)
+	assert.NotContains(t, fields, "phone")
+
+	got, err := store.Get(ctx, state.TenantID, state.Phone, otp.PurposeDefault)
+	require.NoError(t, err)
+	assert.Equal(t, state.Phone, got.Phone)
+	assert.Equal(t, state.RequestID, got.RequestID)
+}
+
+func TestRedisOTPStoreLegacyPhoneKeyReads(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	hasher := newTestPhoneKeyHasher(t)
+	ctx := context.Background()
+	tenantID := int64(1014)
+	phone := "+989120001014"
+	legacyKey := redisOTPKey(tenantID, phone)
+	hashedKey := redisOTPKey(tenantID, hasher.TenantKey(tenantID, phone))
+	defer client.Del(ctx, legacyKey, hashedKey)
+
+	legacyState := otp.OTPState{
+		RequestID:    "request-legacy-phone-key",
+		TenantID:     tenantID,
+		Phone:        phone,
+		CodeHash:     otp.HashCode("123456"),
+		AttemptCount: 0,
+		MaxAttempts:  3,
+		CreatedAt:    time.Now().UTC().Round(0),
+		ExpiresAt:    time.Now().UTC().Add(2 * time.Minute).Round(0),
+	}
+	require.NoError(t, NewRedisOTPStore(client).Save(ctx, legacyState, 2*time.Minute))
+
+	withoutLegacy := NewRedisOTPStore(client)
+	withoutLegacy.SetPhoneKeyHasher(hasher)
+	_, err := withoutLegacy.Get(ctx, tenantID, phone, otp.PurposeDefault)
+	assert.ErrorIs(t, err, otp.ErrOTPNotFound)
+
+	store := NewRedisOTPStore(client)
+	store.SetPhoneKeyHasher(hasher)
+	store.SetLegacyPhoneKeyReads(true)
+
+	got, err := store.Get(ctx, tenantID, phone, otp.PurposeDefault)
+	require.NoError(t, err)
+	assert.Equal(t, legacyState.RequestID, got.RequestID)
+
+	attempts, err := store.IncrementAttempts(ctx, tenantID, phone, otp.PurposeDefault, legacyState.RequestID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempts)
+
+	// The legacy OTP is still active, so no second OTP can be reserved under the new key.
+	newState := legacyState
+	newState.RequestID = "request-hashed-after-legacy"
+	assert.ErrorIs(t, store.Reserve(ctx, newState, 2*time.Minute), otp.ErrOTPAlreadyActive)
+
+	require.NoError(t, store.Delete(ctx, tenantID, phone, otp.PurposeDefault))
+	exists, err := client.Exists(ctx, legacyKey).Result()
+	require.NoError(t, err)
+	assert.Zero(t, exists)
+
+	require.NoError(t, store.Reserve(ctx, newState, 2*time.Minute))
+	exists, err = client.Exists(ctx, hashedKey).Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), exists)
+}
+
+func TestRedisOTPStoreGetByRequestID(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	store := NewRedisOTPStore(client)
+	ctx := context.Background()
+	state := otp.OTPState{
+		RequestID:    "request-get-by-request-id",
+		TenantID:     1015,
+		Phone:        "+989120001015",
+		CodeHash:     otp.HashCode("123456"),
+		AttemptCount: 0,
+		MaxAttempts:  3,
+		CreatedAt:    time.Now().UTC().Round(0),
+		ExpiresAt:    time.Now().UTC().Add(2 * time.Minute).Round(0),
+	}
+	indexKey := redisOTPRequestKey(state.RequestID)
+	defer client.Del(ctx, redisOTPKey(state.TenantID, state.Phone), indexKey)
+
+	require.NoError(t, store.Reserve(ctx, state, 2*time.Minute))
+
+	got, err := store.GetByRequestID(ctx, state.TenantID, state.RequestID)
+	require.NoError(t, err)
+	assert.Equal(t, state.RequestID, got.RequestID)
+	assert.Equal(t, state.Phone, got.Phone)
+	assert.Equal(t, state.CodeHash, got.CodeHash)
+	ttl, err := client.TTL(ctx, indexKey).Result()
+	require.NoError(t, err)
+	assert.Greater(t, ttl, time.Duration(0))
+
+	_, err = store.GetByRequestID(ctx, 1016, state.RequestID)
+	assert.ErrorIs(t, err, otp.ErrOTPNotFound)
+	_, err = store.GetByRequestID(ctx, state.TenantID, "request-unknown")
+	assert.ErrorIs(t, err, otp.ErrOTPNotFound)
+}
+
+func TestRedisOTPStoreGetByRequestIDStaleAfterReplacement(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	store := NewRedisOTPStore(client)
+	ctx := context.Background()
+	old := otp.OTPState{
+		RequestID:    "request-stale-old",
+		TenantID:     1017,
+		Phone:        "+989120001017",
+		CodeHash:     otp.HashCode("123456"),
+		AttemptCount: 0,
+		MaxAttempts:  3,
+		CreatedAt:    time.Now().UTC().Round(0),
+		ExpiresAt:    time.Now().UTC().Add(2 * time.Minute).Round(0),
+	}
+	newer := old
+	newer.RequestID = "request-stale-new"
+	defer client.Del(ctx, redisOTPKey(old.TenantID, old.Phone), redisOTPRequestKey(old.RequestID), redisOTPRequestKey(newer.RequestID))
+
+	require.NoError(t, store.Reserve(ctx, old, 2*time.Minute))
+	require.NoError(t, store.Delete(ctx, old.TenantID, old.Phone, otp.PurposeDefault))
+	require.NoError(t, store.Reserve(ctx, newer, 2*time.Minute))
+
+	// The old index still exists but points at the newer OTP.
+	_, err := store.GetByRequestID(ctx, old.TenantID, old.RequestID)
+	assert.ErrorIs(t, err, otp.ErrOTPNotFound)
+	_, err = store.IncrementAttempts(ctx, old.TenantID, old.Phone, otp.PurposeDefault, old.RequestID)
+	assert.ErrorIs(t, err, otp.ErrOTPNotFound)
+	assert.ErrorIs(t, store.DeleteRequest(ctx, old.TenantID, old.Phone, otp.PurposeDefault, old.RequestID), otp.ErrOTPNotFound)
+
+	got, err := store.GetByRequestID(ctx, newer.TenantID, newer.RequestID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, got.AttemptCount)
+}
+
+func TestRedisOTPStoreDeleteRequest(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	store := NewRedisOTPStore(client)
+	ctx := context.Background()
+	state := otp.OTPState{
+		RequestID:    "request-delete-request",
+		TenantID:     1018,
+		Phone:        "+989120001018",
+		CodeHash:     otp.HashCode("123456"),
+		AttemptCount: 0,
+		MaxAttempts:  3,
+		CreatedAt:    time.Now().UTC().Round(0),
+		ExpiresAt:    time.Now().UTC().Add(2 * time.Minute).Round(0),
+	}
+	key := redisOTPKey(state.TenantID, state.Phone)
+	indexKey := redisOTPRequestKey(state.RequestID)
+	defer client.Del(ctx, key, indexKey)
+
+	require.NoError(t, store.Reserve(ctx, state, 2*time.Minute))
+
+	require.NoError(t, store.DeleteRequest(ctx, state.TenantID, state.Phone, otp.PurposeDefault, state.RequestID))
+	exists, err := client.Exists(ctx, key, indexKey).Result()
+	require.NoError(t, err)
+	assert.Zero(t, exists)
+
+	// A second consumer of the same OTP loses.
+	assert.ErrorIs(t, store.DeleteRequest(ctx, state.TenantID, state.Phone, otp.PurposeDefault, state.RequestID), otp.ErrOTPNotFound)
+}
+
+func TestRedisOTPStoreGetByRequestIDHashedPhone(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	hasher := newTestPhoneKeyHasher(t)
+	store := NewRedisOTPStore(client)
+	store.SetPhoneKeyHasher(hasher)
+	ctx := context.Background()
+	state := otp.OTPState{
+		RequestID:    "request-get-by-request-id-hashed",
+		TenantID:     1019,
+		Phone:        "+989120001019",
+		CodeHash:     otp.HashCode("123456"),
+		AttemptCount: 0,
+		MaxAttempts:  3,
+		CreatedAt:    time.Now().UTC().Round(0),
+		ExpiresAt:    time.Now().UTC().Add(2 * time.Minute).Round(0),
+	}
+	indexKey := redisOTPRequestKey(state.RequestID)
+	defer client.Del(ctx, redisOTPKey(state.TenantID, hasher.TenantKey(state.TenantID, state.Phone)), indexKey)
+
+	require.NoError(t, store.Reserve(ctx, state, 2*time.Minute))
+
+	// The index seals the phone instead of storing it.
+	index, err := client.HGetAll(ctx, indexKey).Result()
+	require.NoError(t, err)
+	assert.NotContains(t, index, "phone")
+	assert.NotContains(t, index["sealed_phone"], "9120001019")
+
+	got, err := store.GetByRequestID(ctx, state.TenantID, state.RequestID)
+	require.NoError(t, err)
+	assert.Equal(t, state.Phone, got.Phone)
+
+	resent := *got
+	resent.ResendCount = 1
+	resent.LastSentAt = time.Now().UTC().Round(0)
+	resent.ExpiresAt = time.Now().UTC().Add(2 * time.Minute).Round(0)
+	require.NoError(t, store.Resend(ctx, resent, 2*time.Minute))
+
+	got, err = store.GetByRequestID(ctx, state.TenantID, state.RequestID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.ResendCount)
+	assert.Equal(t, state.Phone, got.Phone)
+}
+
+func TestRedisOTPStoreRevertResend(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	store := NewRedisOTPStore(client)
+	ctx := context.Background()
+	state := otp.OTPState{
+		RequestID:    "request-revert-resend",
+		TenantID:     1021,
+		Phone:        "+989120001021",
+		CodeHash:     otp.HashCode("123456"),
+		AttemptCount: 1,
+		MaxAttempts:  3,
+		CreatedAt:    time.Now().UTC().Round(0),
+		LastSentAt:   time.Now().UTC().Round(0),
+		ExpiresAt:    time.Now().UTC().Add(time.Minute).Round(0),
+	}
+	defer client.Del(ctx, redisOTPKey(state.TenantID, state.Phone), redisOTPRequestKey(state.RequestID))
+	require.NoError(t, store.Reserve(ctx, state, time.Minute))
+	previous, err := store.Get(ctx, state.TenantID, state.Phone, otp.PurposeDefault)
+	require.NoError(t, err)
+
+	// Reverting before the resend landed finds no resend to undo.
+	assert.ErrorIs(t, store.RevertResend(ctx, *previous), otp.ErrOTPResendCooldown)
+
+	resent := *previous
+	resent.CodeHash = otp.HashCode("654321")
+	resent.ResendCount = 1
+	resent.LastSentAt = time.Now().UTC().Round(0)
+	resent.ExpiresAt = time.Now().UTC().Add(2 * time.Minute).Round(0)
+	require.NoError(t, store.Resend(ctx, resent, 2*time.Minute))
+	_, err = store.IncrementAttempts(ctx, state.TenantID, state.Phone, otp.PurposeDefault, state.RequestID)
+	require.NoError(t, err)
+
+	require.NoError(t, store.RevertResend(ctx, *previous))
+
+	got, err := store.Get(ctx, state.TenantID, state.Phone, otp.PurposeDefault)
+	require.NoError(t, err)
+	assert.True(t, otp.VerifyCode("123456", got.CodeHash))
+	assert.Equal(t, 0, got.ResendCount)
+	assert.Equal(t, 2, got.AttemptCount)
+	assert.True(t, got.ExpiresAt.Equal(state.ExpiresAt))
+	ttl, err := client.PTTL(ctx, redisOTPKey(state.TenantID, state.Phone)).Result()
+	require.NoError(t, err)
+	assert.LessOrEqual(t, ttl, time.Minute)
+}
+
+func TestRedisOTPStoreCancel(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	store := NewRedisOTPStore(client)
+	ctx := context.Background()
+	state := otp.OTPState{
+		RequestID:    "request-cancel",
+		TenantID:     1020,
+		Phone:        "+989120001020",
+		CodeHash:     otp.HashCode("123456"),
+		AttemptCount: 0,
+		MaxAttempts:  3,
+		CreatedAt:    time.Now().UTC().Round(0),
+		ExpiresAt:    time.Now().UTC().Add(2 * time.Minute).Round(0),
+	}
+	key := redisOTPKey(state.TenantID, state.Phone)
+	indexKey := redisOTPRequestKey(state.RequestID)
+	cancelledRequestKey := redisOTPCancelledRequestKey(state.RequestID)
+	cancelledPhoneKey := store.cancelledPhoneKey(state.TenantID, state.Phone, otp.PurposeDefault)
+	defer client.Del(ctx, key, indexKey, cancelledRequestKey, cancelledPhoneKey)
+
+	require.NoError(t, store.Reserve(ctx, state, 2*time.Minute))
+	assert.ErrorIs(t, store.Cancel(ctx, state.TenantID, state.Phone, otp.PurposeDefault, "request-other", time.Minute), otp.ErrOTPNotFound)
+
+	require.NoError(t, store.Cancel(ctx, state.TenantID, state.Phone, otp.PurposeDefault, state.RequestID, time.Minute))
+	exists, err := client.Exists(ctx, key, indexKey).Result()
+	require.NoError(t, err)
+	assert.Zero(t, exists)
+	ttl, err := client.PTTL(ctx, cancelledPhoneKey).Result()
+	require.NoError(t, err)
+	assert.Greater(t, ttl, time.Duration(0))
+	assert.LessOrEqual(t, ttl, time.Minute)
+
+	requestID, err := store.CancelledRequestID(ctx, state.TenantID, state.Phone, otp.PurposeDefault)
+	require.NoError(t, err)
+	assert.Equal(t, state.RequestID, requestID)
+	cancelled, err := store.IsCancelled(ctx, state.TenantID, state.RequestID)
+	require.NoError(t, err)
+	assert.True(t, cancelled)
+	cancelled, err = store.IsCancelled(ctx, 1021, state.RequestID)
+	require.NoError(t, err)
+	assert.False(t, cancelled)
+
+	// The OTP can only be cancelled once.
+	assert.ErrorIs(t, store.Cancel(ctx, state.TenantID, state.Phone, otp.PurposeDefault, state.RequestID, time.Minute), otp.ErrOTPNotFound)
+}
+
+func TestRedisOTPStoreReserveClearsCancellation(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	store := NewRedisOTPStore(client)
+	ctx := context.Background()
+	state := otp.OTPState{
+		RequestID:    "request-cancel-then-send",
+		TenantID:     1022,
+		Phone:        "+989120001022",
+		CodeHash:     otp.HashCode("123456"),
+		AttemptCount: 0,
+		MaxAttempts:  3,
+		CreatedAt:    time.Now().UTC().Round(0),
+		ExpiresAt:    time.Now().UTC().Add(2 * time.Minute).Round(0),
+	}
+	newer := state
+	newer.RequestID = "request-cancel-then-send-new"
+	defer client.Del(ctx,
+		redisOTPKey(state.TenantID, state.Phone),
+		redisOTPRequestKey(state.RequestID),
+		redisOTPRequestKey(newer.RequestID),
+		redisOTPCancelledRequestKey(state.RequestID),
+		store.cancelledPhoneKey(state.TenantID, state.Phone, otp.PurposeDefault),
+	)
+
+	require.NoError(t, store.Reserve(ctx, state, 2*time.Minute))
+	require.NoError(t, store.Cancel(ctx, state.TenantID, state.Phone, otp.PurposeDefault, state.RequestID, time.Minute))
+	require.NoError(t, store.Reserve(ctx, newer, 2*time.Minute))
+
+	_, err := store.CancelledRequestID(ctx, state.TenantID, state.Phone, otp.PurposeDefault)
+	assert.ErrorIs(t, err, otp.ErrOTPNotFound)
+	// The cancelled request stays cancelled.
+	cancelled, err := store.IsCancelled(ctx, state.TenantID, state.RequestID)
+	require.NoError(t, err)
+	assert.True(t, cancelled)
+}
+
+func TestRedisOTPStorePurposesAreIndependent(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	store := NewRedisOTPStore(client)
+	ctx := context.Background()
+	state := otp.OTPState{
+		RequestID:    "request-purpose-default",
+		TenantID:     1023,
+		Phone:        "+989120001023",
+		Purpose:      otp.PurposeDefault,
+		CodeHash:     otp.HashCode("123456"),
+		AttemptCount: 0,
+		MaxAttempts:  3,
+		CreatedAt:    time.Now().UTC().Round(0),
+		ExpiresAt:    time.Now().UTC().Add(2 * time.Minute).Round(0),
+	}
+	login := state
+	login.RequestID = "request-purpose-login"
+	login.Purpose = "login"
+	login.CodeHash = otp.HashCode("654321")
+	defer client.Del(ctx,
+		redisOTPKey(state.TenantID, state.Phone),
+		redisOTPKey(state.TenantID, "login:"+state.Phone),
+		redisOTPRequestKey(state.RequestID),
+		redisOTPRequestKey(login.RequestID),
+	)
+
+	require.NoError(t, store.Reserve(ctx, state, 2*time.Minute))
+	require.NoError(t, store.Reserve(ctx, login, 2*time.Minute))
+	assert.ErrorIs(t, store.Reserve(ctx, login, 2*time.Minute), otp.ErrOTPAlreadyActive)
+
+	got, err := store.Get(ctx, state.TenantID, state.Phone, "login")
+	require.NoError(t, err)
+	assert.Equal(t, login.RequestID, got.RequestID)
+	assert.Equal(t, "login", got.Purpose)
+	got, err = store.GetByRequestID(ctx, state.TenantID, login.RequestID)
+	require.NoError(t, err)
+	assert.Equal(t, "login", got.Purpose)
+	_, err = store.Get(ctx, state.TenantID, state.Phone, "payment")
+	assert.ErrorIs(t, err, otp.ErrOTPNotFound)
+
+	require.NoError(t, store.DeleteRequest(ctx, state.TenantID, state.Phone, "login", login.RequestID))
+	got, err = store.Get(ctx, state.TenantID, state.Phone, otp.PurposeDefault)
+	require.NoError(t, err)
+	assert.Equal(t, state.RequestID, got.RequestID)
+	assert.Equal(t, otp.PurposeDefault, got.Purpose)
+}
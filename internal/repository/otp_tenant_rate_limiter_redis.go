@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-backend-service/internal/otp"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const tenantSendRateLimitWindow = time.Minute
+
+// RedisTenantSendRateLimiter limits OTP sends per tenant, across all phones, with a
+// one-minute Redis fixed window sized by the tenant's own rate_limit_per_min.
+type RedisTenantSendRateLimiter struct {
+	client *redis.Client
+}
+
+// NewRedisTenantSendRateLimiter creates a Redis-backed tenant-wide OTP send rate limiter.
+func NewRedisTenantSendRateLimiter(client *redis.Client) *RedisTenantSendRateLimiter {
+	return &RedisTenantSendRateLimiter{client: client}
+}
+
+// AllowTenantSend returns nil when the tenant is under its quota, or an *otp.LimitError
+// wrapping otp.ErrTenantRateLimited when it is exceeded.
+func (l *RedisTenantSendRateLimiter) AllowTenantSend(ctx context.Context, tenantID int64, limitPerMin int) error {
+	if l.client == nil {
+		return fmt.Errorf("redis tenant send rate limiter: client is nil")
+	}
+	if limitPerMin <= 0 {
+		return fmt.Errorf("redis tenant send rate limiter: limit must be positive")
+	}
+
+	key := redisTenantSendRateLimitKey(tenantID)
+	count, resetAfter, err := runFixedWindow(ctx, l.client, key, tenantSendRateLimitWindow)
+	if err != nil {
+		return fmt.Errorf("redis tenant send rate limiter allow send: %w", err)
+	}
+	if count > int64(limitPerMin) {
+		return &otp.LimitError{Err: otp.ErrTenantRateLimited, Limit: limitPerMin, ResetAfter: resetAfter}
+	}
+
+	return nil
+}
+
+func redisTenantSendRateLimitKey(tenantID int64) string {
+	return fmt.Sprintf("otp:rate:tenant:%d", tenantID)
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-backend-service/internal/otp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisTenantSendRateLimiterBlocksAfterTenantQuota(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	ctx := context.Background()
+	tenantID := int64(3101)
+	key := redisTenantSendRateLimitKey(tenantID)
+	defer client.Del(ctx, key)
+	require.NoError(t, client.Del(ctx, key).Err())
+
+	limiter := NewRedisTenantSendRateLimiter(client)
+
+	require.NoError(t, limiter.AllowTenantSend(ctx, tenantID, 2))
+	require.NoError(t, limiter.AllowTenantSend(ctx, tenantID, 2))
+	err := limiter.AllowTenantSend(ctx, tenantID, 2)
+
+	assert.ErrorIs(t, err, otp.ErrTenantRateLimited)
+	assert.NotErrorIs(t, err, otp.ErrOTPRateLimited)
+	var limitErr *otp.LimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, 2, limitErr.Limit)
+	assert.Greater(t, limitErr.ResetAfter, time.Duration(0))
+	assert.LessOrEqual(t, limitErr.ResetAfter, time.Minute)
+}
+
+func TestRedisTenantSendRateLimiterSetsMinuteTTL(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	ctx := context.Background()
+	tenantID := int64(3102)
+	key := redisTenantSendRateLimitKey(tenantID)
+	defer client.Del(ctx, key)
+	require.NoError(t, client.Del(ctx, key).Err())
+
+	limiter := NewRedisTenantSendRateLimiter(client)
+
+	require.NoError(t, limiter.AllowTenantSend(ctx, tenantID, 10))
+
+	ttl, err := client.TTL(ctx, key).Result()
+	require.NoError(t, err)
+	assert.Greater(t, ttl, time.Duration(0))
+	assert.LessOrEqual(t, ttl, time.Minute)
+}
+
+func TestRedisTenantSendRateLimiterIsolatesTenants(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	ctx := context.Background()
+	keyA := redisTenantSendRateLimitKey(3103)
+	keyB := redisTenantSendRateLimitKey(3104)
+	defer client.Del(ctx, keyA, keyB)
+	require.NoError(t, client.Del(ctx, keyA, keyB).Err())
+
+	limiter := NewRedisTenantSendRateLimiter(client)
+
+	require.NoError(t, limiter.AllowTenantSend(ctx, 3103, 1))
+	require.NoError(t, limiter.AllowTenantSend(ctx, 3104, 1))
+	assert.ErrorIs(t, limiter.AllowTenantSend(ctx, 3103, 1), otp.ErrTenantRateLimited)
+}
+
+func TestRedisTenantSendRateLimiterInvalidConfig(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	ctx := context.Background()
+
+	assert.Error(t, NewRedisTenantSendRateLimiter(nil).AllowTenantSend(ctx, 3105, 1))
+	assert.Error(t, NewRedisTenantSendRateLimiter(client).AllowTenantSend(ctx, 3105, 0))
+}
@@ -0,0 +1,56 @@
+package repository
+
+import "go-backend-service/internal/otp"
+
+// phoneKeys is embedded by the Redis adapters whose key names include a phone number.
+type phoneKeys struct {
+	phoneKeyHasher *otp.PhoneKeyHasher
+}
+
+// SetPhoneKeyHasher replaces the phone in key names with a keyed hash of it. Without a
+// hasher, key names carry the plaintext phone.
+func (k *phoneKeys) SetPhoneKeyHasher(hasher *otp.PhoneKeyHasher) {
+	k.phoneKeyHasher = hasher
+}
+
+// tenantPhoneKey returns the phone part of a tenant-scoped key name.
+func (k *phoneKeys) tenantPhoneKey(tenantID int64, phone string) string {
+	return k.phoneKeyHasher.TenantKey(tenantID, phone)
+}
+
+// globalPhoneKey returns the phone part of a key name shared by all tenants.
+func (k *phoneKeys) globalPhoneKey(phone string) string {
+	return k.phoneKeyHasher.GlobalKey(phone)
+}
+
+// legacyPhoneKeys is phoneKeys for adapters that can also read keys written before
+// hashing was enabled, so state created during a rollout is not lost.
+type legacyPhoneKeys struct {
+	phoneKeys
+	legacyReads bool
+}
+
+// SetLegacyPhoneKeyReads makes reads fall back to plaintext phone keys when hashing is
+// enabled. Turn it off once the longest-lived legacy key has expired.
+func (k *legacyPhoneKeys) SetLegacyPhoneKeyReads(enabled bool) {
+	k.legacyReads = enabled
+}
+
+// tenantPhoneKeys returns the phone part of the key name to write first, followed by the
+// plaintext phone when legacy reads are on.
+func (k *legacyPhoneKeys) tenantPhoneKeys(tenantID int64, phone string) []string {
+	phoneKeys := []string{k.tenantPhoneKey(tenantID, phone)}
+	if k.phoneKeyHasher != nil && k.legacyReads {
+		phoneKeys = append(phoneKeys, phone)
+	}
+	return phoneKeys
+}
+
+// purposePhoneKey scopes the phone part of a key name to an OTP purpose. The default
+// purpose keeps the bare phone key, so key names written before purposes stay valid.
+func purposePhoneKey(purpose string, phoneKey string) string {
+	if purpose == "" || purpose == otp.PurposeDefault {
+		return phoneKey
+	}
+	return purpose + ":" + phoneKey
+}
@@ -4,13 +4,25 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
+	"go-backend-service/internal/logger"
 	"go-backend-service/internal/otp"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// Tenant metadata keys holding provider-side SMS settings.
+const (
+	smsTemplateMetadataKey       = "sms_template"
+	smsTemplateParamsMetadataKey = "sms_template_params"
+	smsSenderMetadataKey         = "sms_sender"
+	smsFailoverMetadataKey       = "sms_failover"
+	smsProvidersMetadataKey      = "sms_providers"
+	smsProviderAPIKeyField       = "api_key"
+)
+
 type tenantSettingsSource interface {
 	GetTenantSettingsByID(ctx context.Context, tenantID int64) (*TenantSettings, error)
 }
@@ -68,17 +80,102 @@ func tenantSettingsCacheKey(tenantID int64) string {
 	return fmt.Sprintf("tenant:%d:settings", tenantID)
 }
 
+// mapTenantSettingsToOTP keeps an invalid otp_policy from taking the tenant's OTP flows
+// down without failing open: the policy keeps its valid fields and stays marked Invalid,
+// which restricts sends to the default purpose or the valid listed purposes.
 func mapTenantSettingsToOTP(settings *TenantSettings) *otp.TenantSettings {
+	policy, err := otp.ParsePolicy(settings.Metadata)
+	if err != nil {
+		log := logger.Get()
+		log.Error().
+			Err(err).
+			Int64("tenant_id", settings.ID).
+			Msg("Invalid tenant OTP policy; applying its valid fields and restricting purposes")
+	}
+
 	return &otp.TenantSettings{
-		ID:              settings.ID,
-		TenantCode:      settings.TenantCode,
-		Name:            settings.Name,
-		Status:          string(settings.Status),
-		OTPEnabled:      settings.OTPEnabled,
-		SMSProvider:     string(settings.SMSProvider),
-		RateLimitPerMin: settings.RateLimitPerMin,
-		Timezone:        settings.Timezone,
-		Metadata:        settings.Metadata,
-		ExpiresAt:       settings.ExpiresAt,
+		ID:                settings.ID,
+		TenantCode:        settings.TenantCode,
+		Name:              settings.Name,
+		Status:            string(settings.Status),
+		OTPEnabled:        settings.OTPEnabled,
+		SMSProvider:       string(settings.SMSProvider),
+		SMSTemplate:       metadataString(settings.Metadata, smsTemplateMetadataKey),
+		SMSTemplateParams: metadataStrings(settings.Metadata, smsTemplateParamsMetadataKey),
+		SMSSender:         metadataString(settings.Metadata, smsSenderMetadataKey),
+		SMSFailover:       metadataStrings(settings.Metadata, smsFailoverMetadataKey),
+		SMSProviders:      mapProviderSMSSettings(settings.Metadata),
+		RateLimitPerMin:   settings.RateLimitPerMin,
+		Timezone:          settings.Timezone,
+		Metadata:          metadataWithoutSMSProviders(settings.Metadata),
+		OTPPolicy:         policy,
+		ExpiresAt:         settings.ExpiresAt,
+	}
+}
+
+// mapProviderSMSSettings maps the sms_providers metadata entries to their non-secret settings.
+// Per-provider API keys stay behind; only their presence is recorded.
+func mapProviderSMSSettings(metadata map[string]interface{}) map[string]otp.ProviderSMSSettings {
+	entries := smsProviderEntries(metadata)
+	if len(entries) == 0 {
+		return nil
+	}
+
+	result := make(map[string]otp.ProviderSMSSettings, len(entries))
+	for provider, entry := range entries {
+		result[provider] = otp.ProviderSMSSettings{
+			Template:       metadataString(entry, smsTemplateMetadataKey),
+			TemplateParams: metadataStrings(entry, smsTemplateParamsMetadataKey),
+			Sender:         metadataString(entry, smsSenderMetadataKey),
+			HasCredentials: strings.TrimSpace(metadataString(entry, smsProviderAPIKeyField)) != "",
+		}
+	}
+	return result
+}
+
+// smsProviderEntries returns the sms_providers metadata entries keyed by lower-case provider name.
+func smsProviderEntries(metadata map[string]interface{}) map[string]map[string]interface{} {
+	values, _ := metadata[smsProvidersMetadataKey].(map[string]interface{})
+	entries := make(map[string]map[string]interface{}, len(values))
+	for provider, value := range values {
+		if entry, ok := value.(map[string]interface{}); ok {
+			entries[strings.ToLower(strings.TrimSpace(provider))] = entry
+		}
+	}
+	return entries
+}
+
+// metadataWithoutSMSProviders copies metadata without sms_providers, which holds provider
+// API keys that must not reach the Redis cache.
+func metadataWithoutSMSProviders(metadata map[string]interface{}) map[string]interface{} {
+	if _, ok := metadata[smsProvidersMetadataKey]; !ok {
+		return metadata
+	}
+
+	result := make(map[string]interface{}, len(metadata)-1)
+	for key, value := range metadata {
+		if key != smsProvidersMetadataKey {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+func metadataString(metadata map[string]interface{}, key string) string {
+	value, _ := metadata[key].(string)
+	return value
+}
+
+func metadataStrings(metadata map[string]interface{}, key string) []string {
+	values, _ := metadata[key].([]interface{})
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if s, ok := value.(string); ok {
+			result = append(result, s)
+		}
+	}
+	if len(result) == 0 {
+		return nil
 	}
+	return result
 }
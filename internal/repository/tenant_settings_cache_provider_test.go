@@ -147,6 +147,110 @@ func TestCachedTenantSettingsProviderSourceErrorOnCacheMiss(t *testing.T) {
 	assert.Equal(t, 1, source.calls)
 }
 
+func TestCachedTenantSettingsProviderCachesOTPPolicy(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	ctx := context.Background()
+	tenantID := int64(2005)
+	key := tenantSettingsCacheKey(tenantID)
+	defer client.Del(ctx, key)
+	require.NoError(t, client.Del(ctx, key).Err())
+
+	settings := repositoryTenantSettings(tenantID, "policy")
+	settings.Metadata[otp.PolicyMetadataKey] = map[string]interface{}{
+		"code_length":  8,
+		"ttl":          "60s",
+		"max_attempts": 3,
+	}
+	source := &fakeTenantSettingsSource{settings: settings}
+	provider := NewCachedTenantSettingsProvider(client, source, time.Minute)
+
+	_, err := provider.GetTenantSettings(ctx, tenantID)
+	require.NoError(t, err)
+
+	got, err := provider.GetTenantSettings(ctx, tenantID)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, source.calls)
+	require.NotNil(t, got.OTPPolicy)
+	assert.Equal(t, otp.Policy{CodeLength: 8, TTL: 60 * time.Second, MaxAttempts: 3}, *got.OTPPolicy)
+}
+
+func TestMapTenantSettingsToOTPPolicy(t *testing.T) {
+	settings := repositoryTenantSettings(2006, "policy")
+	settings.Metadata[otp.PolicyMetadataKey] = map[string]interface{}{"ttl": "5m"}
+
+	got := mapTenantSettingsToOTP(settings)
+
+	require.NotNil(t, got.OTPPolicy)
+	assert.Equal(t, 5*time.Minute, got.OTPPolicy.TTL)
+	assert.Zero(t, got.OTPPolicy.CodeLength)
+}
+
+func TestMapTenantSettingsToOTPSMSTemplate(t *testing.T) {
+	settings := repositoryTenantSettings(2009, "template")
+	settings.Metadata["sms_template"] = "verify-login"
+	settings.Metadata["sms_sender"] = "+15557122661"
+	settings.Metadata["sms_template_params"] = []interface{}{"Acme", 42, "Support"}
+	settings.Metadata["sms_failover"] = []interface{}{"ghasedak", "twilio"}
+
+	got := mapTenantSettingsToOTP(settings)
+
+	assert.Equal(t, []string{"Acme", "Support"}, got.SMSTemplateParams)
+	assert.Equal(t, []string{"ghasedak", "twilio"}, got.SMSFailover)
+	assert.Equal(t, "verify-login", got.SMSTemplate)
+	assert.Equal(t, "+15557122661", got.SMSSender)
+}
+
+func TestMapTenantSettingsToOTPSMSProviders(t *testing.T) {
+	settings := repositoryTenantSettings(2010, "providers")
+	settings.Metadata["sms_providers"] = map[string]interface{}{
+		"Ghasedak": map[string]interface{}{
+			"api_key":             "ghasedak-key",
+			"sms_template":        "otp-login",
+			"sms_template_params": []interface{}{"Acme"},
+		},
+		"twilio": map[string]interface{}{"sms_sender": "+15557122661"},
+	}
+
+	got := mapTenantSettingsToOTP(settings)
+
+	assert.Equal(t, map[string]otp.ProviderSMSSettings{
+		"ghasedak": {Template: "otp-login", TemplateParams: []string{"Acme"}, HasCredentials: true},
+		"twilio":   {Sender: "+15557122661"},
+	}, got.SMSProviders)
+	assert.NotContains(t, got.Metadata, "sms_providers")
+	assert.Contains(t, settings.Metadata, "sms_providers")
+	data, err := json.Marshal(got)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "ghasedak-key")
+}
+
+func TestMapTenantSettingsToOTPWithoutPolicy(t *testing.T) {
+	got := mapTenantSettingsToOTP(repositoryTenantSettings(2007, "plain"))
+
+	assert.Nil(t, got.OTPPolicy)
+}
+
+func TestMapTenantSettingsToOTPInvalidPolicy(t *testing.T) {
+	settings := repositoryTenantSettings(2008, "invalid")
+	settings.Metadata[otp.PolicyMetadataKey] = map[string]interface{}{"code_length": 0, "ttl": "5m"}
+
+	got := mapTenantSettingsToOTP(settings)
+
+	require.NotNil(t, got)
+	assert.Equal(t, "tenant-invalid", got.TenantCode)
+	require.NotNil(t, got.OTPPolicy)
+	assert.Equal(t, otp.Policy{TTL: 5 * time.Minute, Invalid: true}, *got.OTPPolicy)
+
+	data, err := json.Marshal(got)
+	require.NoError(t, err)
+	var cached otp.TenantSettings
+	require.NoError(t, json.Unmarshal(data, &cached))
+	assert.True(t, cached.OTPPolicy.Invalid, "the cached copy must stay restricted")
+}
+
 func repositoryTenantSettings(id int64, codeSuffix string) *TenantSettings {
 	apiKey := "secret-api-key"
 	return &TenantSettings{
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go-backend-service/internal/otp"
+)
+
+// TenantSMSCredentialsProvider reads tenant SMS API keys from the tenant settings source.
+// It deliberately bypasses the Redis tenant settings cache, which never stores API keys.
+type TenantSMSCredentialsProvider struct {
+	source tenantSettingsSource
+}
+
+// NewTenantSMSCredentialsProvider creates a credentials provider backed by tenant settings.
+func NewTenantSMSCredentialsProvider(source tenantSettingsSource) *TenantSMSCredentialsProvider {
+	return &TenantSMSCredentialsProvider{source: source}
+}
+
+// GetSMSAPIKey returns the tenant's API key for provider, or ErrSMSInvalidCredentials when
+// none is set. The sms_providers metadata entry for provider wins; the sms_api_key column
+// only serves the tenant's primary sms_provider, so it is never sent to a failover provider.
+func (p *TenantSMSCredentialsProvider) GetSMSAPIKey(ctx context.Context, tenantID int64, provider string) (string, error) {
+	settings, err := p.source.GetTenantSettingsByID(ctx, tenantID)
+	if err != nil {
+		return "", fmt.Errorf("get tenant sms credentials: %w", err)
+	}
+
+	entry := smsProviderEntries(settings.Metadata)[strings.ToLower(provider)]
+	if apiKey := metadataString(entry, smsProviderAPIKeyField); strings.TrimSpace(apiKey) != "" {
+		return apiKey, nil
+	}
+	if strings.EqualFold(provider, string(settings.SMSProvider)) &&
+		settings.SMSAPIKey != nil && strings.TrimSpace(*settings.SMSAPIKey) != "" {
+		return *settings.SMSAPIKey, nil
+	}
+	return "", fmt.Errorf("tenant %d has no %s api key: %w", tenantID, provider, otp.ErrSMSInvalidCredentials)
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-backend-service/internal/otp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantSMSCredentialsProviderReturnsAPIKey(t *testing.T) {
+	source := &fakeTenantSettingsSource{settings: repositoryTenantSettings(3001, "credentials")}
+	provider := NewTenantSMSCredentialsProvider(source)
+
+	apiKey, err := provider.GetSMSAPIKey(context.Background(), 3001, "other")
+
+	require.NoError(t, err)
+	assert.Equal(t, "secret-api-key", apiKey)
+	assert.Equal(t, 1, source.calls)
+}
+
+func TestTenantSMSCredentialsProviderMissingAPIKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		apiKey *string
+	}{
+		{name: "null", apiKey: nil},
+		{name: "blank", apiKey: stringPtr("  ")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := repositoryTenantSettings(3002, "credentials")
+			settings.SMSAPIKey = tt.apiKey
+			provider := NewTenantSMSCredentialsProvider(&fakeTenantSettingsSource{settings: settings})
+
+			apiKey, err := provider.GetSMSAPIKey(context.Background(), 3002, "other")
+
+			assert.Empty(t, apiKey)
+			assert.ErrorIs(t, err, otp.ErrSMSInvalidCredentials)
+		})
+	}
+}
+
+func TestTenantSMSCredentialsProviderPerProviderAPIKey(t *testing.T) {
+	settings := repositoryTenantSettings(3004, "credentials")
+	settings.SMSProvider = SMSProviderKavenegar
+	settings.Metadata["sms_providers"] = map[string]interface{}{
+		"Ghasedak": map[string]interface{}{"api_key": "ghasedak-key"},
+		"twilio":   map[string]interface{}{"sender": "+15557122661"},
+	}
+	provider := NewTenantSMSCredentialsProvider(&fakeTenantSettingsSource{settings: settings})
+
+	tests := []struct {
+		name     string
+		provider string
+		apiKey   string
+	}{
+		{name: "primary uses sms_api_key", provider: "kavenegar", apiKey: "secret-api-key"},
+		{name: "failover uses its own key", provider: "ghasedak", apiKey: "ghasedak-key"},
+		{name: "failover without a key", provider: "twilio"},
+		{name: "unlisted provider", provider: "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiKey, err := provider.GetSMSAPIKey(context.Background(), 3004, tt.provider)
+
+			if tt.apiKey == "" {
+				assert.Empty(t, apiKey)
+				assert.ErrorIs(t, err, otp.ErrSMSInvalidCredentials)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.apiKey, apiKey)
+		})
+	}
+}
+
+func TestTenantSMSCredentialsProviderSourceError(t *testing.T) {
+	sourceErr := errors.New("source failed")
+	provider := NewTenantSMSCredentialsProvider(&fakeTenantSettingsSource{err: sourceErr})
+
+	apiKey, err := provider.GetSMSAPIKey(context.Background(), 3003, "other")
+
+	assert.Empty(t, apiKey)
+	assert.ErrorIs(t, err, sourceErr)
+}
+
+func stringPtr(value string) *string {
+	return &value
+}
@@ -20,9 +20,15 @@ type Server struct {
 
 // New creates a new server instance
 func New(cfg *config.Config, handler http.Handler) *Server {
+	return NewWithAddr(cfg, fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port), handler)
+}
+
+// NewWithAddr creates a server instance listening on addr, such as the admin listener,
+// with the configured timeouts
+func NewWithAddr(cfg *config.Config, addr string, handler http.Handler) *Server {
 	return &Server{
 		httpServer: &http.Server{
-			Addr:         fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
+			Addr:         addr,
 			Handler:      handler,
 			ReadTimeout:  cfg.Server.ReadTimeout,
 			WriteTimeout: cfg.Server.WriteTimeout,
@@ -0,0 +1,287 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go-backend-service/internal/logger"
+	"go-backend-service/internal/metrics"
+	"go-backend-service/internal/otp"
+)
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitHalfOpen
+	CircuitOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitHalfOpen:
+		return "half_open"
+	case CircuitOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerConfig holds the thresholds for one provider's circuit.
+type CircuitBreakerConfig struct {
+	// WindowSize is the number of most recent calls the rates are computed over.
+	WindowSize int
+	// MinCalls is the number of calls the window needs before the circuit may open.
+	MinCalls int
+	// FailureRateThreshold opens the circuit when this fraction of calls failed.
+	FailureRateThreshold float64
+	// SlowCallDuration marks a call as slow, whatever its outcome.
+	SlowCallDuration time.Duration
+	// SlowCallRateThreshold opens the circuit when this fraction of calls was slow.
+	SlowCallRateThreshold float64
+	// OpenDuration is how long the circuit fails fast before allowing trial calls.
+	OpenDuration time.Duration
+	// HalfOpenMaxCalls is the number of trial calls that must succeed to close the circuit.
+	HalfOpenMaxCalls int
+}
+
+// DefaultCircuitBreakerConfig returns conservative thresholds for a single SMS provider.
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		WindowSize:            20,
+		MinCalls:              10,
+		FailureRateThreshold:  0.5,
+		SlowCallDuration:      time.Second,
+		SlowCallRateThreshold: 0.8,
+		OpenDuration:          30 * time.Second,
+		HalfOpenMaxCalls:      3,
+	}
+}
+
+type callOutcome struct {
+	failed bool
+	slow   bool
+}
+
+// CircuitBreaker decorates an otp.SMSProvider and fails fast with otp.ErrSMSCircuitOpen
+// while the provider is unhealthy. Only provider-health failures count: unavailability,
+// timeouts and unclassified errors. Credential, credit and recipient errors are
+// tenant- or request-specific and never open the circuit.
+type CircuitBreaker struct {
+	name   string
+	next   otp.SMSProvider
+	config CircuitBreakerConfig
+	now    func() time.Time
+
+	mu                sync.Mutex
+	state             CircuitState
+	window            []callOutcome
+	cursor            int
+	filled            int
+	openedAt          time.Time
+	halfOpenInFlight  int
+	halfOpenSucceeded int
+	// generation changes on every state transition; a call only reports back to the
+	// state it was admitted under.
+	generation uint64
+}
+
+// NewCircuitBreaker wraps next with a circuit named after the provider.
+func NewCircuitBreaker(name string, next otp.SMSProvider, config CircuitBreakerConfig) *CircuitBreaker {
+	defaults := DefaultCircuitBreakerConfig()
+	if config.WindowSize <= 0 {
+		config.WindowSize = defaults.WindowSize
+	}
+	if config.MinCalls <= 0 || config.MinCalls > config.WindowSize {
+		config.MinCalls = config.WindowSize
+	}
+	if config.FailureRateThreshold <= 0 {
+		config.FailureRateThreshold = defaults.FailureRateThreshold
+	}
+	if config.SlowCallDuration <= 0 {
+		config.SlowCallDuration = defaults.SlowCallDuration
+	}
+	if config.SlowCallRateThreshold <= 0 {
+		config.SlowCallRateThreshold = defaults.SlowCallRateThreshold
+	}
+	if config.OpenDuration <= 0 {
+		config.OpenDuration = defaults.OpenDuration
+	}
+	if config.HalfOpenMaxCalls <= 0 {
+		config.HalfOpenMaxCalls = defaults.HalfOpenMaxCalls
+	}
+
+	breaker := &CircuitBreaker{
+		name:   name,
+		next:   next,
+		config: config,
+		now:    time.Now,
+		window: make([]callOutcome, config.WindowSize),
+	}
+	metrics.SMSProviderCircuitState.WithLabelValues(name).Set(float64(CircuitClosed))
+	return breaker
+}
+
+// State returns the current circuit state.
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refreshLocked()
+	return b.state
+}
+
+// SendOTP forwards to the wrapped provider unless the circuit is open.
+func (b *CircuitBreaker) SendOTP(ctx context.Context, req otp.SMSRequest) (*otp.SMSResult, error) {
+	generation, ok := b.acquire()
+	if !ok {
+		return nil, &otp.SMSProviderError{
+			Provider: b.name,
+			Kind:     otp.ErrSMSCircuitOpen,
+		}
+	}
+
+	started := b.now()
+	result, err := b.next.SendOTP(ctx, req)
+	b.record(generation, err, b.now().Sub(started))
+	return result, err
+}
+
+// acquire admits a call and returns the generation it was admitted under. In half-open
+// state the call takes one of the HalfOpenMaxCalls trial slots.
+func (b *CircuitBreaker) acquire() (uint64, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refreshLocked()
+	switch b.state {
+	case CircuitOpen:
+		return 0, false
+	case CircuitHalfOpen:
+		if b.halfOpenInFlight+b.halfOpenSucceeded >= b.config.HalfOpenMaxCalls {
+			return 0, false
+		}
+		b.halfOpenInFlight++
+	}
+	return b.generation, true
+}
+
+// record applies a call's outcome to the state it was admitted under. Calls that finish
+// after the circuit has moved on are ignored: a call admitted while closed is never a
+// half-open trial, and a trial from an earlier half-open period holds no current slot.
+func (b *CircuitBreaker) record(generation uint64, err error, elapsed time.Duration) {
+	if errors.Is(err, context.Canceled) {
+		b.mu.Lock()
+		if b.generation == generation && b.state == CircuitHalfOpen {
+			b.halfOpenInFlight--
+		}
+		b.mu.Unlock()
+		return
+	}
+
+	outcome := callOutcome{
+		failed: countsAsProviderFailure(err),
+		slow:   elapsed >= b.config.SlowCallDuration,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.generation != generation {
+		return
+	}
+	switch b.state {
+	case CircuitHalfOpen:
+		b.halfOpenInFlight--
+		if outcome.failed || outcome.slow {
+			b.transitionLocked(CircuitOpen)
+			return
+		}
+		b.halfOpenSucceeded++
+		if b.halfOpenSucceeded >= b.config.HalfOpenMaxCalls {
+			b.transitionLocked(CircuitClosed)
+		}
+	case CircuitClosed:
+		b.window[b.cursor] = outcome
+		b.cursor = (b.cursor + 1) % len(b.window)
+		if b.filled < len(b.window) {
+			b.filled++
+		}
+		if b.shouldOpenLocked() {
+			b.transitionLocked(CircuitOpen)
+		}
+	}
+}
+
+func (b *CircuitBreaker) shouldOpenLocked() bool {
+	if b.filled < b.config.MinCalls {
+		return false
+	}
+
+	var failed, slow int
+	for i := 0; i < b.filled; i++ {
+		if b.window[i].failed {
+			failed++
+		}
+		if b.window[i].slow {
+			slow++
+		}
+	}
+	total := float64(b.filled)
+	return float64(failed)/total >= b.config.FailureRateThreshold ||
+		float64(slow)/total >= b.config.SlowCallRateThreshold
+}
+
+// refreshLocked moves an open circuit to half-open once OpenDuration has elapsed.
+func (b *CircuitBreaker) refreshLocked() {
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.config.OpenDuration {
+		b.transitionLocked(CircuitHalfOpen)
+	}
+}
+
+func (b *CircuitBreaker) transitionLocked(to CircuitState) {
+	from := b.state
+	if from == to {
+		return
+	}
+
+	b.state = to
+	b.generation++
+	b.halfOpenInFlight = 0
+	b.halfOpenSucceeded = 0
+	switch to {
+	case CircuitOpen:
+		b.openedAt = b.now()
+	case CircuitClosed:
+		b.window = make([]callOutcome, len(b.window))
+		b.cursor = 0
+		b.filled = 0
+	}
+
+	metrics.SMSProviderCircuitState.WithLabelValues(b.name).Set(float64(to))
+	metrics.SMSProviderCircuitTransitions.WithLabelValues(b.name, from.String(), to.String()).Inc()
+	log := logger.Get()
+	log.Warn().
+		Str("provider", b.name).
+		Str("from", from.String()).
+		Str("to", to.String()).
+		Msg("SMS provider circuit state changed")
+}
+
+// countsAsProviderFailure reports whether err says something about the provider's health.
+func countsAsProviderFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, otp.ErrSMSProviderUnavailable) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var providerErr *otp.SMSProviderError
+	return !errors.As(err, &providerErr)
+}
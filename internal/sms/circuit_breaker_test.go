@@ -0,0 +1,276 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go-backend-service/internal/metrics"
+	"go-backend-service/internal/otp"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// scriptedProvider returns err for every call and advances the clock by latency.
+type scriptedProvider struct {
+	clock   *fakeClock
+	err     error
+	latency time.Duration
+	calls   int
+}
+
+func (p *scriptedProvider) SendOTP(ctx context.Context, req otp.SMSRequest) (*otp.SMSResult, error) {
+	p.calls++
+	p.clock.Advance(p.latency)
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &otp.SMSResult{Provider: req.Provider, Status: otp.RequestStatusSent}, nil
+}
+
+func newTestCircuitBreaker(name string, next otp.SMSProvider, clock *fakeClock) *CircuitBreaker {
+	breaker := NewCircuitBreaker(name, next, CircuitBreakerConfig{
+		WindowSize:            4,
+		MinCalls:              4,
+		FailureRateThreshold:  0.5,
+		SlowCallDuration:      time.Second,
+		SlowCallRateThreshold: 0.75,
+		OpenDuration:          30 * time.Second,
+		HalfOpenMaxCalls:      2,
+	})
+	breaker.now = clock.Now
+	return breaker
+}
+
+func sendTimes(t *testing.T, provider otp.SMSProvider, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		_, _ = provider.SendOTP(context.Background(), otp.SMSRequest{Provider: "test"})
+	}
+}
+
+func TestCircuitBreakerOpensOnFailureRate(t *testing.T) {
+	clock := newFakeClock()
+	unavailable := &otp.SMSProviderError{Provider: "cb-failure", Kind: otp.ErrSMSProviderUnavailable}
+	next := &scriptedProvider{clock: clock}
+	breaker := newTestCircuitBreaker("cb-failure", next, clock)
+
+	sendTimes(t, breaker, 2)
+	next.err = unavailable
+	sendTimes(t, breaker, 1)
+	assert.Equal(t, CircuitClosed, breaker.State())
+
+	sendTimes(t, breaker, 1)
+	assert.Equal(t, CircuitOpen, breaker.State())
+	assert.Equal(t, float64(CircuitOpen), testutil.ToFloat64(metrics.SMSProviderCircuitState.WithLabelValues("cb-failure")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.SMSProviderCircuitTransitions.WithLabelValues("cb-failure", "closed", "open")))
+
+	result, err := breaker.SendOTP(context.Background(), otp.SMSRequest{Provider: "cb-failure"})
+
+	require.Nil(t, result)
+	assert.ErrorIs(t, err, otp.ErrSMSCircuitOpen)
+	var providerErr *otp.SMSProviderError
+	require.ErrorAs(t, err, &providerErr)
+	assert.Equal(t, "cb-failure", providerErr.Provider)
+	assert.Equal(t, 4, next.calls)
+}
+
+func TestCircuitBreakerOpensOnSlowCallRate(t *testing.T) {
+	clock := newFakeClock()
+	next := &scriptedProvider{clock: clock, latency: 1500 * time.Millisecond}
+	breaker := newTestCircuitBreaker("cb-slow", next, clock)
+
+	sendTimes(t, breaker, 3)
+	assert.Equal(t, CircuitClosed, breaker.State())
+
+	next.latency = 0
+	sendTimes(t, breaker, 1)
+
+	assert.Equal(t, CircuitOpen, breaker.State())
+}
+
+func TestCircuitBreakerIgnoresTenantAndRecipientErrors(t *testing.T) {
+	clock := newFakeClock()
+	next := &scriptedProvider{clock: clock}
+	breaker := newTestCircuitBreaker("cb-ignored", next, clock)
+
+	for _, kind := range []error{
+		otp.ErrSMSInvalidCredentials,
+		otp.ErrSMSInsufficientCredit,
+		otp.ErrSMSInvalidRecipient,
+		otp.ErrSMSRejected,
+	} {
+		next.err = &otp.SMSProviderError{Provider: "cb-ignored", Kind: kind}
+		sendTimes(t, breaker, 2)
+	}
+	next.err = context.Canceled
+	sendTimes(t, breaker, 4)
+
+	assert.Equal(t, CircuitClosed, breaker.State())
+}
+
+func TestCircuitBreakerCountsTimeoutsAndUnclassifiedErrors(t *testing.T) {
+	clock := newFakeClock()
+	next := &scriptedProvider{clock: clock, err: context.DeadlineExceeded}
+	breaker := newTestCircuitBreaker("cb-timeout", next, clock)
+
+	sendTimes(t, breaker, 2)
+	next.err = errors.New("connection reset by peer")
+	sendTimes(t, breaker, 2)
+
+	assert.Equal(t, CircuitOpen, breaker.State())
+}
+
+func TestCircuitBreakerHalfOpenClosesAfterTrialSuccesses(t *testing.T) {
+	clock := newFakeClock()
+	next := &scriptedProvider{clock: clock, err: errors.New("down")}
+	breaker := newTestCircuitBreaker("cb-recover", next, clock)
+	sendTimes(t, breaker, 4)
+	require.Equal(t, CircuitOpen, breaker.State())
+
+	clock.Advance(29 * time.Second)
+	assert.Equal(t, CircuitOpen, breaker.State())
+	clock.Advance(time.Second)
+	assert.Equal(t, CircuitHalfOpen, breaker.State())
+	assert.Equal(t, float64(CircuitHalfOpen), testutil.ToFloat64(metrics.SMSProviderCircuitState.WithLabelValues("cb-recover")))
+
+	next.err = nil
+	sendTimes(t, breaker, 1)
+	assert.Equal(t, CircuitHalfOpen, breaker.State())
+	sendTimes(t, breaker, 1)
+
+	assert.Equal(t, CircuitClosed, breaker.State())
+	assert.Equal(t, 6, next.calls)
+
+	// The window starts empty after closing, so one failure does not reopen the circuit.
+	next.err = errors.New("down")
+	sendTimes(t, breaker, 1)
+	assert.Equal(t, CircuitClosed, breaker.State())
+}
+
+func TestCircuitBreakerHalfOpenReopensOnFailure(t *testing.T) {
+	clock := newFakeClock()
+	next := &scriptedProvider{clock: clock, err: errors.New("down")}
+	breaker := newTestCircuitBreaker("cb-reopen", next, clock)
+	sendTimes(t, breaker, 4)
+	clock.Advance(30 * time.Second)
+	require.Equal(t, CircuitHalfOpen, breaker.State())
+
+	sendTimes(t, breaker, 1)
+
+	assert.Equal(t, CircuitOpen, breaker.State())
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.SMSProviderCircuitTransitions.WithLabelValues("cb-reopen", "half_open", "open")))
+}
+
+func TestCircuitBreakerHalfOpenLimitsTrialCalls(t *testing.T) {
+	clock := newFakeClock()
+	release := make(chan struct{})
+	started := make(chan struct{}, 4)
+	blocking := otpProviderFunc(func(ctx context.Context, req otp.SMSRequest) (*otp.SMSResult, error) {
+		started <- struct{}{}
+		<-release
+		return &otp.SMSResult{Status: otp.RequestStatusSent}, nil
+	})
+	breaker := newTestCircuitBreaker("cb-trials", blocking, clock)
+	breaker.mu.Lock()
+	breaker.transitionLocked(CircuitOpen)
+	breaker.mu.Unlock()
+	clock.Advance(30 * time.Second)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = breaker.SendOTP(context.Background(), otp.SMSRequest{})
+		}()
+	}
+	<-started
+	<-started
+
+	_, err := breaker.SendOTP(context.Background(), otp.SMSRequest{})
+	assert.ErrorIs(t, err, otp.ErrSMSCircuitOpen)
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, CircuitClosed, breaker.State())
+}
+
+func TestCircuitBreakerIgnoresCallsAdmittedBeforeHalfOpen(t *testing.T) {
+	clock := newFakeClock()
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	var mu sync.Mutex
+	block := true
+	next := otpProviderFunc(func(ctx context.Context, req otp.SMSRequest) (*otp.SMSResult, error) {
+		mu.Lock()
+		shouldBlock := block
+		mu.Unlock()
+		if shouldBlock {
+			started <- struct{}{}
+			<-release
+		}
+		return &otp.SMSResult{Status: otp.RequestStatusSent}, nil
+	})
+	breaker := newTestCircuitBreaker("cb-stale", next, clock)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = breaker.SendOTP(context.Background(), otp.SMSRequest{})
+	}()
+	<-started
+	mu.Lock()
+	block = false
+	mu.Unlock()
+
+	breaker.mu.Lock()
+	breaker.transitionLocked(CircuitOpen)
+	breaker.mu.Unlock()
+	clock.Advance(30 * time.Second)
+	require.Equal(t, CircuitHalfOpen, breaker.State())
+
+	// The call admitted while closed finishes now; it is neither a trial success nor a free slot.
+	close(release)
+	<-done
+
+	breaker.mu.Lock()
+	assert.Equal(t, 0, breaker.halfOpenInFlight)
+	assert.Equal(t, 0, breaker.halfOpenSucceeded)
+	breaker.mu.Unlock()
+	sendTimes(t, breaker, 1)
+	assert.Equal(t, CircuitHalfOpen, breaker.State())
+	sendTimes(t, breaker, 1)
+	assert.Equal(t, CircuitClosed, breaker.State())
+}
+
+type otpProviderFunc func(ctx context.Context, req otp.SMSRequest) (*otp.SMSResult, error)
+
+func (f otpProviderFunc) SendOTP(ctx context.Context, req otp.SMSRequest) (*otp.SMSResult, error) {
+	return f(ctx, req)
+}
@@ -0,0 +1,10 @@
+package sms
+
+import "context"
+
+// CredentialsProvider resolves the SMS API key a tenant configured for one provider.
+// Keys are looked up per send so they never travel through the tenant settings cache,
+// and each adapter asks for its own provider's key only.
+type CredentialsProvider interface {
+	GetSMSAPIKey(ctx context.Context, tenantID int64, provider string) (string, error)
+}
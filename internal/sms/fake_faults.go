@@ -0,0 +1,157 @@
+package sms
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"go-backend-service/internal/otp"
+)
+
+// FakeOutcome is a scripted result for FakeProvider sends.
+type FakeOutcome string
+
+const (
+	FakeOutcomeSuccess            FakeOutcome = "success"
+	FakeOutcomeHang               FakeOutcome = "hang"
+	FakeOutcomeUnavailable        FakeOutcome = "unavailable"
+	FakeOutcomeRejected           FakeOutcome = "rejected"
+	FakeOutcomeInvalidCredentials FakeOutcome = "invalid_credentials"
+	FakeOutcomeInsufficientCredit FakeOutcome = "insufficient_credit"
+	FakeOutcomeInvalidRecipient   FakeOutcome = "invalid_recipient"
+	FakeOutcomeLandline           FakeOutcome = "landline"
+	FakeOutcomeUnreachable        FakeOutcome = "unreachable"
+)
+
+// Latency distributions supported by FakeProvider.
+const (
+	FakeLatencyUniform = "uniform"
+	FakeLatencyPareto  = "pareto"
+)
+
+// fakeOutcomeErrors maps failing outcomes to the error an adapter would report.
+var fakeOutcomeErrors = map[FakeOutcome]struct {
+	kind       error
+	statusCode int
+	retryable  bool
+}{
+	FakeOutcomeUnavailable:        {kind: otp.ErrSMSProviderUnavailable, statusCode: 503, retryable: true},
+	FakeOutcomeRejected:           {kind: otp.ErrSMSRejected, statusCode: 400},
+	FakeOutcomeInvalidCredentials: {kind: otp.ErrSMSInvalidCredentials, statusCode: 401},
+	FakeOutcomeInsufficientCredit: {kind: otp.ErrSMSInsufficientCredit, statusCode: 402},
+	FakeOutcomeInvalidRecipient:   {kind: otp.ErrSMSInvalidRecipient, statusCode: 400},
+	FakeOutcomeLandline:           {kind: otp.ErrSMSRecipientLandline, statusCode: 400},
+	FakeOutcomeUnreachable:        {kind: otp.ErrSMSRecipientUnreachable, statusCode: 400},
+}
+
+// FakeFaults configures failure injection for FakeProvider so load tests can
+// exercise the failure paths of SendOTP. The zero value injects nothing.
+type FakeFaults struct {
+	// ErrorRate is the fraction of sends failing with a retryable ErrSMSProviderUnavailable.
+	ErrorRate float64
+	// HangRate is the fraction of sends blocking until the caller's context ends.
+	HangRate float64
+	// LatencyDistribution is FakeLatencyUniform (minDelay..maxDelay) or FakeLatencyPareto,
+	// a heavy tail with scale minDelay and shape ParetoAlpha, capped at LatencyCap.
+	LatencyDistribution string
+	ParetoAlpha         float64
+	LatencyCap          time.Duration
+	// PhoneOutcomes scripts the outcome for phones ending in a suffix; the longest suffix wins
+	// and takes precedence over the random rates.
+	PhoneOutcomes map[string]FakeOutcome
+}
+
+// ParseFakeOutcome validates a scripted outcome name.
+func ParseFakeOutcome(name string) (FakeOutcome, error) {
+	outcome := FakeOutcome(strings.ToLower(strings.TrimSpace(name)))
+	if outcome == FakeOutcomeSuccess || outcome == FakeOutcomeHang {
+		return outcome, nil
+	}
+	if _, ok := fakeOutcomeErrors[outcome]; ok {
+		return outcome, nil
+	}
+	return "", fmt.Errorf("unknown fake sms outcome %q", name)
+}
+
+// SetFaults enables failure injection on the provider.
+func (p *FakeProvider) SetFaults(faults FakeFaults) error {
+	if faults.ErrorRate < 0 || faults.HangRate < 0 || faults.ErrorRate+faults.HangRate > 1 {
+		return fmt.Errorf("fake sms error and hang rates must be >= 0 and sum to at most 1")
+	}
+	switch faults.LatencyDistribution {
+	case "", FakeLatencyUniform:
+	case FakeLatencyPareto:
+		if faults.ParetoAlpha <= 0 {
+			return fmt.Errorf("fake sms pareto alpha must be > 0")
+		}
+		if p.minDelay <= 0 {
+			return fmt.Errorf("fake sms pareto latency needs a positive min delay")
+		}
+		if faults.LatencyCap < p.minDelay {
+			return fmt.Errorf("fake sms latency cap must be >= min delay")
+		}
+	default:
+		return fmt.Errorf("unknown fake sms latency distribution %q", faults.LatencyDistribution)
+	}
+	for suffix, outcome := range faults.PhoneOutcomes {
+		if suffix == "" {
+			return fmt.Errorf("fake sms phone outcome suffix must not be empty")
+		}
+		if _, err := ParseFakeOutcome(string(outcome)); err != nil {
+			return err
+		}
+	}
+
+	p.faults = faults
+	return nil
+}
+
+// outcome picks the result for a send: a scripted phone outcome, else a random fault.
+func (p *FakeProvider) outcome(phone string) FakeOutcome {
+	longest := -1
+	var scripted FakeOutcome
+	for suffix, outcome := range p.faults.PhoneOutcomes {
+		if strings.HasSuffix(phone, suffix) && len(suffix) > longest {
+			longest = len(suffix)
+			scripted = outcome
+		}
+	}
+	if longest >= 0 {
+		return scripted
+	}
+
+	if p.faults.ErrorRate == 0 && p.faults.HangRate == 0 {
+		return FakeOutcomeSuccess
+	}
+	roll := p.random()
+	switch {
+	case roll < p.faults.ErrorRate:
+		return FakeOutcomeUnavailable
+	case roll < p.faults.ErrorRate+p.faults.HangRate:
+		return FakeOutcomeHang
+	default:
+		return FakeOutcomeSuccess
+	}
+}
+
+func (p *FakeProvider) paretoDelay() time.Duration {
+	// Inverse CDF: scale / U^(1/alpha), with U in (0, 1].
+	u := 1 - p.random()
+	delay := float64(p.minDelay) / math.Pow(u, 1/p.faults.ParetoAlpha)
+	if delay >= float64(p.faults.LatencyCap) {
+		return p.faults.LatencyCap
+	}
+	return time.Duration(delay)
+}
+
+func fakeOutcomeError(provider string, outcome FakeOutcome) error {
+	spec := fakeOutcomeErrors[outcome]
+	return &otp.SMSProviderError{
+		Provider:   provider,
+		Kind:       spec.kind,
+		StatusCode: spec.statusCode,
+		Retryable:  spec.retryable,
+		Message:    "simulated " + string(outcome),
+	}
+}
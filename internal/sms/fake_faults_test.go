@@ -0,0 +1,137 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-backend-service/internal/otp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeProviderScriptedPhoneOutcomes(t *testing.T) {
+	provider := newFakeProviderWithDelay(0, 0)
+	require.NoError(t, provider.SetFaults(FakeFaults{
+		ErrorRate: 1,
+		PhoneOutcomes: map[string]FakeOutcome{
+			"0001":  FakeOutcomeUnavailable,
+			"0002":  FakeOutcomeInvalidRecipient,
+			"10002": FakeOutcomeSuccess,
+			"0003":  FakeOutcomeLandline,
+		},
+	}))
+
+	tests := []struct {
+		phone     string
+		kind      error
+		retryable bool
+	}{
+		{phone: "+989120000001", kind: otp.ErrSMSProviderUnavailable, retryable: true},
+		{phone: "+989120000002", kind: otp.ErrSMSInvalidRecipient},
+		{phone: "+989120000003", kind: otp.ErrSMSRecipientLandline},
+		{phone: "+989120010002"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.phone, func(t *testing.T) {
+			result, err := provider.SendOTP(context.Background(), otp.SMSRequest{Provider: "other", Phone: tt.phone, Code: "123456"})
+
+			if tt.kind == nil {
+				require.NoError(t, err)
+				assert.Equal(t, otp.RequestStatusSent, result.Status)
+				return
+			}
+			require.Nil(t, result)
+			require.ErrorIs(t, err, tt.kind)
+			var providerErr *otp.SMSProviderError
+			require.ErrorAs(t, err, &providerErr)
+			assert.Equal(t, "other", providerErr.Provider)
+			assert.Equal(t, tt.retryable, providerErr.Retryable)
+			assert.NotContains(t, err.Error(), "123456")
+		})
+	}
+}
+
+func TestFakeProviderErrorRate(t *testing.T) {
+	provider := newFakeProviderWithDelay(0, 0)
+	require.NoError(t, provider.SetFaults(FakeFaults{ErrorRate: 0.3, HangRate: 0.2}))
+
+	rolls := map[float64]FakeOutcome{
+		0.1:  FakeOutcomeUnavailable,
+		0.29: FakeOutcomeUnavailable,
+		0.3:  FakeOutcomeHang,
+		0.49: FakeOutcomeHang,
+		0.5:  FakeOutcomeSuccess,
+		0.99: FakeOutcomeSuccess,
+	}
+	for roll, want := range rolls {
+		provider.random = func() float64 { return roll }
+		assert.Equal(t, want, provider.outcome("+989121234567"), "roll %v", roll)
+	}
+}
+
+func TestFakeProviderHangBlocksUntilContextEnds(t *testing.T) {
+	provider := newFakeProviderWithDelay(0, 0)
+	require.NoError(t, provider.SetFaults(FakeFaults{HangRate: 1}))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	result, err := provider.SendOTP(ctx, otp.SMSRequest{Phone: "+989121234567"})
+
+	require.Nil(t, result)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.GreaterOrEqual(t, time.Since(started), 20*time.Millisecond)
+}
+
+func TestFakeProviderParetoLatency(t *testing.T) {
+	provider := newFakeProviderWithDelay(10*time.Millisecond, 10*time.Millisecond)
+	require.NoError(t, provider.SetFaults(FakeFaults{
+		LatencyDistribution: FakeLatencyPareto,
+		ParetoAlpha:         1,
+		LatencyCap:          time.Second,
+	}))
+
+	provider.random = func() float64 { return 0 }
+	assert.Equal(t, 10*time.Millisecond, provider.delay())
+	provider.random = func() float64 { return 0.9 }
+	assert.Equal(t, 100*time.Millisecond, provider.delay().Round(time.Millisecond))
+	provider.random = func() float64 { return 0.999999 }
+	assert.Equal(t, time.Second, provider.delay())
+}
+
+func TestFakeProviderSetFaultsValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		faults FakeFaults
+	}{
+		{name: "negative error rate", faults: FakeFaults{ErrorRate: -0.1}},
+		{name: "rates above one", faults: FakeFaults{ErrorRate: 0.6, HangRate: 0.5}},
+		{name: "unknown distribution", faults: FakeFaults{LatencyDistribution: "gaussian"}},
+		{name: "pareto without alpha", faults: FakeFaults{LatencyDistribution: FakeLatencyPareto, LatencyCap: time.Second}},
+		{name: "pareto cap below min delay", faults: FakeFaults{LatencyDistribution: FakeLatencyPareto, ParetoAlpha: 1, LatencyCap: time.Millisecond}},
+		{name: "unknown outcome", faults: FakeFaults{PhoneOutcomes: map[string]FakeOutcome{"0001": "explode"}}},
+		{name: "empty suffix", faults: FakeFaults{PhoneOutcomes: map[string]FakeOutcome{"": FakeOutcomeHang}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newFakeProviderWithDelay(10*time.Millisecond, 20*time.Millisecond)
+
+			require.Error(t, provider.SetFaults(tt.faults))
+			assert.Equal(t, FakeFaults{}, provider.faults)
+		})
+	}
+}
+
+func TestParseFakeOutcome(t *testing.T) {
+	outcome, err := ParseFakeOutcome(" Invalid_Recipient ")
+	require.NoError(t, err)
+	assert.Equal(t, FakeOutcomeInvalidRecipient, outcome)
+
+	_, err = ParseFakeOutcome("explode")
+	require.Error(t, err)
+}
@@ -24,12 +24,15 @@ type FakeProvider struct {
 	maxDelay        time.Duration
 	debugCodeClient *redis.Client
 	debugCodeTTL    time.Duration
+	phoneKeyHasher  *otp.PhoneKeyHasher
+	faults          FakeFaults
+	random          func() float64
 }
 
 type debugCodeValue struct {
 	RequestID string    `json:"request_id"`
 	TenantID  int64     `json:"tenant_id"`
-	Phone     string    `json:"phone"`
+	Phone     string    `json:"phone,omitempty"`
 	Code      string    `json:"code"`
 	Provider  string    `json:"provider"`
 	CreatedAt time.Time `json:"created_at"`
@@ -69,15 +72,28 @@ func (p *FakeProvider) enableDebugCodeCapture(client *redis.Client, ttl time.Dur
 	}
 }
 
+// SetPhoneKeyHasher makes debug code keys carry a keyed hash of the phone instead of the
+// phone, and drops the phone from the stored value.
+func (p *FakeProvider) SetPhoneKeyHasher(hasher *otp.PhoneKeyHasher) {
+	p.phoneKeyHasher = hasher
+}
+
 func newFakeProviderWithDelay(minDelay, maxDelay time.Duration) *FakeProvider {
 	return &FakeProvider{
 		minDelay: minDelay,
 		maxDelay: maxDelay,
+		random:   rand.Float64,
 	}
 }
 
 // SendOTP simulates sending an OTP through an SMS provider.
 func (p *FakeProvider) SendOTP(ctx context.Context, req otp.SMSRequest) (*otp.SMSResult, error) {
+	outcome := p.outcome(req.Phone)
+	if outcome == FakeOutcomeHang {
+		<-ctx.Done()
+		return nil, fmt.Errorf("fake sms provider canceled: %w", ctx.Err())
+	}
+
 	delay := p.delay()
 	timer := time.NewTimer(delay)
 	defer timer.Stop()
@@ -92,6 +108,9 @@ func (p *FakeProvider) SendOTP(ctx context.Context, req otp.SMSRequest) (*otp.SM
 	if provider == "" {
 		provider = fakeProviderName
 	}
+	if outcome != FakeOutcomeSuccess {
+		return nil, fakeOutcomeError(provider, outcome)
+	}
 
 	messageID := req.RequestID
 	if messageID == "" {
@@ -124,24 +143,29 @@ func (p *FakeProvider) captureDebugCode(ctx context.Context, req otp.SMSRequest,
 	value := debugCodeValue{
 		RequestID: req.RequestID,
 		TenantID:  req.TenantID,
-		Phone:     req.Phone,
 		Code:      req.Code,
 		Provider:  provider,
 		CreatedAt: createdAt,
 	}
+	if p.phoneKeyHasher == nil {
+		value.Phone = req.Phone
+	}
 	data, err := json.Marshal(value)
 	if err != nil {
 		return
 	}
 
-	_ = p.debugCodeClient.Set(ctx, debugCodeKey(req.TenantID, req.Phone), data, p.debugCodeTTL).Err()
+	_ = p.debugCodeClient.Set(ctx, debugCodeKey(req.TenantID, p.phoneKeyHasher.TenantKey(req.TenantID, req.Phone)), data, p.debugCodeTTL).Err()
 }
 
-func debugCodeKey(tenantID int64, phone string) string {
-	return fmt.Sprintf("%s:%d:%s", debugCodeKeyNamespace, tenantID, phone)
+func debugCodeKey(tenantID int64, phoneKey string) string {
+	return fmt.Sprintf("%s:%d:%s", debugCodeKeyNamespace, tenantID, phoneKey)
 }
 
 func (p *FakeProvider) delay() time.Duration {
+	if p.faults.LatencyDistribution == FakeLatencyPareto {
+		return p.paretoDelay()
+	}
 	if p.maxDelay <= p.minDelay {
 		return p.minDelay
 	}
@@ -219,3 +219,43 @@ func TestFakeProviderDebugCodeCaptureCanceledContextDoesNotWrite(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, int64(0), exists)
 }
+
+func TestFakeProviderDebugCodeCaptureHashesPhone(t *testing.T) {
+	client := setupTestRedis(t)
+	defer client.Close()
+
+	hasher, err := otp.NewPhoneKeyHasher([]byte("test-phone-key-secret"))
+	require.NoError(t, err)
+	provider := NewFakeProviderWithDebugCodeCapture(client, time.Minute)
+	provider.SetPhoneKeyHasher(hasher)
+	provider.minDelay = 0
+	provider.maxDelay = 0
+
+	req := otp.SMSRequest{
+		RequestID: "request-debug-hashed",
+		TenantID:  458,
+		Phone:     "+989121234570",
+		Code:      "778899",
+		Provider:  "fake",
+	}
+	key := debugCodeKey(req.TenantID, hasher.TenantKey(req.TenantID, req.Phone))
+	ctx := context.Background()
+	defer client.Del(ctx, key)
+	require.NoError(t, client.Del(ctx, key).Err())
+
+	_, err = provider.SendOTP(ctx, req)
+	require.NoError(t, err)
+
+	data, err := client.Get(ctx, key).Bytes()
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), req.Phone)
+
+	var stored debugCodeValue
+	require.NoError(t, json.Unmarshal(data, &stored))
+	assert.Equal(t, req.RequestID, stored.RequestID)
+	assert.Equal(t, req.Code, stored.Code)
+
+	exists, err := client.Exists(ctx, debugCodeKey(req.TenantID, req.Phone)).Result()
+	require.NoError(t, err)
+	assert.Zero(t, exists)
+}
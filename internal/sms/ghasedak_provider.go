@@ -0,0 +1,163 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-backend-service/internal/otp"
+)
+
+const (
+	ghasedakProviderName    = "ghasedak"
+	defaultGhasedakBaseURL  = "https://api.ghasedak.me"
+	ghasedakMaxTemplateArgs = 10
+	ghasedakVerifyTypeSMS   = "1"
+)
+
+// GhasedakProvider sends OTP codes through the Ghasedak verification template API.
+type GhasedakProvider struct {
+	baseURL         string
+	httpClient      *http.Client
+	credentials     CredentialsProvider
+	defaultTemplate string
+}
+
+type ghasedakResponse struct {
+	Result struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"result"`
+	Items []json.Number `json:"items"`
+}
+
+// NewGhasedakProvider creates a Ghasedak adapter. An empty baseURL uses the public API and
+// a nil httpClient uses a client with a conservative timeout. defaultTemplate is used when
+// the tenant has no sms_template configured.
+func NewGhasedakProvider(baseURL string, httpClient *http.Client, credentials CredentialsProvider, defaultTemplate string) *GhasedakProvider {
+	if baseURL == "" {
+		baseURL = defaultGhasedakBaseURL
+	}
+	if httpClient == nil {
+		httpClient = newDefaultHTTPClient()
+	}
+
+	return &GhasedakProvider{
+		baseURL:         strings.TrimRight(baseURL, "/"),
+		httpClient:      httpClient,
+		credentials:     credentials,
+		defaultTemplate: defaultTemplate,
+	}
+}
+
+// SendOTP sends the code as param1 of the tenant's template, followed by any extra template params.
+func (p *GhasedakProvider) SendOTP(ctx context.Context, req otp.SMSRequest) (*otp.SMSResult, error) {
+	template := req.Template
+	if template == "" {
+		template = p.defaultTemplate
+	}
+	if template == "" {
+		return nil, &otp.SMSProviderError{
+			Provider: ghasedakProviderName,
+			Kind:     otp.ErrSMSRejected,
+			Message:  "no template configured",
+		}
+	}
+	if len(req.TemplateParams)+1 > ghasedakMaxTemplateArgs {
+		return nil, &otp.SMSProviderError{
+			Provider: ghasedakProviderName,
+			Kind:     otp.ErrSMSRejected,
+			Message:  fmt.Sprintf("template supports at most %d params including the code", ghasedakMaxTemplateArgs),
+		}
+	}
+
+	apiKey, err := p.credentials.GetSMSAPIKey(ctx, req.TenantID, ghasedakProviderName)
+	if err != nil {
+		return nil, credentialsError(ghasedakProviderName, err)
+	}
+
+	form := url.Values{}
+	form.Set("receptor", req.Phone)
+	form.Set("type", ghasedakVerifyTypeSMS)
+	form.Set("template", template)
+	form.Set("param1", req.Code)
+	for i, param := range req.TemplateParams {
+		form.Set("param"+strconv.Itoa(i+2), param)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/v2/verification/send/simple", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("ghasedak: build request: %w", err)
+	}
+	httpReq.Header.Set("apikey", apiKey)
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpReq.Header.Set("Accept", "application/json")
+
+	resp, written, err := doProviderRequest(p.httpClient, httpReq)
+	if err != nil {
+		return nil, providerTransportError(ghasedakProviderName, err, written)
+	}
+	defer resp.Body.Close()
+
+	body, err := readProviderResponse(resp)
+	if err != nil {
+		return nil, providerTransportError(ghasedakProviderName, err, true)
+	}
+
+	var parsed ghasedakResponse
+	if err := json.Unmarshal(body, &parsed); err != nil || parsed.Result.Code == 0 {
+		return nil, ghasedakError(resp.StatusCode, resp.StatusCode, "unparseable response", resp.Header)
+	}
+	if parsed.Result.Code != http.StatusOK {
+		return nil, ghasedakError(resp.StatusCode, parsed.Result.Code, parsed.Result.Message, resp.Header)
+	}
+	if len(parsed.Items) == 0 {
+		return nil, ghasedakError(resp.StatusCode, resp.StatusCode, "response has no message ids", resp.Header)
+	}
+
+	return &otp.SMSResult{
+		Provider:    ghasedakProviderName,
+		Status:      otp.RequestStatusSent,
+		MessageID:   parsed.Items[0].String(),
+		RawResponse: ghasedakRawResponse(body),
+		SentAt:      time.Now().UTC(),
+	}, nil
+}
+
+// ghasedakError maps Ghasedak result codes to SMS failure kinds.
+func ghasedakError(httpStatus int, resultCode int, message string, header http.Header) error {
+	providerErr := &otp.SMSProviderError{
+		Provider:   ghasedakProviderName,
+		Code:       strconv.Itoa(resultCode),
+		StatusCode: httpStatus,
+		Message:    message,
+	}
+
+	switch {
+	case resultCode == 401 || resultCode == 412:
+		providerErr.Kind = otp.ErrSMSInvalidCredentials
+	case resultCode == 418:
+		providerErr.Kind = otp.ErrSMSInsufficientCredit
+	case resultCode == 421:
+		providerErr.Kind = otp.ErrSMSInvalidRecipient
+	case resultCode == http.StatusTooManyRequests || resultCode >= 500:
+		providerErr.Kind = otp.ErrSMSProviderUnavailable
+		providerErr.Retryable = retryableProviderStatus(resultCode, header)
+	default:
+		providerErr.Kind = otp.ErrSMSRejected
+	}
+	return providerErr
+}
+
+func ghasedakRawResponse(body []byte) map[string]interface{} {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil
+	}
+	return raw
+}
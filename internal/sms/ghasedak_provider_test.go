@@ -0,0 +1,134 @@
+package sms
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"go-backend-service/internal/otp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ghasedakTestRequest() otp.SMSRequest {
+	return otp.SMSRequest{
+		RequestID:      "request-ghasedak",
+		TenantID:       99,
+		Phone:          "09121234567",
+		Code:           "123456",
+		Provider:       ghasedakProviderName,
+		Template:       "otp-login",
+		TemplateParams: []string{"Acme"},
+	}
+}
+
+func TestGhasedakProviderSendOTPSuccess(t *testing.T) {
+	var gotPath, gotAPIKey string
+	var gotForm url.Values
+	server := newRecordedServer(t, "ghasedak", recordedResponse{status: http.StatusOK, fixture: "success.json"}, func(r *http.Request) {
+		gotPath = r.URL.Path
+		gotAPIKey = r.Header.Get("apikey")
+		assert.NoError(t, r.ParseForm())
+		gotForm = r.PostForm
+	})
+	credentials := &fakeCredentials{apiKey: "ghasedak-key"}
+	provider := NewGhasedakProvider(server.URL, server.Client(), credentials, "")
+
+	result, err := provider.SendOTP(context.Background(), ghasedakTestRequest())
+
+	require.NoError(t, err)
+	assert.Equal(t, ghasedakProviderName, credentials.provider)
+	assert.Equal(t, "/v2/verification/send/simple", gotPath)
+	assert.Equal(t, "ghasedak-key", gotAPIKey)
+	assert.Equal(t, url.Values{
+		"receptor": {"09121234567"},
+		"type":     {"1"},
+		"template": {"otp-login"},
+		"param1":   {"123456"},
+		"param2":   {"Acme"},
+	}, gotForm)
+	assert.Equal(t, ghasedakProviderName, result.Provider)
+	assert.Equal(t, otp.RequestStatusSent, result.Status)
+	assert.Equal(t, "1346829453", result.MessageID)
+	assert.Equal(t, "success", result.RawResponse["result"].(map[string]interface{})["message"])
+}
+
+func TestGhasedakProviderUsesDefaultTemplate(t *testing.T) {
+	var gotTemplate string
+	server := newRecordedServer(t, "ghasedak", recordedResponse{status: http.StatusOK, fixture: "success.json"}, func(r *http.Request) {
+		gotTemplate = r.FormValue("template")
+	})
+	provider := NewGhasedakProvider(server.URL, server.Client(), &fakeCredentials{apiKey: "ghasedak-key"}, "default-template")
+	req := ghasedakTestRequest()
+	req.Template = ""
+
+	_, err := provider.SendOTP(context.Background(), req)
+
+	require.NoError(t, err)
+	assert.Equal(t, "default-template", gotTemplate)
+}
+
+func TestGhasedakProviderSendOTPErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		response  recordedResponse
+		kind      error
+		code      string
+		retryable bool
+	}{
+		{name: "invalid api key", response: recordedResponse{status: http.StatusUnauthorized, fixture: "invalid_key.json"}, kind: otp.ErrSMSInvalidCredentials, code: "401"},
+		{name: "out of credit", response: recordedResponse{status: http.StatusOK, fixture: "out_of_credit.json"}, kind: otp.ErrSMSInsufficientCredit, code: "418"},
+		{name: "invalid receptor", response: recordedResponse{status: http.StatusOK, fixture: "invalid_receptor.json"}, kind: otp.ErrSMSInvalidRecipient, code: "421"},
+		{name: "missing params", response: recordedResponse{status: http.StatusBadRequest, fixture: "missing_params.json"}, kind: otp.ErrSMSRejected, code: "400"},
+		{name: "server busy", response: recordedResponse{status: http.StatusServiceUnavailable, fixture: "server_busy.json"}, kind: otp.ErrSMSProviderUnavailable, code: "503"},
+		{name: "server busy with retry-after", response: recordedResponse{status: http.StatusServiceUnavailable, fixture: "server_busy.json", retryAfter: "2"}, kind: otp.ErrSMSProviderUnavailable, code: "503", retryable: true},
+		{name: "html gateway error", response: recordedResponse{status: http.StatusBadGateway, fixture: "bad_gateway.html"}, kind: otp.ErrSMSProviderUnavailable, code: "502"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newRecordedServer(t, "ghasedak", tt.response, nil)
+			provider := NewGhasedakProvider(server.URL, server.Client(), &fakeCredentials{apiKey: "ghasedak-key"}, "")
+
+			result, err := provider.SendOTP(context.Background(), ghasedakTestRequest())
+
+			require.Nil(t, result)
+			require.ErrorIs(t, err, tt.kind)
+			var providerErr *otp.SMSProviderError
+			require.ErrorAs(t, err, &providerErr)
+			assert.Equal(t, ghasedakProviderName, providerErr.Provider)
+			assert.Equal(t, tt.code, providerErr.Code)
+			assert.Equal(t, tt.response.status, providerErr.StatusCode)
+			assert.Equal(t, tt.retryable, providerErr.Retryable)
+		})
+	}
+}
+
+func TestGhasedakProviderRejectsBeforeCallingAPI(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(req *otp.SMSRequest)
+	}{
+		{name: "missing template", modify: func(req *otp.SMSRequest) { req.Template = "" }},
+		{name: "too many params", modify: func(req *otp.SMSRequest) {
+			req.TemplateParams = []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credentials := &fakeCredentials{apiKey: "ghasedak-key"}
+			provider := NewGhasedakProvider("http://127.0.0.1:1", nil, credentials, "")
+			req := ghasedakTestRequest()
+			tt.modify(&req)
+
+			result, err := provider.SendOTP(context.Background(), req)
+
+			require.Nil(t, result)
+			assert.ErrorIs(t, err, otp.ErrSMSRejected)
+			assert.Equal(t, 0, credentials.calls)
+		})
+	}
+}
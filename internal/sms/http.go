@@ -0,0 +1,84 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"go-backend-service/internal/otp"
+)
+
+const (
+	defaultHTTPTimeout       = 10 * time.Second
+	maxProviderResponseBytes = 64 << 10
+)
+
+func newDefaultHTTPClient() *http.Client {
+	return &http.Client{Timeout: defaultHTTPTimeout}
+}
+
+// readProviderResponse reads a bounded provider response body.
+func readProviderResponse(resp *http.Response) ([]byte, error) {
+	return io.ReadAll(io.LimitReader(resp.Body, maxProviderResponseBytes))
+}
+
+// doProviderRequest sends req and reports whether the whole request was written to the
+// connection. Once it was, the provider may have delivered the SMS whatever error follows.
+func doProviderRequest(client *http.Client, req *http.Request) (*http.Response, bool, error) {
+	var written atomic.Bool
+	trace := &httptrace.ClientTrace{
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			if info.Err == nil {
+				written.Store(true)
+			}
+		},
+	}
+	resp, err := client.Do(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	return resp, written.Load(), err
+}
+
+// retryableProviderStatus reports whether a provider status proves the send was turned
+// away before the SMS went out: 429, or 503 with a Retry-After header. Other 5xx
+// responses can follow a message the provider already accepted, so they are final.
+func retryableProviderStatus(status int, header http.Header) bool {
+	return status == http.StatusTooManyRequests ||
+		(status == http.StatusServiceUnavailable && header.Get("Retry-After") != "")
+}
+
+// providerTransportError converts a failed HTTP round trip into an SMSProviderError.
+// The *url.Error wrapper is dropped because its URL may embed the tenant API key.
+// None of the providers takes an idempotency key, so only a request that never fully
+// reached the provider is retryable: retrying a written one, e.g. after a response
+// timeout, could deliver a second SMS.
+func providerTransportError(provider string, err error, written bool) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+
+	return &otp.SMSProviderError{
+		Provider:  provider,
+		Kind:      otp.ErrSMSProviderUnavailable,
+		Retryable: !written && !errors.Is(err, context.Canceled),
+		Err:       err,
+	}
+}
+
+// credentialsError reports a failed tenant credentials lookup. Missing keys keep their
+// ErrSMSInvalidCredentials kind; other lookup failures are not provider failures.
+func credentialsError(provider string, err error) error {
+	if errors.Is(err, otp.ErrSMSInvalidCredentials) {
+		return &otp.SMSProviderError{
+			Provider: provider,
+			Kind:     otp.ErrSMSInvalidCredentials,
+			Err:      err,
+		}
+	}
+	return fmt.Errorf("%s: get sms credentials: %w", provider, err)
+}
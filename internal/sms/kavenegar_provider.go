@@ -0,0 +1,165 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-backend-service/internal/otp"
+)
+
+const (
+	kavenegarProviderName   = "kavenegar"
+	defaultKavenegarBaseURL = "https://api.kavenegar.com"
+)
+
+// KavenegarProvider sends OTP codes through the Kavenegar verify/lookup API.
+type KavenegarProvider struct {
+	baseURL         string
+	httpClient      *http.Client
+	credentials     CredentialsProvider
+	defaultTemplate string
+}
+
+type kavenegarResponse struct {
+	Return struct {
+		Status  int    `json:"status"`
+		Message string `json:"message"`
+	} `json:"return"`
+	Entries []struct {
+		MessageID  int64  `json:"messageid"`
+		Status     int    `json:"status"`
+		StatusText string `json:"statustext"`
+	} `json:"entries"`
+}
+
+// NewKavenegarProvider creates a Kavenegar adapter. An empty baseURL uses the public API
+// and a nil httpClient uses a client with a conservative timeout. defaultTemplate is used
+// when the tenant has no sms_template configured.
+func NewKavenegarProvider(baseURL string, httpClient *http.Client, credentials CredentialsProvider, defaultTemplate string) *KavenegarProvider {
+	if baseURL == "" {
+		baseURL = defaultKavenegarBaseURL
+	}
+	if httpClient == nil {
+		httpClient = newDefaultHTTPClient()
+	}
+
+	return &KavenegarProvider{
+		baseURL:         strings.TrimRight(baseURL, "/"),
+		httpClient:      httpClient,
+		credentials:     credentials,
+		defaultTemplate: defaultTemplate,
+	}
+}
+
+// SendOTP sends the code with the tenant's lookup template and API key.
+func (p *KavenegarProvider) SendOTP(ctx context.Context, req otp.SMSRequest) (*otp.SMSResult, error) {
+	template := req.Template
+	if template == "" {
+		template = p.defaultTemplate
+	}
+	if template == "" {
+		return nil, &otp.SMSProviderError{
+			Provider: kavenegarProviderName,
+			Kind:     otp.ErrSMSRejected,
+			Message:  "no lookup template configured",
+		}
+	}
+
+	apiKey, err := p.credentials.GetSMSAPIKey(ctx, req.TenantID, kavenegarProviderName)
+	if err != nil {
+		return nil, credentialsError(kavenegarProviderName, err)
+	}
+
+	form := url.Values{}
+	form.Set("receptor", req.Phone)
+	form.Set("token", req.Code)
+	form.Set("template", template)
+
+	endpoint := fmt.Sprintf("%s/v1/%s/verify/lookup.json", p.baseURL, url.PathEscape(apiKey))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("kavenegar: build request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpReq.Header.Set("Accept", "application/json")
+
+	resp, written, err := doProviderRequest(p.httpClient, httpReq)
+	if err != nil {
+		return nil, providerTransportError(kavenegarProviderName, err, written)
+	}
+	defer resp.Body.Close()
+
+	body, err := readProviderResponse(resp)
+	if err != nil {
+		return nil, providerTransportError(kavenegarProviderName, err, true)
+	}
+
+	var parsed kavenegarResponse
+	if err := json.Unmarshal(body, &parsed); err != nil || parsed.Return.Status == 0 {
+		return nil, kavenegarError(resp.StatusCode, resp.StatusCode, "unparseable response", resp.Header)
+	}
+	if parsed.Return.Status != http.StatusOK {
+		return nil, kavenegarError(resp.StatusCode, parsed.Return.Status, parsed.Return.Message, resp.Header)
+	}
+	if len(parsed.Entries) == 0 {
+		return nil, kavenegarError(resp.StatusCode, resp.StatusCode, "response has no message entries", resp.Header)
+	}
+
+	return &otp.SMSResult{
+		Provider:    kavenegarProviderName,
+		Status:      otp.RequestStatusSent,
+		MessageID:   strconv.FormatInt(parsed.Entries[0].MessageID, 10),
+		RawResponse: kavenegarRawResponse(body),
+		SentAt:      time.Now().UTC(),
+	}, nil
+}
+
+// kavenegarError maps Kavenegar return statuses to SMS failure kinds.
+// See https://kavenegar.com/rest.html for the status table.
+func kavenegarError(httpStatus int, apiStatus int, message string, header http.Header) error {
+	providerErr := &otp.SMSProviderError{
+		Provider:   kavenegarProviderName,
+		Code:       strconv.Itoa(apiStatus),
+		StatusCode: httpStatus,
+		Message:    message,
+	}
+
+	switch {
+	case apiStatus == 401 || apiStatus == 403:
+		providerErr.Kind = otp.ErrSMSInvalidCredentials
+	case apiStatus == 418:
+		providerErr.Kind = otp.ErrSMSInsufficientCredit
+	case apiStatus == 411:
+		providerErr.Kind = otp.ErrSMSInvalidRecipient
+	case apiStatus == http.StatusTooManyRequests || apiStatus == 414 || apiStatus >= 500:
+		providerErr.Kind = otp.ErrSMSProviderUnavailable
+		providerErr.Retryable = retryableProviderStatus(apiStatus, header)
+	default:
+		providerErr.Kind = otp.ErrSMSRejected
+	}
+	return providerErr
+}
+
+// kavenegarRawResponse keeps the decoded response but drops the rendered message text,
+// which contains the OTP code.
+func kavenegarRawResponse(body []byte) map[string]interface{} {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil
+	}
+
+	if entries, ok := raw["entries"].([]interface{}); ok {
+		for _, entry := range entries {
+			if fields, ok := entry.(map[string]interface{}); ok {
+				delete(fields, "message")
+			}
+		}
+	}
+	return raw
+}
@@ -0,0 +1,291 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-backend-service/internal/otp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCredentials struct {
+	apiKey   string
+	err      error
+	calls    int
+	provider string
+}
+
+func (c *fakeCredentials) GetSMSAPIKey(ctx context.Context, tenantID int64, provider string) (string, error) {
+	c.calls++
+	c.provider = provider
+	if c.err != nil {
+		return "", c.err
+	}
+	return c.apiKey, nil
+}
+
+type recordedResponse struct {
+	status     int
+	fixture    string
+	retryAfter string
+}
+
+func newRecordedServer(t *testing.T, dir string, response recordedResponse, inspect func(r *http.Request)) *httptest.Server {
+	t.Helper()
+
+	body, err := os.ReadFile(filepath.Join("testdata", dir, response.fixture))
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if inspect != nil {
+			inspect(r)
+		}
+		if response.retryAfter != "" {
+			w.Header().Set("Retry-After", response.retryAfter)
+		}
+		w.WriteHeader(response.status)
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func kavenegarTestRequest() otp.SMSRequest {
+	return otp.SMSRequest{
+		RequestID: "request-kavenegar",
+		TenantID:  77,
+		Phone:     "09121234567",
+		Code:      "123456",
+		Provider:  kavenegarProviderName,
+		Template:  "verify-tenant",
+	}
+}
+
+func TestKavenegarProviderSendOTPSuccess(t *testing.T) {
+	var gotPath string
+	var gotForm map[string]string
+	server := newRecordedServer(t, "kavenegar", recordedResponse{status: http.StatusOK, fixture: "success.json"}, func(r *http.Request) {
+		gotPath = r.URL.Path
+		assert.NoError(t, r.ParseForm())
+		gotForm = map[string]string{
+			"receptor": r.PostForm.Get("receptor"),
+			"token":    r.PostForm.Get("token"),
+			"template": r.PostForm.Get("template"),
+		}
+	})
+	credentials := &fakeCredentials{apiKey: "test-api-key"}
+	provider := NewKavenegarProvider(server.URL, server.Client(), credentials, "default-template")
+
+	result, err := provider.SendOTP(context.Background(), kavenegarTestRequest())
+
+	require.NoError(t, err)
+	assert.Equal(t, "/v1/test-api-key/verify/lookup.json", gotPath)
+	assert.Equal(t, map[string]string{"receptor": "09121234567", "token": "123456", "template": "verify-tenant"}, gotForm)
+	assert.Equal(t, kavenegarProviderName, result.Provider)
+	assert.Equal(t, otp.RequestStatusSent, result.Status)
+	assert.Equal(t, "8792343", result.MessageID)
+	assert.False(t, result.SentAt.IsZero())
+	assert.Equal(t, 1, credentials.calls)
+	assert.Equal(t, kavenegarProviderName, credentials.provider)
+
+	require.NotNil(t, result.RawResponse)
+	entries := result.RawResponse["entries"].([]interface{})
+	require.Len(t, entries, 1)
+	entry := entries[0].(map[string]interface{})
+	assert.Equal(t, "در صف ارسال", entry["statustext"])
+	assert.NotContains(t, entry, "message")
+}
+
+func TestKavenegarProviderUsesDefaultTemplate(t *testing.T) {
+	var gotTemplate string
+	server := newRecordedServer(t, "kavenegar", recordedResponse{status: http.StatusOK, fixture: "success.json"}, func(r *http.Request) {
+		gotTemplate = r.FormValue("template")
+	})
+	provider := NewKavenegarProvider(server.URL, server.Client(), &fakeCredentials{apiKey: "test-api-key"}, "default-template")
+	req := kavenegarTestRequest()
+	req.Template = ""
+
+	_, err := provider.SendOTP(context.Background(), req)
+
+	require.NoError(t, err)
+	assert.Equal(t, "default-template", gotTemplate)
+}
+
+func TestKavenegarProviderSendOTPErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		response   recordedResponse
+		kind       error
+		code       string
+		statusCode int
+		retryable  bool
+	}{
+		{
+			name:       "invalid api key",
+			response:   recordedResponse{status: http.StatusForbidden, fixture: "invalid_key.json"},
+			kind:       otp.ErrSMSInvalidCredentials,
+			code:       "403",
+			statusCode: http.StatusForbidden,
+		},
+		{
+			name:       "out of credit",
+			response:   recordedResponse{status: 418, fixture: "out_of_credit.json"},
+			kind:       otp.ErrSMSInsufficientCredit,
+			code:       "418",
+			statusCode: 418,
+		},
+		{
+			name:       "invalid receptor",
+			response:   recordedResponse{status: 411, fixture: "invalid_receptor.json"},
+			kind:       otp.ErrSMSInvalidRecipient,
+			code:       "411",
+			statusCode: 411,
+		},
+		{
+			name:       "template not found",
+			response:   recordedResponse{status: 424, fixture: "template_not_found.json"},
+			kind:       otp.ErrSMSRejected,
+			code:       "424",
+			statusCode: 424,
+		},
+		{
+			name:       "server error",
+			response:   recordedResponse{status: http.StatusInternalServerError, fixture: "server_error.json"},
+			kind:       otp.ErrSMSProviderUnavailable,
+			code:       "500",
+			statusCode: http.StatusInternalServerError,
+		},
+		{
+			name:       "bad gateway html",
+			response:   recordedResponse{status: http.StatusBadGateway, fixture: "bad_gateway.html"},
+			kind:       otp.ErrSMSProviderUnavailable,
+			code:       "502",
+			statusCode: http.StatusBadGateway,
+		},
+		{
+			name:       "service unavailable with retry-after",
+			response:   recordedResponse{status: http.StatusServiceUnavailable, fixture: "bad_gateway.html", retryAfter: "1"},
+			kind:       otp.ErrSMSProviderUnavailable,
+			code:       "503",
+			statusCode: http.StatusServiceUnavailable,
+			retryable:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newRecordedServer(t, "kavenegar", tt.response, nil)
+			provider := NewKavenegarProvider(server.URL, server.Client(), &fakeCredentials{apiKey: "test-api-key"}, "")
+
+			result, err := provider.SendOTP(context.Background(), kavenegarTestRequest())
+
+			require.Nil(t, result)
+			require.ErrorIs(t, err, tt.kind)
+			var providerErr *otp.SMSProviderError
+			require.ErrorAs(t, err, &providerErr)
+			assert.Equal(t, kavenegarProviderName, providerErr.Provider)
+			assert.Equal(t, tt.code, providerErr.Code)
+			assert.Equal(t, tt.statusCode, providerErr.StatusCode)
+			assert.Equal(t, tt.retryable, providerErr.Retryable)
+			assert.NotContains(t, err.Error(), "test-api-key")
+		})
+	}
+}
+
+func TestKavenegarProviderMissingTemplate(t *testing.T) {
+	credentials := &fakeCredentials{apiKey: "test-api-key"}
+	provider := NewKavenegarProvider("http://127.0.0.1:1", nil, credentials, "")
+	req := kavenegarTestRequest()
+	req.Template = ""
+
+	result, err := provider.SendOTP(context.Background(), req)
+
+	require.Nil(t, result)
+	assert.ErrorIs(t, err, otp.ErrSMSRejected)
+	assert.Equal(t, 0, credentials.calls)
+}
+
+func TestKavenegarProviderCredentialsErrors(t *testing.T) {
+	missingKeyErr := fmt.Errorf("tenant 77 has no kavenegar api key: %w", otp.ErrSMSInvalidCredentials)
+	lookupErr := errors.New("database unavailable")
+
+	t.Run("missing key", func(t *testing.T) {
+		provider := NewKavenegarProvider("http://127.0.0.1:1", nil, &fakeCredentials{err: missingKeyErr}, "")
+
+		_, err := provider.SendOTP(context.Background(), kavenegarTestRequest())
+
+		assert.ErrorIs(t, err, otp.ErrSMSInvalidCredentials)
+		var providerErr *otp.SMSProviderError
+		require.ErrorAs(t, err, &providerErr)
+		assert.False(t, providerErr.Retryable)
+	})
+
+	t.Run("lookup failure", func(t *testing.T) {
+		provider := NewKavenegarProvider("http://127.0.0.1:1", nil, &fakeCredentials{err: lookupErr}, "")
+
+		_, err := provider.SendOTP(context.Background(), kavenegarTestRequest())
+
+		assert.ErrorIs(t, err, lookupErr)
+		var providerErr *otp.SMSProviderError
+		assert.False(t, errors.As(err, &providerErr))
+	})
+}
+
+func TestKavenegarProviderTransportErrorHidesAPIKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+	provider := NewKavenegarProvider(server.URL, server.Client(), &fakeCredentials{apiKey: "test-api-key"}, "")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	result, err := provider.SendOTP(ctx, kavenegarTestRequest())
+
+	require.Nil(t, result)
+	assert.ErrorIs(t, err, otp.ErrSMSProviderUnavailable)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NotContains(t, err.Error(), "test-api-key")
+	// The request was written, so the SMS may have gone out: retrying could send two.
+	var providerErr *otp.SMSProviderError
+	require.ErrorAs(t, err, &providerErr)
+	assert.False(t, providerErr.Retryable)
+}
+
+func TestKavenegarProviderBadGatewayIsNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	server := newRecordedServer(t, "kavenegar", recordedResponse{status: http.StatusBadGateway, fixture: "bad_gateway.html"}, func(r *http.Request) {
+		calls.Add(1)
+	})
+	provider := NewRetryingProvider(kavenegarProviderName, NewKavenegarProvider(server.URL, server.Client(), &fakeCredentials{apiKey: "test-api-key"}, ""),
+		RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}, nil)
+
+	_, err := provider.SendOTP(context.Background(), kavenegarTestRequest())
+
+	assert.ErrorIs(t, err, otp.ErrSMSProviderUnavailable)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestKavenegarProviderConnectionRefusedIsRetryable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	serverURL := server.URL
+	server.Close()
+	provider := NewKavenegarProvider(serverURL, nil, &fakeCredentials{apiKey: "test-api-key"}, "")
+
+	_, err := provider.SendOTP(context.Background(), kavenegarTestRequest())
+
+	assert.ErrorIs(t, err, otp.ErrSMSProviderUnavailable)
+	var providerErr *otp.SMSProviderError
+	require.ErrorAs(t, err, &providerErr)
+	assert.True(t, providerErr.Retryable)
+}
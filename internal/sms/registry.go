@@ -0,0 +1,63 @@
+package sms
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"go-backend-service/internal/otp"
+)
+
+// Registry routes each OTP send to the adapter registered under SMSRequest.Provider.
+type Registry struct {
+	mu        sync.RWMutex
+	providers map[string]otp.SMSProvider
+}
+
+// NewRegistry creates an empty provider registry.
+func NewRegistry() *Registry {
+	return &Registry{providers: make(map[string]otp.SMSProvider)}
+}
+
+// Register adds or replaces the adapter for a provider name. Names are case-insensitive.
+func (r *Registry) Register(name string, provider otp.SMSProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[normalizeProviderName(name)] = provider
+}
+
+// Providers returns the registered provider names in sorted order.
+func (r *Registry) Providers() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SendOTP dispatches to the adapter for req.Provider. Unknown or empty provider names fail
+// with ErrSMSProviderNotConfigured.
+func (r *Registry) SendOTP(ctx context.Context, req otp.SMSRequest) (*otp.SMSResult, error) {
+	name := normalizeProviderName(req.Provider)
+
+	r.mu.RLock()
+	provider, ok := r.providers[name]
+	r.mu.RUnlock()
+
+	if !ok || provider == nil {
+		return nil, &otp.SMSProviderError{
+			Provider: req.Provider,
+			Kind:     otp.ErrSMSProviderNotConfigured,
+		}
+	}
+	return provider.SendOTP(ctx, req)
+}
+
+func normalizeProviderName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
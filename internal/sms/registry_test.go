@@ -0,0 +1,85 @@
+package sms
+
+import (
+	"context"
+	"testing"
+
+	"go-backend-service/internal/otp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubProvider struct {
+	name  string
+	calls int
+	req   otp.SMSRequest
+}
+
+func (p *stubProvider) SendOTP(ctx context.Context, req otp.SMSRequest) (*otp.SMSResult, error) {
+	p.calls++
+	p.req = req
+	return &otp.SMSResult{Provider: p.name, Status: otp.RequestStatusSent}, nil
+}
+
+func TestRegistryDispatchesByProvider(t *testing.T) {
+	kavenegar := &stubProvider{name: "kavenegar"}
+	ghasedak := &stubProvider{name: "ghasedak"}
+	registry := NewRegistry()
+	registry.Register("kavenegar", kavenegar)
+	registry.Register("Ghasedak", ghasedak)
+
+	result, err := registry.SendOTP(context.Background(), otp.SMSRequest{RequestID: "request-1", Provider: "ghasedak"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "ghasedak", result.Provider)
+	assert.Equal(t, 1, ghasedak.calls)
+	assert.Equal(t, "request-1", ghasedak.req.RequestID)
+	assert.Equal(t, 0, kavenegar.calls)
+
+	_, err = registry.SendOTP(context.Background(), otp.SMSRequest{Provider: " KAVENEGAR "})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, kavenegar.calls)
+	assert.Equal(t, []string{"ghasedak", "kavenegar"}, registry.Providers())
+}
+
+func TestRegistryRegisterReplacesProvider(t *testing.T) {
+	first := &stubProvider{name: "first"}
+	second := &stubProvider{name: "second"}
+	registry := NewRegistry()
+	registry.Register("other", first)
+	registry.Register("other", second)
+
+	result, err := registry.SendOTP(context.Background(), otp.SMSRequest{Provider: "other"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "second", result.Provider)
+	assert.Equal(t, 0, first.calls)
+}
+
+func TestRegistryUnknownProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+	}{
+		{name: "unregistered", provider: "twilio"},
+		{name: "empty", provider: ""},
+	}
+
+	registry := NewRegistry()
+	registry.Register("kavenegar", &stubProvider{name: "kavenegar"})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := registry.SendOTP(context.Background(), otp.SMSRequest{Provider: tt.provider})
+
+			require.Nil(t, result)
+			assert.ErrorIs(t, err, otp.ErrSMSProviderNotConfigured)
+			var providerErr *otp.SMSProviderError
+			require.ErrorAs(t, err, &providerErr)
+			assert.Equal(t, tt.provider, providerErr.Provider)
+			assert.False(t, providerErr.Retryable)
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"go-backend-service/internal/metrics"
+	"go-backend-service/internal/otp"
+)
+
+// RetryConfig controls how often and how patiently a provider send is retried.
+type RetryConfig struct {
+	// MaxAttempts includes the first call; 1 disables retries.
+	MaxAttempts int
+	// BaseDelay is the backoff ceiling before the first retry; it doubles per retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff ceiling.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryConfig returns a short retry policy that fits inside the provider timeout.
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxAttempts: 3,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    time.Second,
+	}
+}
+
+// RetryBudget caps retries to a fraction of send traffic so that retries cannot
+// multiply load on a provider during an outage. Every send deposits ratio tokens,
+// every retry withdraws one. A single budget is meant to be shared by all providers.
+type RetryBudget struct {
+	mu        sync.Mutex
+	ratio     float64
+	maxTokens float64
+	tokens    float64
+}
+
+// NewRetryBudget allows roughly ratio retries per send, with bursts of up to maxTokens retries.
+func NewRetryBudget(ratio float64, maxTokens int) *RetryBudget {
+	return &RetryBudget{
+		ratio:     ratio,
+		maxTokens: float64(maxTokens),
+		tokens:    float64(maxTokens),
+	}
+}
+
+func (b *RetryBudget) deposit() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.maxTokens, b.tokens+b.ratio)
+}
+
+func (b *RetryBudget) withdraw() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// RetryingProvider decorates an otp.SMSProvider and retries failures the adapter marked
+// as retryable, with full-jitter exponential backoff. No adapter sends an idempotency key,
+// so failures are only retryable when the provider cannot have delivered the SMS: 429,
+// 503 with Retry-After, and requests that never fully reached the provider. Other 5xx
+// responses may follow an accepted message and are left to failover instead.
+type RetryingProvider struct {
+	name   string
+	next   otp.SMSProvider
+	config RetryConfig
+	budget *RetryBudget
+	now    func() time.Time
+	jitter func(ceiling time.Duration) time.Duration
+}
+
+// NewRetryingProvider wraps next. A nil budget leaves retries unbounded by traffic.
+func NewRetryingProvider(name string, next otp.SMSProvider, config RetryConfig, budget *RetryBudget) *RetryingProvider {
+	defaults := DefaultRetryConfig()
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.BaseDelay <= 0 {
+		config.BaseDelay = defaults.BaseDelay
+	}
+	if config.MaxDelay < config.BaseDelay {
+		config.MaxDelay = config.BaseDelay
+	}
+
+	return &RetryingProvider{
+		name:   name,
+		next:   next,
+		config: config,
+		budget: budget,
+		now:    time.Now,
+		jitter: func(ceiling time.Duration) time.Duration {
+			return time.Duration(rand.Int63n(int64(ceiling) + 1))
+		},
+	}
+}
+
+// SendOTP calls the wrapped provider until it succeeds, fails permanently, runs out of
+// attempts or budget, or the next backoff would outlive the context deadline.
+// The last provider error is returned unchanged.
+func (p *RetryingProvider) SendOTP(ctx context.Context, req otp.SMSRequest) (*otp.SMSResult, error) {
+	p.budget.deposit()
+
+	for attempt := 1; ; attempt++ {
+		result, err := p.next.SendOTP(ctx, req)
+		if err == nil || attempt >= p.config.MaxAttempts || !isRetryable(ctx, err) {
+			return result, err
+		}
+
+		delay := p.backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && !p.now().Add(delay).Before(deadline) {
+			metrics.SMSProviderRetries.WithLabelValues(p.name, "deadline_exceeded").Inc()
+			return result, err
+		}
+		if !p.budget.withdraw() {
+			metrics.SMSProviderRetries.WithLabelValues(p.name, "budget_exhausted").Inc()
+			return result, err
+		}
+		metrics.SMSProviderRetries.WithLabelValues(p.name, "retried").Inc()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, err
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the full-jitter delay before retry number attempt.
+func (p *RetryingProvider) backoff(attempt int) time.Duration {
+	ceiling := p.config.BaseDelay
+	for i := 1; i < attempt && ceiling < p.config.MaxDelay; i++ {
+		ceiling *= 2
+	}
+	return p.jitter(min(ceiling, p.config.MaxDelay))
+}
+
+// isRetryable trusts the adapter's classification. An open circuit is never retried:
+// failing over to the next provider is faster than waiting for it to close.
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, otp.ErrSMSCircuitOpen) {
+		return false
+	}
+
+	var providerErr *otp.SMSProviderError
+	return errors.As(err, &providerErr) && providerErr.Retryable
+}
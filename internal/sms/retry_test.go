@@ -0,0 +1,175 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go-backend-service/internal/metrics"
+	"go-backend-service/internal/otp"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sequenceProvider returns errs in order and succeeds once they run out.
+type sequenceProvider struct {
+	mu       sync.Mutex
+	errs     []error
+	requests []otp.SMSRequest
+}
+
+func (p *sequenceProvider) SendOTP(ctx context.Context, req otp.SMSRequest) (*otp.SMSResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests = append(p.requests, req)
+	if len(p.errs) > 0 {
+		err := p.errs[0]
+		p.errs = p.errs[1:]
+		return nil, err
+	}
+	return &otp.SMSResult{Provider: req.Provider, Status: otp.RequestStatusSent}, nil
+}
+
+func newTestRetryingProvider(name string, next otp.SMSProvider, maxAttempts int, budget *RetryBudget) *RetryingProvider {
+	provider := NewRetryingProvider(name, next, RetryConfig{
+		MaxAttempts: maxAttempts,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    4 * time.Millisecond,
+	}, budget)
+	provider.jitter = func(ceiling time.Duration) time.Duration { return ceiling }
+	return provider
+}
+
+func retryableError(provider string) error {
+	return &otp.SMSProviderError{Provider: provider, Kind: otp.ErrSMSProviderUnavailable, StatusCode: 503, Retryable: true}
+}
+
+func TestRetryingProviderRetriesTransientFailures(t *testing.T) {
+	next := &sequenceProvider{errs: []error{retryableError("retry-ok"), retryableError("retry-ok")}}
+	provider := newTestRetryingProvider("retry-ok", next, 3, nil)
+	req := otp.SMSRequest{RequestID: "req-1", Provider: "retry-ok", Phone: "+989121234567", Code: "123456"}
+
+	result, err := provider.SendOTP(context.Background(), req)
+
+	require.NoError(t, err)
+	assert.Equal(t, otp.RequestStatusSent, result.Status)
+	require.Len(t, next.requests, 3)
+	for _, sent := range next.requests {
+		assert.Equal(t, req, sent)
+	}
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.SMSProviderRetries.WithLabelValues("retry-ok", "retried")))
+}
+
+func TestRetryingProviderStopsAfterMaxAttempts(t *testing.T) {
+	lastErr := retryableError("retry-max")
+	next := &sequenceProvider{errs: []error{retryableError("retry-max"), lastErr, retryableError("retry-max")}}
+	provider := newTestRetryingProvider("retry-max", next, 2, nil)
+
+	_, err := provider.SendOTP(context.Background(), otp.SMSRequest{RequestID: "req-1"})
+
+	assert.Same(t, lastErr, err)
+	assert.Len(t, next.requests, 2)
+}
+
+func TestRetryingProviderDoesNotRetryPermanentFailures(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "non-retryable provider error", err: &otp.SMSProviderError{Provider: "p", Kind: otp.ErrSMSInvalidRecipient}},
+		{name: "rejected", err: &otp.SMSProviderError{Provider: "p", Kind: otp.ErrSMSRejected, StatusCode: 400}},
+		{name: "circuit open", err: &otp.SMSProviderError{Provider: "p", Kind: otp.ErrSMSCircuitOpen, Retryable: true}},
+		{name: "unclassified", err: errors.New("boom")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &sequenceProvider{errs: []error{tt.err}}
+			provider := newTestRetryingProvider("retry-permanent", next, 3, nil)
+
+			_, err := provider.SendOTP(context.Background(), otp.SMSRequest{RequestID: "req-1"})
+
+			assert.Same(t, tt.err, err)
+			assert.Len(t, next.requests, 1)
+		})
+	}
+}
+
+func TestRetryingProviderStopsWhenBackoffOutlivesDeadline(t *testing.T) {
+	next := &sequenceProvider{errs: []error{retryableError("retry-deadline"), retryableError("retry-deadline")}}
+	provider := newTestRetryingProvider("retry-deadline", next, 3, nil)
+	provider.jitter = func(time.Duration) time.Duration { return time.Hour }
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	started := time.Now()
+	_, err := provider.SendOTP(ctx, otp.SMSRequest{RequestID: "req-1"})
+
+	require.ErrorIs(t, err, otp.ErrSMSProviderUnavailable)
+	assert.Len(t, next.requests, 1)
+	assert.Less(t, time.Since(started), 500*time.Millisecond)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.SMSProviderRetries.WithLabelValues("retry-deadline", "deadline_exceeded")))
+}
+
+func TestRetryingProviderStopsWhenContextEnds(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	next := otpProviderFunc(func(ctx context.Context, req otp.SMSRequest) (*otp.SMSResult, error) {
+		cancel()
+		return nil, retryableError("retry-cancel")
+	})
+	provider := newTestRetryingProvider("retry-cancel", next, 5, nil)
+
+	_, err := provider.SendOTP(ctx, otp.SMSRequest{RequestID: "req-1"})
+
+	require.ErrorIs(t, err, otp.ErrSMSProviderUnavailable)
+}
+
+func TestRetryingProviderRespectsBudget(t *testing.T) {
+	budget := NewRetryBudget(0.5, 1)
+	next := &sequenceProvider{errs: []error{
+		retryableError("retry-budget"), retryableError("retry-budget"),
+		retryableError("retry-budget"),
+		retryableError("retry-budget"), retryableError("retry-budget"),
+	}}
+	provider := newTestRetryingProvider("retry-budget", next, 2, budget)
+
+	// The initial token pays for the first retry.
+	_, err := provider.SendOTP(context.Background(), otp.SMSRequest{RequestID: "req-1"})
+	require.Error(t, err)
+	assert.Len(t, next.requests, 2)
+
+	// Half a token is not enough for another retry.
+	_, err = provider.SendOTP(context.Background(), otp.SMSRequest{RequestID: "req-2"})
+	require.Error(t, err)
+	assert.Len(t, next.requests, 3)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.SMSProviderRetries.WithLabelValues("retry-budget", "budget_exhausted")))
+
+	// Two sends have refilled a whole token.
+	_, err = provider.SendOTP(context.Background(), otp.SMSRequest{RequestID: "req-3"})
+	require.Error(t, err)
+	assert.Len(t, next.requests, 5)
+}
+
+func TestRetryBudgetIsCapped(t *testing.T) {
+	budget := NewRetryBudget(1, 2)
+	for i := 0; i < 10; i++ {
+		budget.deposit()
+	}
+
+	assert.True(t, budget.withdraw())
+	assert.True(t, budget.withdraw())
+	assert.False(t, budget.withdraw())
+}
+
+func TestRetryingProviderBackoffIsCapped(t *testing.T) {
+	provider := newTestRetryingProvider("retry-backoff", &sequenceProvider{}, 10, nil)
+
+	assert.Equal(t, time.Millisecond, provider.backoff(1))
+	assert.Equal(t, 2*time.Millisecond, provider.backoff(2))
+	assert.Equal(t, 4*time.Millisecond, provider.backoff(3))
+	assert.Equal(t, 4*time.Millisecond, provider.backoff(8))
+}
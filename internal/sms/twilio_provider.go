@@ -0,0 +1,189 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-backend-service/internal/otp"
+)
+
+const (
+	twilioProviderName    = "twilio"
+	defaultTwilioBaseURL  = "https://api.twilio.com"
+	defaultTwilioBody     = "Your verification code is: {code}"
+	twilioCodePlaceholder = "{code}"
+)
+
+// TwilioProvider sends OTP codes through the Twilio Messages REST API.
+// The tenant's Twilio API key is stored as "AccountSid:AuthToken".
+type TwilioProvider struct {
+	baseURL       string
+	httpClient    *http.Client
+	credentials   CredentialsProvider
+	defaultSender string
+}
+
+type twilioMessageResponse struct {
+	SID    string `json:"sid"`
+	Status string `json:"status"`
+}
+
+type twilioErrorResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  int    `json:"status"`
+}
+
+// NewTwilioProvider creates a Twilio adapter. An empty baseURL uses the public API and a
+// nil httpClient uses a client with a conservative timeout. defaultSender is used when the
+// tenant has no sms_sender configured; it may be a phone number or a Messaging Service SID.
+func NewTwilioProvider(baseURL string, httpClient *http.Client, credentials CredentialsProvider, defaultSender string) *TwilioProvider {
+	if baseURL == "" {
+		baseURL = defaultTwilioBaseURL
+	}
+	if httpClient == nil {
+		httpClient = newDefaultHTTPClient()
+	}
+
+	return &TwilioProvider{
+		baseURL:       strings.TrimRight(baseURL, "/"),
+		httpClient:    httpClient,
+		credentials:   credentials,
+		defaultSender: defaultSender,
+	}
+}
+
+// SendOTP creates a Twilio message carrying the code.
+func (p *TwilioProvider) SendOTP(ctx context.Context, req otp.SMSRequest) (*otp.SMSResult, error) {
+	sender := req.Sender
+	if sender == "" {
+		sender = p.defaultSender
+	}
+	if sender == "" {
+		return nil, &otp.SMSProviderError{
+			Provider: twilioProviderName,
+			Kind:     otp.ErrSMSRejected,
+			Message:  "no sender configured",
+		}
+	}
+
+	apiKey, err := p.credentials.GetSMSAPIKey(ctx, req.TenantID, twilioProviderName)
+	if err != nil {
+		return nil, credentialsError(twilioProviderName, err)
+	}
+	accountSID, authToken, ok := strings.Cut(apiKey, ":")
+	if !ok || accountSID == "" || authToken == "" {
+		return nil, &otp.SMSProviderError{
+			Provider: twilioProviderName,
+			Kind:     otp.ErrSMSInvalidCredentials,
+			Message:  "api key must be AccountSid:AuthToken",
+		}
+	}
+
+	form := url.Values{}
+	form.Set("To", req.Phone)
+	form.Set("Body", twilioMessageBody(req.Template, req.Code))
+	if strings.HasPrefix(sender, "MG") {
+		form.Set("MessagingServiceSid", sender)
+	} else {
+		form.Set("From", sender)
+	}
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", p.baseURL, url.PathEscape(accountSID))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("twilio: build request: %w", err)
+	}
+	httpReq.SetBasicAuth(accountSID, authToken)
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpReq.Header.Set("Accept", "application/json")
+
+	resp, written, err := doProviderRequest(p.httpClient, httpReq)
+	if err != nil {
+		return nil, providerTransportError(twilioProviderName, err, written)
+	}
+	defer resp.Body.Close()
+
+	body, err := readProviderResponse(resp)
+	if err != nil {
+		return nil, providerTransportError(twilioProviderName, err, true)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var parsed twilioErrorResponse
+		_ = json.Unmarshal(body, &parsed)
+		return nil, twilioError(resp.StatusCode, parsed, resp.Header)
+	}
+
+	var parsed twilioMessageResponse
+	if err := json.Unmarshal(body, &parsed); err != nil || parsed.SID == "" {
+		return nil, &otp.SMSProviderError{
+			Provider:   twilioProviderName,
+			Kind:       otp.ErrSMSRejected,
+			StatusCode: resp.StatusCode,
+			Message:    "response has no message sid",
+		}
+	}
+
+	return &otp.SMSResult{
+		Provider:    twilioProviderName,
+		Status:      otp.RequestStatusSent,
+		MessageID:   parsed.SID,
+		RawResponse: twilioRawResponse(body),
+		SentAt:      time.Now().UTC(),
+	}, nil
+}
+
+// twilioMessageBody renders a tenant template containing {code}, or the default body.
+func twilioMessageBody(template string, code string) string {
+	if !strings.Contains(template, twilioCodePlaceholder) {
+		template = defaultTwilioBody
+	}
+	return strings.ReplaceAll(template, twilioCodePlaceholder, code)
+}
+
+// twilioError maps Twilio REST error codes to SMS failure kinds.
+// See https://www.twilio.com/docs/api/errors for the code reference.
+func twilioError(httpStatus int, parsed twilioErrorResponse, header http.Header) error {
+	providerErr := &otp.SMSProviderError{
+		Provider:   twilioProviderName,
+		StatusCode: httpStatus,
+		Message:    parsed.Message,
+	}
+	if parsed.Code != 0 {
+		providerErr.Code = strconv.Itoa(parsed.Code)
+	}
+
+	switch {
+	case parsed.Code == 20003 || parsed.Code == 20005 || httpStatus == http.StatusUnauthorized:
+		providerErr.Kind = otp.ErrSMSInvalidCredentials
+	case parsed.Code == 21211:
+		providerErr.Kind = otp.ErrSMSInvalidRecipient
+	case parsed.Code == 21614:
+		providerErr.Kind = otp.ErrSMSRecipientLandline
+	case parsed.Code == 21612 || parsed.Code == 21610:
+		providerErr.Kind = otp.ErrSMSRecipientUnreachable
+	case parsed.Code == 20429 || httpStatus == http.StatusTooManyRequests || httpStatus >= 500:
+		providerErr.Kind = otp.ErrSMSProviderUnavailable
+		providerErr.Retryable = parsed.Code == 20429 || retryableProviderStatus(httpStatus, header)
+	default:
+		providerErr.Kind = otp.ErrSMSRejected
+	}
+	return providerErr
+}
+
+// twilioRawResponse keeps the decoded message resource but drops the body, which contains the OTP code.
+func twilioRawResponse(body []byte) map[string]interface{} {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil
+	}
+	delete(raw, "body")
+	return raw
+}
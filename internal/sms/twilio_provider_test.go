@@ -0,0 +1,182 @@
+package sms
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-backend-service/internal/otp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testTwilioAccountSID = "ACXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXX"
+	testTwilioAuthToken  = "twilio-auth-token"
+)
+
+// fakeTwilioServer checks basic auth and replays a recorded response chosen by the To number.
+type fakeTwilioServer struct {
+	t         *testing.T
+	responses map[string]recordedResponse
+	form      url.Values
+	path      string
+}
+
+func newFakeTwilioServer(t *testing.T, responses map[string]recordedResponse) (*fakeTwilioServer, *httptest.Server) {
+	t.Helper()
+
+	fake := &fakeTwilioServer{t: t, responses: responses}
+	server := httptest.NewServer(http.HandlerFunc(fake.handle))
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (s *fakeTwilioServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.path = r.URL.Path
+	assert.NoError(s.t, r.ParseForm())
+	s.form = r.PostForm
+
+	response, ok := s.responses[r.PostForm.Get("To")]
+	if sid, token, hasAuth := r.BasicAuth(); !hasAuth || sid != testTwilioAccountSID || token != testTwilioAuthToken {
+		response, ok = recordedResponse{status: http.StatusUnauthorized, fixture: "authenticate.json"}, true
+	}
+	if !ok {
+		response = recordedResponse{status: http.StatusCreated, fixture: "message_created.json"}
+	}
+
+	body, err := os.ReadFile(filepath.Join("testdata", "twilio", response.fixture))
+	assert.NoError(s.t, err)
+	w.Header().Set("Content-Type", "application/json")
+	if response.retryAfter != "" {
+		w.Header().Set("Retry-After", response.retryAfter)
+	}
+	w.WriteHeader(response.status)
+	_, _ = w.Write(body)
+}
+
+func twilioTestRequest(phone string) otp.SMSRequest {
+	return otp.SMSRequest{
+		RequestID: "request-twilio",
+		TenantID:  88,
+		Phone:     phone,
+		Code:      "123456",
+		Provider:  twilioProviderName,
+	}
+}
+
+func twilioTestCredentials() *fakeCredentials {
+	return &fakeCredentials{apiKey: testTwilioAccountSID + ":" + testTwilioAuthToken}
+}
+
+func TestTwilioProviderSendOTPSuccess(t *testing.T) {
+	fake, server := newFakeTwilioServer(t, nil)
+	credentials := twilioTestCredentials()
+	provider := NewTwilioProvider(server.URL, server.Client(), credentials, "+15557122661")
+
+	result, err := provider.SendOTP(context.Background(), twilioTestRequest("+15558675310"))
+
+	require.NoError(t, err)
+	assert.Equal(t, twilioProviderName, credentials.provider)
+	assert.Equal(t, "/2010-04-01/Accounts/"+testTwilioAccountSID+"/Messages.json", fake.path)
+	assert.Equal(t, "+15558675310", fake.form.Get("To"))
+	assert.Equal(t, "+15557122661", fake.form.Get("From"))
+	assert.Equal(t, "Your verification code is: 123456", fake.form.Get("Body"))
+	assert.Equal(t, twilioProviderName, result.Provider)
+	assert.Equal(t, otp.RequestStatusSent, result.Status)
+	assert.Equal(t, "SM3a3b4c5d6e7f8a9b0c1d2e3f4a5b6c7d", result.MessageID)
+	assert.Equal(t, "queued", result.RawResponse["status"])
+	assert.NotContains(t, result.RawResponse, "body")
+}
+
+func TestTwilioProviderSenderAndTemplateFromRequest(t *testing.T) {
+	fake, server := newFakeTwilioServer(t, nil)
+	provider := NewTwilioProvider(server.URL, server.Client(), twilioTestCredentials(), "+15557122661")
+	req := twilioTestRequest("+15558675310")
+	req.Sender = "MG0123456789abcdef0123456789abcdef"
+	req.Template = "{code} is your Acme login code"
+
+	_, err := provider.SendOTP(context.Background(), req)
+
+	require.NoError(t, err)
+	assert.Equal(t, "MG0123456789abcdef0123456789abcdef", fake.form.Get("MessagingServiceSid"))
+	assert.Empty(t, fake.form.Get("From"))
+	assert.Equal(t, "123456 is your Acme login code", fake.form.Get("Body"))
+}
+
+func TestTwilioProviderSendOTPErrors(t *testing.T) {
+	_, server := newFakeTwilioServer(t, map[string]recordedResponse{
+		"+1555000":     {status: http.StatusBadRequest, fixture: "invalid_to.json"},
+		"+15550001111": {status: http.StatusBadRequest, fixture: "landline.json"},
+		"+15550002222": {status: http.StatusBadRequest, fixture: "unreachable.json"},
+		"+15550003333": {status: http.StatusTooManyRequests, fixture: "too_many_requests.json"},
+		"+15550004444": {status: http.StatusBadRequest, fixture: "invalid_from.json"},
+		"+15550005555": {status: http.StatusInternalServerError, fixture: "service_unavailable.json"},
+		"+15550006666": {status: http.StatusServiceUnavailable, fixture: "service_unavailable.json", retryAfter: "1"},
+	})
+
+	tests := []struct {
+		name        string
+		phone       string
+		credentials *fakeCredentials
+		kind        error
+		code        string
+		retryable   bool
+	}{
+		{name: "authentication failure", phone: "+15558675310", credentials: &fakeCredentials{apiKey: testTwilioAccountSID + ":wrong"}, kind: otp.ErrSMSInvalidCredentials, code: "20003"},
+		{name: "invalid to", phone: "+1555000", kind: otp.ErrSMSInvalidRecipient, code: "21211"},
+		{name: "landline", phone: "+15550001111", kind: otp.ErrSMSRecipientLandline, code: "21614"},
+		{name: "unreachable", phone: "+15550002222", kind: otp.ErrSMSRecipientUnreachable, code: "21612"},
+		{name: "too many requests", phone: "+15550003333", kind: otp.ErrSMSProviderUnavailable, code: "20429", retryable: true},
+		{name: "invalid from", phone: "+15550004444", kind: otp.ErrSMSRejected, code: "21606"},
+		{name: "server error", phone: "+15550005555", kind: otp.ErrSMSProviderUnavailable, code: "20500"},
+		{name: "unavailable with retry-after", phone: "+15550006666", kind: otp.ErrSMSProviderUnavailable, code: "20500", retryable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credentials := tt.credentials
+			if credentials == nil {
+				credentials = twilioTestCredentials()
+			}
+			provider := NewTwilioProvider(server.URL, server.Client(), credentials, "+15557122661")
+
+			result, err := provider.SendOTP(context.Background(), twilioTestRequest(tt.phone))
+
+			require.Nil(t, result)
+			require.ErrorIs(t, err, tt.kind)
+			var providerErr *otp.SMSProviderError
+			require.ErrorAs(t, err, &providerErr)
+			assert.Equal(t, twilioProviderName, providerErr.Provider)
+			assert.Equal(t, tt.code, providerErr.Code)
+			assert.Equal(t, tt.retryable, providerErr.Retryable)
+			assert.NotContains(t, err.Error(), testTwilioAuthToken)
+		})
+	}
+}
+
+func TestTwilioProviderMalformedCredentials(t *testing.T) {
+	provider := NewTwilioProvider("http://127.0.0.1:1", nil, &fakeCredentials{apiKey: "token-without-sid"}, "+15557122661")
+
+	result, err := provider.SendOTP(context.Background(), twilioTestRequest("+15558675310"))
+
+	require.Nil(t, result)
+	assert.ErrorIs(t, err, otp.ErrSMSInvalidCredentials)
+	assert.NotContains(t, err.Error(), "token-without-sid")
+}
+
+func TestTwilioProviderMissingSender(t *testing.T) {
+	credentials := twilioTestCredentials()
+	provider := NewTwilioProvider("http://127.0.0.1:1", nil, credentials, "")
+
+	result, err := provider.SendOTP(context.Background(), twilioTestRequest("+15558675310"))
+
+	require.Nil(t, result)
+	assert.ErrorIs(t, err, otp.ErrSMSRejected)
+	assert.Equal(t, 0, credentials.calls)
+}
@@ -9,6 +9,9 @@ type AppError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
 	Details string `json:"details,omitempty"`
+
+	// RetryAfterSeconds tells clients of a throttled request when to retry; zero omits it.
+	RetryAfterSeconds int `json:"retry_after_seconds,omitempty"`
 }
 
 // Error implements the error interface
@@ -49,5 +52,8 @@ type ErrorResponse struct {
 	Code      int    `json:"code"`
 	Details   string `json:"details,omitempty"`
 	RequestID string `json:"request_id,omitempty"`
+
+	// RetryAfterSeconds mirrors the Retry-After header on 429 responses.
+	RetryAfterSeconds int `json:"retry_after_seconds,omitempty"`
 }
 